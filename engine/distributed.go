@@ -0,0 +1,177 @@
+package engine
+
+import (
+	"TransactionRwset/fuzz"
+	nodecontrol "TransactionRwset/nodeControl"
+	"TransactionRwset/utils"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+type WorkerOptions struct {
+	Sync        fuzz.SyncOptions
+	SharedChain bool          // 使用已运行的链，仅部署本 worker 的合约实例
+	SDKConfig   string        // 连接本 worker 所用链的 SDK 配置，为空时使用认证模式对应的配置
+	PoolFile    string        // 主 worker 从文件加载初始种子池，为空时生成
+	PoolWait    time.Duration // 非主 worker 等待初始种子池的最长时间
+}
+
+// worker 名称同时用作合约名后缀与共享目录下的子目录名
+var workerIDPattern = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// RunWorker 以分布式 worker 身份运行：主 worker 生成并发布初始种子池，其余 worker 等待后读取，
+// 各自对分配到的交易对进行变异，并与其他 worker 同步新发现的冲突种子
+func RunWorker(contractPath string, opts *WorkerOptions) error {
+	if !workerIDPattern.MatchString(opts.Sync.WorkerID) {
+		return fmt.Errorf("invalid worker id %q, only letters, digits and _ are allowed", opts.Sync.WorkerID)
+	}
+	if opts.SDKConfig != "" {
+		if err := nodecontrol.SetSDKConfPath(opts.SDKConfig); err != nil {
+			return err
+		}
+	}
+
+	var err error
+	fuzz.Sync, err = fuzz.NewSync(&opts.Sync)
+	if err != nil {
+		return err
+	}
+
+	ContractNameSuffix = "_" + opts.Sync.WorkerID
+	if opts.SharedChain {
+		// 共享链不由本程序启动，也无法保存账本快照
+		GetContractInfoAndPrepare(contractPath)
+		err = deployContract()
+	} else {
+		err = Start(contractPath)
+	}
+	if err != nil {
+		return err
+	}
+	utils.Log.Log(utils.ExecutionLog, fmt.Sprintf("worker %s (%d/%d) syncing with %s", opts.Sync.WorkerID, opts.Sync.Index, opts.Sync.Workers, opts.Sync.Dir))
+
+	var pool *fuzz.FuncPairSeedsPool
+	switch {
+	case fuzz.Sync.Main() && opts.PoolFile != "":
+		if pool, err = fuzz.LoadPairSeedPoolFromFile(opts.PoolFile); err != nil {
+			return err
+		}
+	case fuzz.Sync.Main():
+		pool = GenerateSeeds()
+	default:
+		utils.Log.Section(utils.ExecutionLog, "等待主 worker 发布种子池")
+		if pool, err = fuzz.Sync.WaitForPool(opts.PoolWait); err != nil {
+			return err
+		}
+	}
+	if fuzz.Sync.Main() {
+		if err := fuzz.Sync.PublishPool(pool); err != nil {
+			return err
+		}
+	}
+
+	fuzz.Sync.Partition(pool)
+	if err := pool.SaveToFile(utils.Log.BaseDir); err != nil {
+		fmt.Println(err)
+	}
+
+	HandleFuncPairSeedsPool(pool)
+	return nil
+}
+
+type DistributeOptions struct {
+	Workers     int
+	SyncDir     string   // 每次运行在其下创建以时间命名的子目录作为共享目录
+	RunDir      string   // 本次运行的共享目录，由 Distribute 创建
+	ReleaseDirs []string // 每个 worker 使用的发布包目录，为空时所有 worker 共享一条已运行的链
+	SDKConfigs  []string // 与 ReleaseDirs 对应的 SDK 配置
+	Seed        int64    // 主随机种子，各 worker 使用 Seed+编号
+	WorkerArgs  []string // 原样传给各 worker 的参数
+}
+
+// worker 进程的运行结果
+type WorkerRun struct {
+	ID       string
+	Err      error
+	Duration time.Duration
+}
+
+// Distribute 在本机启动多个 worker 进程，等待全部结束后返回各 worker 的运行结果
+// 每次运行使用新的共享目录，不会读到上一次运行的种子池、认领标记与队列
+func Distribute(opts *DistributeOptions) ([]*WorkerRun, error) {
+	if opts.Workers <= 0 {
+		return nil, fmt.Errorf("at least one worker is required")
+	}
+	if len(opts.ReleaseDirs) > 0 && len(opts.ReleaseDirs) != opts.Workers {
+		return nil, fmt.Errorf("%d release dirs given for %d workers", len(opts.ReleaseDirs), opts.Workers)
+	}
+	if len(opts.SDKConfigs) != len(opts.ReleaseDirs) {
+		return nil, fmt.Errorf("every release dir needs its own sdk config (%d release dirs, %d sdk configs)", len(opts.ReleaseDirs), len(opts.SDKConfigs))
+	}
+	opts.RunDir = filepath.Join(opts.SyncDir, time.Now().Format("20060102_150405"))
+	if err := os.MkdirAll(opts.RunDir, os.ModePerm); err != nil {
+		return nil, fmt.Errorf("failed to create sync directory: %v", err)
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+
+	// 先创建全部日志文件，出错时还没有启动任何 worker 进程
+	// 各 worker 的输出保存在共享目录下，避免多个进程的输出交错
+	outputs := make([]*os.File, opts.Workers)
+	for i := range outputs {
+		output, err := os.Create(filepath.Join(opts.RunDir, fmt.Sprintf("worker%d.log", i)))
+		if err != nil {
+			for _, created := range outputs[:i] {
+				created.Close()
+			}
+			return nil, fmt.Errorf("failed to create worker log: %v", err)
+		}
+		outputs[i] = output
+	}
+
+	runs := make([]*WorkerRun, opts.Workers)
+	var wg sync.WaitGroup
+	for i := 0; i < opts.Workers; i++ {
+		id := fmt.Sprintf("worker%d", i)
+		args := []string{"worker", "-sync-dir", opts.RunDir, "-id", id, "-index", fmt.Sprint(i), "-workers", fmt.Sprint(opts.Workers)}
+		if len(opts.ReleaseDirs) > 0 {
+			args = append(args, "-release-dir", opts.ReleaseDirs[i], "-sdk-config", opts.SDKConfigs[i])
+		} else {
+			args = append(args, "-shared-chain")
+		}
+		if opts.Seed != 0 {
+			args = append(args, "-seed", fmt.Sprint(opts.Seed+int64(i)))
+		}
+		args = append(args, opts.WorkerArgs...)
+
+		output := outputs[i]
+		cmd := exec.Command(executable, args...)
+		cmd.Stdout = output
+		cmd.Stderr = output
+
+		run := &WorkerRun{ID: id}
+		runs[i] = run
+		fmt.Printf("starting %s: %s\n", id, strings.Join(args, " "))
+
+		wg.Add(1)
+		go func() {
+			defer utils.RecoverPanic()
+			defer wg.Done()
+			defer output.Close()
+			start := time.Now()
+			run.Err = cmd.Run()
+			run.Duration = time.Since(start)
+		}()
+	}
+	wg.Wait()
+	return runs, nil
+}
@@ -4,8 +4,12 @@ import (
 	"TransactionRwset/fuzz"
 	getfuncInfo "TransactionRwset/info"
 	nodecontrol "TransactionRwset/nodeControl"
+	"encoding/json"
 	"fmt"
-	"strings"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
 	"time"
 
 	"TransactionRwset/utils"
@@ -13,64 +17,223 @@ import (
 
 func GetContractInfoAndPrepare(contractPath string) {
 	utils.GlobalContractInfo = getfuncInfo.MakeGlobalContractInfo(contractPath)
+	// 合约解析或编译失败时集群尚未启动，直接退出
+	if utils.GlobalContractInfo == nil {
+		fmt.Printf("Error loading contract %s\n", contractPath)
+		os.Exit(1)
+	}
+	utils.GlobalContractInfo.ContractName += ContractNameSuffix
 
 	var err error
 	utils.Log, err = utils.NewLogger(utils.GlobalContractInfo.ContractName)
+	// 日志目录无法创建时后续记录都无法进行，集群尚未启动，直接退出
 	if err != nil {
 		fmt.Printf("Error writing conflict log: %v\n", err)
+		os.Exit(1)
 	}
 
+	prepareReplayRecord()
+
 	var funcNameList []string
 
 	for key, _ := range utils.GlobalContractInfo.ContractFuncMap {
 		funcNameList = append(funcNameList, key)
 	}
+	sort.Strings(funcNameList)
+
+	summary := utils.GlobalContractInfo.Summary()
+	utils.Log.Section(utils.ExecutionLog, "获取合约信息")
+	utils.Log.Emit(&utils.Event{
+		Level:     utils.LevelInfo,
+		Phase:     utils.ExecutionLog,
+		Type:      utils.EventContractInfo,
+		Message:   utils.GlobalContractInfo.ContractName,
+		Functions: funcNameList,
+		Fields: map[string]interface{}{
+			"contract_byte_code_path": summary.ContractByteCodePath,
+			"runtime":                 summary.Runtime,
+			"param_types":             summary.ParamTypes,
+		},
+	})
+
+	saveContractInfo()
+}
+
+// 记录本次运行的随机种子，并开始记录随机决策（回放模式下同时进行比对）
+func prepareReplayRecord() {
+	err := utils.SaveRunSeed(utils.Log.BaseDir)
+	if err != nil {
+		fmt.Println(err)
+	}
 
-	utils.Log.Log(utils.ExecutionLog, "==================================  获取合约信息  ======================================")
-	utils.Log.Log(utils.ExecutionLog, "	ContractName          : "+utils.GlobalContractInfo.ContractName)
-	utils.Log.Log(utils.ExecutionLog, "	ContractByteCodePath  : "+utils.GlobalContractInfo.ContractByteCodePath)
-	utils.Log.Log(utils.ExecutionLog, "	ContractFuncList      : "+strings.Join(funcNameList, " "))
-	utils.Log.Log(utils.ExecutionLog, "	ParamAndCandidateTypes:\n"+fmt.Sprint(utils.GlobalContractInfo.ParamAndCandidateTypes))
-	utils.Log.Log(utils.ExecutionLog, "=======================================================================================")
+	utils.Decisions, err = utils.NewDecisionRecorder(utils.Log.BaseDir, utils.ReplayDecisions)
+	if err != nil {
+		fmt.Println(err)
+	}
+
+	event := &utils.Event{
+		Level:   utils.LevelInfo,
+		Phase:   utils.ExecutionLog,
+		Type:    utils.EventRunSeed,
+		Message: fmt.Sprintf("seed: %d", utils.MasterSeed),
+		Fields:  map[string]interface{}{"seed": utils.MasterSeed},
+	}
+	if utils.ReplayOf != "" {
+		event.Fields["replay_of"] = utils.ReplayOf
+	}
+	utils.Log.Emit(event)
 }
 
+// 将合约信息摘要保存至结果目录，供报告生成使用
+func saveContractInfo() {
+	err := utils.GlobalContractInfo.SaveToFile(filepath.Join(utils.Log.BaseDir, utils.ContractInfoFileName))
+	if err != nil {
+		fmt.Println(err)
+	}
+}
+
+// 节点是否由本程序启动，只有由本程序启动的节点才在结束时停止
+var chainStarted bool
+
+// 节点日志目录下保存节点状态的文件名
+const NodeStatusFileName = "status.json"
+
+// 部署合约后保存账本快照，每次冲突实验前恢复，参数可通过命令行设置
+var SnapshotLedger bool
+
+// 合约名后缀，分布式模式下各 worker 在共享链上部署各自的合约实例，结果目录也随之区分
+var ContractNameSuffix string
+
 // 启动节点，并进行合约信息初步获取工作
-func Start(contractPath string) {
+func Start(contractPath string) error {
 	GetContractInfoAndPrepare(contractPath)
+	return startChain(utils.Log.BaseDir, SnapshotLedger)
+}
+
+// 启动集群并部署合约，节点日志与账本快照保存在 baseDir 下
+func startChain(baseDir string, snapshot bool) error {
 	Log := utils.Log
 
-	// 启动节点
-	nodecontrol.ChainmakerController.StartChainmaker()
-	Log.Log(utils.ExecutionLog, "====================================  部署合约  ========================================")
-	txId, err := nodecontrol.ChainmakerController.UserContractClaimCreate(utils.GlobalContractInfo.ContractName, utils.GlobalContractInfo.ContractByteCodePath, true, false)
+	// 启动节点，节点日志保存至结果目录
+	Log.Section(utils.ExecutionLog, "启动节点")
+	options := nodecontrol.ClusterOptions
+	options.LogDir = filepath.Join(baseDir, utils.NodeLogDirName)
+
+	// 按认证模式生成的配置模板仅用于本次启动
+	templateDir := options.TemplateDir
+	authTemplateDir, err := nodecontrol.AuthTemplateDir(options, filepath.Join(baseDir, utils.AuthConfigDirName))
 	if err != nil {
-		fmt.Println(err)
+		return fmt.Errorf("failed to prepare %s node configs: %v", nodecontrol.AuthTypeName(), err)
+	}
+	options.TemplateDir = authTemplateDir
+	err = nodecontrol.ChainmakerController.StartChainmaker()
+	options.TemplateDir = templateDir
+	if err != nil {
+		return fmt.Errorf("failed to start chainmaker cluster: %v", err)
+	}
+	chainStarted = true
+
+	if err := deployContract(); err != nil {
+		return err
 	}
 
-	time.Sleep(5 * time.Second)
+	// 此后的探测交易与实验都会改变链状态，在此保存快照
+	if snapshot {
+		Log.Section(utils.ExecutionLog, "保存账本快照")
+		if err := nodecontrol.ChainmakerController.SnapshotLedger(filepath.Join(baseDir, utils.LedgerSnapshotDirName)); err != nil {
+			return fmt.Errorf("failed to snapshot ledger: %v", err)
+		}
+	}
+	return nil
+}
+
+// 部署合约并等待部署交易上链
+func deployContract() error {
+	utils.Log.Section(utils.ExecutionLog, "部署合约")
+	return manageContract("contract_deploy", func() (string, error) {
+		txId, err := nodecontrol.ChainmakerController.UserContractClaimCreate(utils.GlobalContractInfo.ContractName, utils.GlobalContractInfo.ContractByteCodePath, true, false)
+		if err != nil {
+			return "", fmt.Errorf("failed to deploy contract: %v", err)
+		}
+		return txId, nil
+	})
+}
+
+// 将已部署的合约升级为当前合约信息对应的版本并等待升级交易上链，返回升级交易 ID
+func upgradeContract(version string) (string, error) {
+	utils.Log.Section(utils.ExecutionLog, "升级合约")
+	var txId string
+	err := manageContract("contract_upgrade", func() (string, error) {
+		var err error
+		txId, err = nodecontrol.ChainmakerController.UserContractUpgrade(utils.GlobalContractInfo.ContractName, utils.GlobalContractInfo.ContractByteCodePath, version, true)
+		return txId, err
+	})
+	return txId, err
+}
+
+// 发送合约管理交易，等待其所在区块上链并记录结果
+func manageContract(eventType string, send func() (string, error)) error {
+	Log := utils.Log
+
+	start := time.Now()
+	height, err := nodecontrol.ChainmakerController.Client.GetCurrentBlockHeight()
+	if err != nil {
+		return fmt.Errorf("failed to query block height: %v", err)
+	}
+
+	txId, err := send()
+	if err != nil {
+		return err
+	}
+
+	// 等待合约管理交易所在区块上链
+	if err := nodecontrol.ChainmakerController.WaitForBlock(height+1, nodecontrol.ClusterOptions.ReadyTimeout); err != nil {
+		return err
+	}
 
 	tx := nodecontrol.TestContractGetTxByTxId(txId)
-	Log.Log(utils.ExecutionLog, "Claim Txid: "+txId)
-	Log.Log(utils.ExecutionLog, fmt.Sprintf("result code:%d, msg:%s\n", tx.Transaction.Result.Code, tx.Transaction.Result.Code.String()))
-	Log.Log(utils.ExecutionLog, "=======================================================================================")
+	if tx == nil {
+		return fmt.Errorf("contract manage tx %s not found", txId)
+	}
+	Log.Emit(&utils.Event{
+		Level:      utils.LevelInfo,
+		Phase:      utils.ExecutionLog,
+		Type:       eventType,
+		Message:    "Claim Txid: " + txId,
+		DurationMs: time.Since(start).Milliseconds(),
+		Fields: map[string]interface{}{
+			"tx_id":       txId,
+			"result_code": tx.Transaction.Result.Code.String(),
+		},
+	})
+	return nil
 }
 
 // 交易种子、交易对种子生成工作
 func GenerateSeeds() *fuzz.FuncPairSeedsPool {
 	Log := utils.Log
 
-	Log.Log(utils.ExecutionLog, "===========================  确认所有参数的可能使用的类型  ==============================")
+	Log.Section(utils.ExecutionLog, "确认所有参数的可能使用的类型")
 	fuzz.ConfirmAllInputParamType()
-	Log.Log(utils.ExecutionLog, "=======================================================================================")
-	Log.Log(utils.ExecutionLog, "===========================  打印所有参数的可能使用的类型  ==============================")
-	Log.Log(utils.ExecutionLog, "	ParamAndCandidateTypes: "+fmt.Sprint(utils.GlobalContractInfo.ParamAndCandidateTypes))
-	Log.Log(utils.ExecutionLog, "=======================================================================================")
-	Log.Log(utils.ExecutionLog, "==================================  生成种子池  ========================================")
+	Log.Emit(&utils.Event{
+		Level:  utils.LevelInfo,
+		Phase:  utils.ExecutionLog,
+		Type:   utils.EventParamConfirm,
+		Fields: map[string]interface{}{"param_types": utils.GlobalContractInfo.Summary().ParamTypes},
+	})
+	saveContractInfo()
+
+	Log.Section(utils.ExecutionLog, "生成种子池")
 	funcSeedsPool := fuzz.NewFuncSeedsPool()
+
+	if fuzz.GasSearchExecs > 0 {
+		Log.Section(utils.ExecutionLog, "搜索 gas 消耗最高的输入")
+		fuzz.SearchMaxGas(funcSeedsPool)
+	}
 	// 打印种子池结果（将其打入一个文件中）
 	funcSeedsPool.PrintFuncSeedsPool()
-	Log.Log(utils.ExecutionLog, "=======================================================================================")
-	Log.Log(utils.ExecutionLog, "===============================  生成交易对种子池  ======================================")
+
+	Log.Section(utils.ExecutionLog, "生成交易对种子池")
 	funcPairSeedsPool := fuzz.NewFuncPairSeedsPool(funcSeedsPool)
 	// 打印交易对种子池
 	funcPairSeedsPool.PrintFuncPairSeedsPool()
@@ -80,7 +243,13 @@ func GenerateSeeds() *fuzz.FuncPairSeedsPool {
 	if err != nil {
 		fmt.Println(err)
 	}
-	Log.Log(utils.ExecutionLog, "=======================================================================================")
+
+	// 生成函数对冲突热力图与热点 key 排名图
+	saveConflictMap(funcPairSeedsPool)
+	saveCorpusStats(funcPairSeedsPool)
+	saveGasProfile()
+	saveLatencyProfile()
+	saveEventMismatches()
 	return funcPairSeedsPool
 }
 
@@ -88,32 +257,244 @@ func GenerateSeeds() *fuzz.FuncPairSeedsPool {
 // 可变异种子进行变异
 func HandleFuncPairSeedsPool(pool *fuzz.FuncPairSeedsPool) {
 	Log := utils.Log
-	Log.Log(utils.ExecutionLog, "=============================  冲突交易集测试/交易对变异  ===============================")
-	Log.Log(utils.ExecutionLog, "=======================================================================================")
+	Log.Section(utils.ExecutionLog, "冲突交易集测试/交易对变异")
 
+	fuzz.Schedule.Start()
 	round := 0
-	for pool.ConflictSeeds.Len() > 0 || pool.MutateSeeds.Len() > 0 {
-		if pool.ConflictSeeds.Len() > 0 {
-			Log.Log(utils.ExecutionLog, fmt.Sprintf("round:[%d] 使用冲突交易对种子进行测试", round))
-			Log.Log(utils.ConflictLog, fmt.Sprintf("=======================================  round:[%d]  =======================================", round))
-			pool.ConflictTxsFirstSeedInPool()
-			Log.Log(utils.ConflictLog, "============================================================================================")
-			Log.Log(utils.ExecutionLog, fmt.Sprintf("round:[%d] 冲突交易对种子测试结束！", round))
-			continue
+rounds:
+	for {
+		for pool.ConflictSeeds.Len() > 0 || pool.MutateSeeds.Len() > 0 {
+			// 分布式模式下定期导入其他 worker 发布的冲突种子
+			if fuzz.Sync.Due() {
+				fuzz.Sync.Import(pool)
+			}
+
+			// 节点崩溃后的实验结果没有意义，停止测试
+			if err := nodecontrol.ChainmakerController.CheckCluster(); err != nil {
+				Log.Emit(&utils.Event{
+					Level:   utils.LevelError,
+					Phase:   utils.ExecutionLog,
+					Type:    utils.EventNodeCrashed,
+					Message: fmt.Sprintf("round:[%d] %v", round, err),
+				})
+				break rounds
+			}
+
+			if pool.ConflictSeeds.Len() > 0 {
+				Log.Log(utils.ExecutionLog, fmt.Sprintf("round:[%d] 使用冲突交易对种子进行测试", round))
+				Log.Section(utils.ConflictLog, fmt.Sprintf("round:[%d]", round))
+				if err := pool.ConflictTxsFirstSeedInPool(); err != nil {
+					Log.Emit(&utils.Event{
+						Level:   utils.LevelError,
+						Phase:   utils.ExecutionLog,
+						Type:    utils.EventLedgerRestore,
+						Message: fmt.Sprintf("round:[%d] %v", round, err),
+					})
+					break rounds
+				}
+				Log.Log(utils.ExecutionLog, fmt.Sprintf("round:[%d] 冲突交易对种子测试结束！", round))
+				continue
+			}
+
+			if pool.MutateSeeds.Len() > 0 {
+				// 超出全局时间预算后不再变异
+				if fuzz.Schedule.Expired() {
+					Log.Warn(utils.ExecutionLog, fmt.Sprintf("round:[%d] 变异时间预算耗尽，剩余 [%d] 个可变异种子退出变异", round, pool.MutateSeeds.Len()))
+					pool.RetireAll(fuzz.RetiredTimeBudget)
+					continue
+				}
+
+				Log.Log(utils.ExecutionLog, fmt.Sprintf("round:[%d] 对可变异种子进行变异", round))
+				Log.Section(utils.FuzzLog, fmt.Sprintf("round:[%d]", round))
+				pool.MutateNextSeedInPool()
+				Log.Log(utils.ExecutionLog, fmt.Sprintf("round:[%d] 可变异种子本轮变异结束！", round))
+				round++
+			}
 		}
 
-		if pool.MutateSeeds.Len() > 0 {
-			Log.Log(utils.ExecutionLog, fmt.Sprintf("round:[%d] 对可变异种子进行变异", round))
-			Log.Log(utils.FuzzLog, fmt.Sprintf("=======================================  round:[%d]  =======================================", round))
-			pool.MutateFirstSeedInPool()
-			Log.Log(utils.FuzzLog, "============================================================================================")
-			Log.Log(utils.ExecutionLog, fmt.Sprintf("round:[%d] 可变异种子本轮变异结束！", round))
+		// 本地队列清空后等待其他 worker 的种子，单机模式下直接结束
+		if fuzz.Sync.WaitForSeeds(pool) == 0 {
+			break
 		}
 	}
+	fuzz.Sync.Finish()
+
+	// 变异过程中可能发现新的冲突，重新生成冲突图
+	saveConflictMap(pool)
+	saveCorpusStats(pool)
+	saveMutatorStats()
+	saveGasProfile()
+	fuzz.Gas.LogProfile()
+	saveLatencyProfile()
+	saveEventMismatches()
 
 }
 
-// 程序结束
+// 保存读写集形状语料统计
+func saveCorpusStats(pool *fuzz.FuncPairSeedsPool) {
+	if pool.Corpus == nil {
+		return
+	}
+
+	err := pool.Corpus.SaveStats(filepath.Join(utils.Log.BaseDir, fuzz.CorpusStatsFileName))
+	if err != nil {
+		fmt.Println(err)
+	}
+	pool.Corpus.LogStats()
+}
+
+// 保存各函数的 gas 统计与 gas 异常
+func saveGasProfile() {
+	err := fuzz.Gas.Save(filepath.Join(utils.Log.BaseDir, fuzz.GasProfileFileName))
+	if err != nil {
+		fmt.Println(err)
+	}
+}
+
+// 保存各函数的探测耗时统计与超时输入
+func saveLatencyProfile() {
+	err := fuzz.Latency.Save(filepath.Join(utils.Log.BaseDir, fuzz.LatencyProfileFileName))
+	if err != nil {
+		fmt.Println(err)
+	}
+}
+
+// 保存合约事件与写集不一致的检查结果
+func saveEventMismatches() {
+	err := fuzz.EventOracle.Save(filepath.Join(utils.Log.BaseDir, fuzz.EventOracleFileName))
+	if err != nil {
+		fmt.Println(err)
+	}
+}
+
+// 保存各变异算子的使用次数与效果
+func saveMutatorStats() {
+	err := utils.SaveMutatorStats(filepath.Join(utils.Log.BaseDir, utils.MutatorStatsFileName))
+	if err != nil {
+		fmt.Println(err)
+	}
+
+	for _, stat := range utils.MutatorStats() {
+		utils.Log.Emit(&utils.Event{
+			Level:   utils.LevelInfo,
+			Phase:   utils.FuzzLog,
+			Type:    utils.EventMutatorStats,
+			Message: stat.Name,
+			Fields: map[string]interface{}{
+				"weight":    stat.Weight,
+				"applied":   stat.Applied,
+				"improved":  stat.Improved,
+				"conflicts": stat.Conflicts,
+			},
+		})
+	}
+}
+
+// 保存各节点的运行状态，便于排查崩溃的节点
+func saveNodeStatuses() {
+	data, err := json.MarshalIndent(nodecontrol.ChainmakerController.NodeStatuses(), "", "  ")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	err = os.WriteFile(filepath.Join(nodecontrol.ClusterOptions.LogDir, NodeStatusFileName), data, 0644)
+	if err != nil {
+		fmt.Println(err)
+	}
+}
+
+func saveConflictMap(pool *fuzz.FuncPairSeedsPool) {
+	if pool.ConflictMap == nil {
+		return
+	}
+
+	err := pool.ConflictMap.Save(utils.Log.BaseDir)
+	if err != nil {
+		fmt.Println(err)
+	}
+}
+
+// 在链上重放种子池中的单个种子
+// startChain 为 false 时使用已运行的链，要求合约已部署
+func Replay(contractPath string, startChain bool, opts *fuzz.ReplayOptions) (*fuzz.ReplayResult, error) {
+	if startChain {
+		if err := Start(contractPath); err != nil {
+			return nil, err
+		}
+	} else {
+		GetContractInfoAndPrepare(contractPath)
+	}
+
+	utils.Log.Section(utils.ExecutionLog, "重放种子")
+	return fuzz.ReplaySeed(opts)
+}
+
+// 交易信封测试：以种子池中各函数的交易种子为基础，发送修改了交易信封的交易
+// 未指定种子池文件时先生成种子池
+func Envelope(contractPath string, startChain bool, opts *fuzz.EnvelopeOptions) (*fuzz.EnvelopeReport, error) {
+	if startChain {
+		if err := Start(contractPath); err != nil {
+			return nil, err
+		}
+	} else {
+		GetContractInfoAndPrepare(contractPath)
+	}
+
+	var pool *fuzz.FuncPairSeedsPool
+	if opts.PoolFile != "" {
+		var err error
+		if pool, err = fuzz.LoadPairSeedPoolFromFile(opts.PoolFile); err != nil {
+			return nil, err
+		}
+	} else {
+		pool = GenerateSeeds()
+	}
+
+	utils.Log.Section(utils.ExecutionLog, "交易信封测试")
+	return fuzz.FuzzEnvelope(pool, opts)
+}
+
+var stopOnce sync.Once
+
+// 程序结束，信号、panic 与正常退出都会调用，只执行一次
 func Stop() {
-	nodecontrol.ChainmakerController.StopChainmaker()
+	stopOnce.Do(stop)
+}
+
+func stop() {
+	stopChain()
+
+	if summary := utils.Decisions.Summary(); summary != "" {
+		fmt.Println(summary)
+		utils.Log.Log(utils.ExecutionLog, summary)
+	}
+	if err := utils.Decisions.Close(); err != nil {
+		fmt.Println(err)
+	}
+
+	// 将缓冲中的日志落盘，并渲染文本日志
+	if err := utils.Log.Close(); err != nil {
+		fmt.Println(err)
+	}
+}
+
+// 停止由本程序启动的集群，保存节点状态
+func stopChain() {
+	if !chainStarted {
+		return
+	}
+
+	saveNodeStatuses()
+	if err := nodecontrol.ChainmakerController.StopChainmaker(); err != nil {
+		fmt.Println("停止集群时出错：", err)
+	}
+	chainStarted = false
+
+	// 快照与节点数据一同保留或清理
+	if !nodecontrol.ClusterOptions.KeepData {
+		if err := nodecontrol.ChainmakerController.RemoveLedgerSnapshot(); err != nil {
+			fmt.Println("删除账本快照时出错：", err)
+		}
+	}
 }
@@ -0,0 +1,254 @@
+/*
+	本文件主要用于：
+
+	1. 解析配置矩阵，如 "consensus=TBFT,RAFT;block_tx_capacity=10,100"，
+	   展开为所有配置项取值的组合
+
+	2. 对每个配置组合：
+			a. 按组合改写节点配置，作为集群配置模板启动集群并部署合约
+			b. 保存账本快照，依次对相同的冲突交易对种子进行实验，每次实验前恢复快照
+			c. 停止集群，节点日志、配置与实验结果保存在组合各自的目录中
+
+	3. 汇总各组合的实验结果，生成对比表
+*/
+
+package engine
+
+import (
+	"TransactionRwset/fuzz"
+	nodecontrol "TransactionRwset/nodeControl"
+	"TransactionRwset/utils"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// 配置矩阵中的一个维度：配置项及其取值
+type MatrixDimension struct {
+	Setting string
+	Values  []string
+}
+
+// 配置矩阵中的一个组合
+type MatrixCell struct {
+	Name   string
+	Values map[string]string
+}
+
+type MatrixOptions struct {
+	Dimensions    []MatrixDimension
+	BaseConfigDir string // prepare.sh 生成的节点配置目录
+	MaxSeeds      int    // 每个组合进行实验的冲突种子数，0 表示全部
+}
+
+// 解析配置矩阵，维度之间以 ; 分隔，取值之间以 , 分隔
+func ParseMatrixSpec(spec string) ([]MatrixDimension, error) {
+	dimensions := make([]MatrixDimension, 0)
+	seen := make(map[string]bool)
+
+	for _, part := range strings.Split(spec, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid matrix dimension %q, expected setting=value1,value2", part)
+		}
+		setting := strings.TrimSpace(kv[0])
+		if _, err := nodecontrol.LookupConfigSetting(setting); err != nil {
+			return nil, err
+		}
+		if seen[setting] {
+			return nil, fmt.Errorf("duplicate matrix dimension %s", setting)
+		}
+		seen[setting] = true
+
+		values := make([]string, 0)
+		for _, value := range strings.Split(kv[1], ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+		if len(values) == 0 {
+			return nil, fmt.Errorf("matrix dimension %s has no value", setting)
+		}
+
+		dimensions = append(dimensions, MatrixDimension{Setting: setting, Values: values})
+	}
+
+	if len(dimensions) == 0 {
+		return nil, fmt.Errorf("empty matrix")
+	}
+	return dimensions, nil
+}
+
+// 展开所有组合，前面的维度变化最慢
+func ExpandMatrix(dimensions []MatrixDimension) []*MatrixCell {
+	cells := []*MatrixCell{{Values: map[string]string{}}}
+
+	for _, dimension := range dimensions {
+		expanded := make([]*MatrixCell, 0, len(cells)*len(dimension.Values))
+		for _, cell := range cells {
+			for _, value := range dimension.Values {
+				values := make(map[string]string, len(cell.Values)+1)
+				for k, v := range cell.Values {
+					values[k] = v
+				}
+				values[dimension.Setting] = value
+				expanded = append(expanded, &MatrixCell{Values: values})
+			}
+		}
+		cells = expanded
+	}
+
+	for i, cell := range cells {
+		parts := []string{fmt.Sprintf("%02d", i+1)}
+		for _, dimension := range dimensions {
+			parts = append(parts, cell.Values[dimension.Setting])
+		}
+		cell.Name = strings.Join(parts, "_")
+	}
+	return cells
+}
+
+// 在配置矩阵的每个组合上进行相同的冲突实验
+// pool 为空时在第一个组合上生成种子池，之后所有组合使用其中的冲突种子
+func RunMatrix(contractPath string, pool *fuzz.FuncPairSeedsPool, opts *MatrixOptions) (*fuzz.MatrixResult, error) {
+	cells := ExpandMatrix(opts.Dimensions)
+
+	// 启动前检查所有组合，避免运行到一半才发现配置不可用
+	nodes := make([]int, len(cells))
+	for i, cell := range cells {
+		n, err := nodecontrol.RequiredNodes(cell.Values, nodecontrol.ClusterOptions.Nodes)
+		if err != nil {
+			return nil, fmt.Errorf("cell %s: %v", cell.Name, err)
+		}
+		nodes[i] = n
+	}
+
+	GetContractInfoAndPrepare(contractPath)
+	Log := utils.Log
+	matrixDir := filepath.Join(Log.BaseDir, utils.MatrixDirName)
+	if err := os.MkdirAll(matrixDir, 0755); err != nil {
+		return nil, err
+	}
+
+	result := &fuzz.MatrixResult{}
+	for _, dimension := range opts.Dimensions {
+		result.Settings = append(result.Settings, dimension.Setting)
+	}
+
+	// 组合改写的集群参数在结束后还原
+	options := nodecontrol.ClusterOptions
+	templateDir, nodeCount := options.TemplateDir, options.Nodes
+	defer func() {
+		options.TemplateDir, options.Nodes = templateDir, nodeCount
+	}()
+
+	var seeds []*fuzz.FuncPairSeed
+	for i, cell := range cells {
+		Log.Section(utils.ExecutionLog, fmt.Sprintf("配置组合 [%d/%d] %s", i+1, len(cells), cell.Name))
+		start := time.Now()
+		cellDir := filepath.Join(matrixDir, cell.Name)
+
+		configDir := filepath.Join(cellDir, "config")
+		if err := nodecontrol.GenerateNodeConfigs(opts.BaseConfigDir, configDir, cell.Values); err != nil {
+			result.Add(&fuzz.MatrixRow{Cell: cell.Name, Values: cell.Values, Error: err.Error()})
+			continue
+		}
+		options.TemplateDir = configDir
+		options.Nodes = nodeCount
+		if nodes[i] > 0 {
+			options.Nodes = nodes[i]
+		}
+
+		if err := startChain(cellDir, true); err != nil {
+			result.Add(&fuzz.MatrixRow{Cell: cell.Name, Values: cell.Values, Error: err.Error()})
+			stopChain()
+			continue
+		}
+
+		if seeds == nil {
+			if pool == nil {
+				pool = GenerateSeeds()
+			}
+			seeds = conflictSeeds(pool, opts.MaxSeeds)
+			if len(seeds) == 0 {
+				stopChain()
+				return result, fmt.Errorf("no conflict seed to run the matrix with")
+			}
+		}
+
+		runMatrixCell(cell, cellDir, seeds, result)
+		stopChain()
+
+		Log.Emit(&utils.Event{
+			Level:      utils.LevelInfo,
+			Phase:      utils.ExecutionLog,
+			Type:       utils.EventMatrixCell,
+			Message:    cell.Name,
+			DurationMs: time.Since(start).Milliseconds(),
+			Fields: map[string]interface{}{
+				"values":     cell.Values,
+				"result_dir": cellDir,
+			},
+		})
+	}
+
+	err := result.SaveToFile(filepath.Join(matrixDir, fuzz.MatrixResultFileName), filepath.Join(matrixDir, "matrix.txt"))
+	if err != nil {
+		fmt.Println(err)
+	}
+	Log.Log(utils.ExecutionLog, "配置矩阵对比:\n"+result.String())
+
+	return result, nil
+}
+
+// 在当前组合上依次进行冲突实验，节点异常时跳过剩余种子
+func runMatrixCell(cell *MatrixCell, cellDir string, seeds []*fuzz.FuncPairSeed, result *fuzz.MatrixResult) {
+	for _, seed := range seeds {
+		row := &fuzz.MatrixRow{
+			Cell:      cell.Name,
+			Values:    cell.Values,
+			Seed:      seed.ID(),
+			Functions: seed.SeedOne.FunctionName + " × " + seed.SeedTwo.FunctionName,
+		}
+		result.Add(row)
+
+		if err := nodecontrol.ChainmakerController.CheckCluster(); err != nil {
+			row.Error = err.Error()
+			return
+		}
+		if err := nodecontrol.ChainmakerController.RestoreLedger(); err != nil {
+			row.Error = fmt.Sprintf("failed to restore ledger snapshot: %v", err)
+			return
+		}
+
+		start := time.Now()
+		row.ResultDir = fuzz.ConflictPairSeedExperimentIn(seed, cellDir)
+		row.DurationMs = time.Since(start).Milliseconds()
+
+		outcomes, err := fuzz.LoadExperimentOutcomesFromFile(filepath.Join(row.ResultDir, fuzz.OutcomesFileName))
+		if err != nil {
+			row.Error = err.Error()
+			continue
+		}
+		row.Outcomes = outcomes
+	}
+}
+
+// 种子池中的冲突种子，不从池中移除
+func conflictSeeds(pool *fuzz.FuncPairSeedsPool, max int) []*fuzz.FuncPairSeed {
+	seeds := make([]*fuzz.FuncPairSeed, 0)
+	for e := pool.ConflictSeeds.Front(); e != nil; e = e.Next() {
+		if max > 0 && len(seeds) >= max {
+			break
+		}
+		seeds = append(seeds, e.Value.(*fuzz.FuncPairSeed))
+	}
+	return seeds
+}
@@ -0,0 +1,101 @@
+package engine
+
+import (
+	"TransactionRwset/fuzz"
+	getfuncInfo "TransactionRwset/info"
+	nodecontrol "TransactionRwset/nodeControl"
+	"TransactionRwset/utils"
+	"fmt"
+	"path/filepath"
+	"time"
+)
+
+type UpgradeOptions struct {
+	NewContractPath string // 升级后的合约源码，链上合约名与原合约相同
+	Version         string // 升级后的合约版本
+	MaxSeeds        int    // 执行的交易对种子数，0 表示全部
+}
+
+// RunUpgradeDiff 部署原合约并在其上执行语料，恢复部署后的账本快照后通过升级交易将合约升级为新版本
+// （保留合约状态），再执行相同的语料，比较两个版本的接口与每个种子的冲突签名
+// pool 为空时在原合约上生成种子池
+func RunUpgradeDiff(contractPath string, pool *fuzz.FuncPairSeedsPool, opts *UpgradeOptions) (*fuzz.VersionDiff, error) {
+	if opts.Version == "" || opts.Version == nodecontrol.ClaimVersion {
+		return nil, fmt.Errorf("upgrade version must differ from %s", nodecontrol.ClaimVersion)
+	}
+
+	GetContractInfoAndPrepare(contractPath)
+	Log := utils.Log
+	oldInfo := utils.GlobalContractInfo
+	// 在确认参数类型之前记录，与新版本的静态分析结果对比
+	oldSummary := oldInfo.Summary()
+
+	// 两个版本都从部署后的状态开始执行语料
+	if err := startChain(Log.BaseDir, true); err != nil {
+		return nil, err
+	}
+	if pool == nil {
+		pool = GenerateSeeds()
+	}
+	pairs := pool.AllPairSeeds()
+	if opts.MaxSeeds > 0 && len(pairs) > opts.MaxSeeds {
+		pairs = pairs[:opts.MaxSeeds]
+	}
+	if len(pairs) == 0 {
+		return nil, fmt.Errorf("no pair seed to run on the two versions")
+	}
+
+	Log.Section(utils.ExecutionLog, fmt.Sprintf("在版本 %s 上执行语料", nodecontrol.ClaimVersion))
+	start := time.Now()
+	oldRuns := fuzz.RunVersionCorpus(pairs)
+	Log.Log(utils.ExecutionLog, fmt.Sprintf("%d pair seeds executed in %s", len(pairs), time.Since(start).Round(time.Millisecond)))
+
+	if err := nodecontrol.ChainmakerController.RestoreLedger(); err != nil {
+		return nil, fmt.Errorf("failed to restore ledger snapshot: %v", err)
+	}
+
+	newInfo := getfuncInfo.MakeGlobalContractInfo(opts.NewContractPath)
+	if newInfo == nil {
+		return nil, fmt.Errorf("failed to load contract %s", opts.NewContractPath)
+	}
+	// 升级不改变链上合约名
+	newInfo.ContractName = oldInfo.ContractName
+	utils.GlobalContractInfo = newInfo
+	if err := newInfo.SaveToFile(filepath.Join(Log.BaseDir, "upgraded_"+utils.ContractInfoFileName)); err != nil {
+		fmt.Println(err)
+	}
+
+	txId, err := upgradeContract(opts.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	Log.Section(utils.ExecutionLog, fmt.Sprintf("在版本 %s 上执行语料", opts.Version))
+	start = time.Now()
+	newRuns := fuzz.RunVersionCorpus(pairs)
+	Log.Log(utils.ExecutionLog, fmt.Sprintf("%d pair seeds executed in %s", len(pairs), time.Since(start).Round(time.Millisecond)))
+
+	diff := fuzz.NewVersionDiff(oldSummary, newInfo.Summary())
+	diff.OldVersion, diff.NewVersion, diff.UpgradeTxId = nodecontrol.ClaimVersion, opts.Version, txId
+	for _, pair := range pairs {
+		seedDiff := diff.AddSeed(pair, oldRuns[pair.ID()], newRuns[pair.ID()])
+		if seedDiff.Change == fuzz.VersionUnchanged {
+			continue
+		}
+
+		event := pair.Event(utils.ConflictLog, utils.EventVersionDiff, string(seedDiff.Change))
+		if seedDiff.Change == fuzz.VersionConflictAppeared || seedDiff.Change == fuzz.VersionConflictChanged {
+			event.Level = utils.LevelWarn
+		}
+		event.Fields["old"] = seedDiff.Old
+		event.Fields["new"] = seedDiff.New
+		Log.Emit(event)
+	}
+
+	if err := diff.SaveToFile(filepath.Join(Log.BaseDir, fuzz.VersionDiffFileName)); err != nil {
+		fmt.Println(err)
+	}
+	Log.Log(utils.ExecutionLog, "版本对比:\n"+diff.String())
+
+	return diff, nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"TransactionRwset/engine"
+	"TransactionRwset/fuzz"
+	"TransactionRwset/report"
+	"TransactionRwset/utils"
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// envelope 子命令，返回进程退出码
+// 用法: envelope [-pool <func_pair_seeds_pool.json>] [-variants a,b,...]
+func envelopeCommand(args []string) int {
+	flags := flag.NewFlagSet("envelope", flag.ExitOnError)
+	opts := &fuzz.EnvelopeOptions{}
+	flags.StringVar(&opts.PoolFile, "pool", "", "Path to a saved func_pair_seeds_pool_*.json (empty generates a pool first)")
+	flags.StringVar(&opts.Variants, "variants", "", "Comma separated envelope variants to send (empty sends all: "+strings.Join(fuzz.EnvelopeVariantNames(), ", ")+")")
+	contractPath := flags.String("contract", defaultContractPath, "Path to the contract source (.go), EVM ABI (.abi) or WASM module (.wasm)")
+	startChain := flags.Bool("start", true, "Start the local cluster and deploy the contract first (false uses a running chain)")
+	registerClusterFlags(flags)
+	registerBuildFlags(flags)
+	logLevel := flags.String("log-level", utils.DefaultLogLevel.String(), "Minimum level of events written to the event log (debug, info, warn, error)")
+	flags.Parse(args)
+
+	level, err := utils.ParseLogLevel(*logLevel)
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	utils.DefaultLogLevel = level
+
+	result, err := engine.Envelope(*contractPath, *startChain, opts)
+	if err != nil {
+		fmt.Printf("Error fuzzing transaction envelopes: %v\n", err)
+	} else {
+		fmt.Print(result.String())
+		fmt.Printf("Envelope result saved to: %s\n", utils.Log.BaseDir)
+	}
+	engine.Stop()
+
+	if err != nil {
+		return 1
+	}
+	reportPath, err := report.Generate(utils.Log.BaseDir)
+	if err != nil {
+		fmt.Printf("Error generating report: %v\n", err)
+	} else {
+		fmt.Printf("Report generated: %s\n", reportPath)
+	}
+	return 0
+}
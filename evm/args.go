@@ -0,0 +1,145 @@
+package evm
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"chainmaker.org/chainmaker/common/v2/evmutils"
+	"chainmaker.org/chainmaker/common/v2/evmutils/abi"
+)
+
+// 将交易参数的字节值还原为 Go 值，数组以 JSON 解析，其余保留为字符串
+func decodeRaw(t abi.Type, raw []byte) interface{} {
+	if t.T != abi.SliceTy && t.T != abi.ArrayTy {
+		return string(raw)
+	}
+
+	var values []interface{}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	if err := decoder.Decode(&values); err != nil {
+		// 变异后不再是数组时，作为单个元素处理
+		return []interface{}{string(raw)}
+	}
+	return values
+}
+
+// 将变异得到的任意值转换为 ABI 库可编码的值：
+// 整数为十进制串，地址与字节串为 0x 开头的十六进制串，数组为 []interface{}
+// 取值不合法时按确定规则映射到合法值，保证同一输入得到同一编码
+func toABIArg(t abi.Type, value interface{}) (interface{}, error) {
+	switch t.T {
+	case abi.IntTy, abi.UintTy:
+		return toABIInt(t, value).String(), nil
+	case abi.BoolTy:
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		default:
+			b, err := strconv.ParseBool(strings.TrimSpace(fmt.Sprint(v)))
+			if err != nil {
+				return toABIInt(t, value).Sign() != 0, nil
+			}
+			return b, nil
+		}
+	case abi.StringTy:
+		return fmt.Sprint(value), nil
+	case abi.AddressTy:
+		s := fmt.Sprint(value)
+		if data, ok := hexBytes(s); ok && len(data) == 20 {
+			return "0x" + hex.EncodeToString(data), nil
+		}
+		return "0x" + hex.EncodeToString(evmutils.Keccak256([]byte(s))[12:]), nil
+	case abi.BytesTy:
+		return "0x" + hex.EncodeToString(toBytes(value)), nil
+	case abi.FixedBytesTy, abi.HashTy:
+		size := t.Size
+		if t.T == abi.HashTy {
+			size = 32
+		}
+		data := toBytes(value)
+		fixed := make([]byte, size)
+		copy(fixed, data)
+		return "0x" + hex.EncodeToString(fixed), nil
+	case abi.SliceTy, abi.ArrayTy:
+		values, ok := value.([]interface{})
+		if !ok {
+			values = []interface{}{value}
+		}
+		if t.T == abi.ArrayTy {
+			fixed := make([]interface{}, t.Size)
+			for i := range fixed {
+				if i < len(values) {
+					fixed[i] = values[i]
+				} else {
+					fixed[i] = DefaultValue(*t.Elem)
+				}
+			}
+			values = fixed
+		}
+		args := make([]interface{}, 0, len(values))
+		for _, v := range values {
+			arg, err := toABIArg(*t.Elem, v)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+		}
+		return args, nil
+	default:
+		return nil, fmt.Errorf("unsupported ABI type %s", t.String())
+	}
+}
+
+// 整数取值截断到类型范围内，非数字值取其哈希
+func toABIInt(t abi.Type, value interface{}) *big.Int {
+	n, ok := new(big.Int).SetString(strings.TrimSpace(fmt.Sprint(value)), 0)
+	if !ok {
+		switch v := value.(type) {
+		case bool:
+			n = big.NewInt(0)
+			if v {
+				n = big.NewInt(1)
+			}
+		default:
+			n = new(big.Int).SetBytes(evmutils.Keccak256([]byte(fmt.Sprint(v))))
+		}
+	}
+
+	size := t.Size
+	if size == 0 {
+		size = 256
+	}
+	modulus := new(big.Int).Lsh(big.NewInt(1), uint(size))
+	n.Mod(n, modulus)
+	if t.T == abi.IntTy && n.Cmp(new(big.Int).Rsh(modulus, 1)) >= 0 {
+		n.Sub(n, modulus)
+	}
+	return n
+}
+
+// 十六进制串取其字节，其余值取其字符串的字节
+func toBytes(value interface{}) []byte {
+	s := fmt.Sprint(value)
+	if data, ok := hexBytes(s); ok {
+		return data
+	}
+	return []byte(s)
+}
+
+func hexBytes(s string) ([]byte, bool) {
+	if !strings.HasPrefix(s, "0x") && !strings.HasPrefix(s, "0X") {
+		return nil, false
+	}
+	s = s[2:]
+	if len(s)%2 == 1 {
+		s = "0" + s
+	}
+	data, err := hex.DecodeString(s)
+	return data, err == nil
+}
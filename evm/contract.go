@@ -0,0 +1,334 @@
+/*
+	本文件主要用于：
+
+	1. 读取 solc 编译得到的 EVM 合约：<Name>.abi、十六进制字节码 <Name>.bin，
+	   以及可选的存储布局 <Name>_storage.json（solc --storage-layout）
+
+	2. 由 ABI 得到合约方法、参数名与参数类型，参数类型以 ABI 类型名命名的 go/types 类型表示，
+	   种子生成与变异沿用 Go 合约的流程
+
+	3. 按 ABI 将交易参数编码为调用数据：方法名转换为 4 字节选择器，参数编码后以 data 传递
+*/
+
+package evm
+
+import (
+	"encoding/hex"
+	"fmt"
+	"go/token"
+	"go/types"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"chainmaker.org/chainmaker/common/v2/evmutils/abi"
+	"chainmaker.org/chainmaker/pb-go/v2/common"
+)
+
+const (
+	ABIExt           = ".abi"
+	ByteCodeExt      = ".bin"
+	StorageLayoutExt = "_storage.json"
+
+	// 调用数据在交易参数中的键
+	DataKey = "data"
+)
+
+type Contract struct {
+	ABI          *abi.ABI
+	ABIPath      string
+	ByteCodePath string
+	LayoutPath   string         // 为空表示没有存储布局
+	Layout       *StorageLayout // 为空时读写集键保留为槽位
+
+	Methods map[string]*Method
+
+	mu         sync.Mutex
+	slotNames  map[string]string   // 已还原的槽位名称
+	candidates map[string]struct{} // 交易参数中出现过的值，用于还原映射项
+}
+
+type Method struct {
+	Name     string // ABI 中的方法名，重载方法带有序号后缀
+	Selector string // 4 字节选择器的十六进制串，作为调用方法名
+	Params   []*Param
+}
+
+type Param struct {
+	Name string // ABI 中参数名为空时为 arg<序号>
+	Type abi.Type
+}
+
+// Load 读取 ABI 文件及同目录下同名的字节码与存储布局
+func Load(abiPath string) (*Contract, error) {
+	data, err := os.ReadFile(abiPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ABI: %v", err)
+	}
+	contractABI, err := abi.JSON(strings.NewReader(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ABI %s: %v", abiPath, err)
+	}
+
+	base := strings.TrimSuffix(abiPath, ABIExt)
+	c := &Contract{
+		ABI:          contractABI,
+		ABIPath:      abiPath,
+		ByteCodePath: base + ByteCodeExt,
+		Methods:      make(map[string]*Method),
+		slotNames:    make(map[string]string),
+		candidates:   make(map[string]struct{}),
+	}
+	if _, err := os.Stat(c.ByteCodePath); err != nil {
+		return nil, fmt.Errorf("no byte code next to the ABI: %v", err)
+	}
+
+	if _, err := os.Stat(base + StorageLayoutExt); err == nil {
+		c.LayoutPath = base + StorageLayoutExt
+		if c.Layout, err = LoadStorageLayout(c.LayoutPath); err != nil {
+			return nil, err
+		}
+	}
+
+	for name, m := range contractABI.Methods {
+		method, err := c.newMethod(name, m.Inputs)
+		if err != nil {
+			// 暂不支持结构体参数等无法构造的类型，跳过该方法
+			fmt.Printf("skip EVM method %s: %v\n", name, err)
+			continue
+		}
+		c.Methods[name] = method
+	}
+	if len(c.Methods) == 0 {
+		return nil, fmt.Errorf("no callable method in %s", abiPath)
+	}
+
+	return c, nil
+}
+
+func (c *Contract) newMethod(name string, inputs abi.Arguments) (*Method, error) {
+	method := &Method{Name: name}
+	args := make([]interface{}, 0, len(inputs))
+	for i, input := range inputs {
+		if _, err := GoType(input.Type); err != nil {
+			return nil, fmt.Errorf("param %d: %v", i, err)
+		}
+		paramName := input.Name
+		if paramName == "" {
+			paramName = fmt.Sprintf("arg%d", i)
+		}
+		method.Params = append(method.Params, &Param{Name: paramName, Type: input.Type})
+
+		arg, err := toABIArg(input.Type, DefaultValue(input.Type))
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+
+	// 选择器取编码结果的前 4 字节，与 ABI 库的签名计算保持一致
+	data, err := c.ABI.Pack(name, args...)
+	if err != nil {
+		return nil, err
+	}
+	method.Selector = hex.EncodeToString(data[:4])
+	return method, nil
+}
+
+// 按方法名排序的方法列表
+func (c *Contract) SortedMethods() []*Method {
+	methods := make([]*Method, 0, len(c.Methods))
+	for _, method := range c.Methods {
+		methods = append(methods, method)
+	}
+	sort.Slice(methods, func(i, j int) bool { return methods[i].Name < methods[j].Name })
+	return methods
+}
+
+// 参数名列表
+func (m *Method) ParamNames() []string {
+	names := make([]string, 0, len(m.Params))
+	for _, param := range m.Params {
+		names = append(names, param.Name)
+	}
+	return names
+}
+
+// GoType 将 ABI 类型表示为以 ABI 类型名命名的 go/types 类型
+// 超过 64 位的整数以 64 位整数生成与变异，编码时再扩展
+func GoType(t abi.Type) (types.Type, error) {
+	var underlying types.Type
+	switch t.T {
+	case abi.IntTy:
+		underlying = types.Typ[intKind(t.Size, true)]
+	case abi.UintTy:
+		underlying = types.Typ[intKind(t.Size, false)]
+	case abi.BoolTy:
+		underlying = types.Typ[types.Bool]
+	case abi.StringTy, abi.AddressTy, abi.BytesTy, abi.FixedBytesTy, abi.HashTy:
+		underlying = types.Typ[types.String]
+	case abi.SliceTy, abi.ArrayTy:
+		elem, err := GoType(*t.Elem)
+		if err != nil {
+			return nil, err
+		}
+		underlying = types.NewSlice(elem)
+	default:
+		return nil, fmt.Errorf("unsupported ABI type %s", t.String())
+	}
+
+	return types.NewNamed(types.NewTypeName(token.NoPos, nil, t.String(), nil), underlying, nil), nil
+}
+
+func intKind(size int, signed bool) types.BasicKind {
+	kinds := []types.BasicKind{types.Uint8, types.Uint16, types.Uint32, types.Uint64}
+	if signed {
+		kinds = []types.BasicKind{types.Int8, types.Int16, types.Int32, types.Int64}
+	}
+	switch {
+	case size <= 8:
+		return kinds[0]
+	case size <= 16:
+		return kinds[1]
+	case size <= 32:
+		return kinds[2]
+	default:
+		return kinds[3]
+	}
+}
+
+// DefaultValue 参数的初始值，整数为对应位宽的零值，地址与字节串为十六进制串
+func DefaultValue(t abi.Type) interface{} {
+	switch t.T {
+	case abi.IntTy:
+		switch intKind(t.Size, true) {
+		case types.Int8:
+			return int8(0)
+		case types.Int16:
+			return int16(0)
+		case types.Int32:
+			return int32(0)
+		default:
+			return int64(0)
+		}
+	case abi.UintTy:
+		switch intKind(t.Size, false) {
+		case types.Uint8:
+			return uint8(0)
+		case types.Uint16:
+			return uint16(0)
+		case types.Uint32:
+			return uint32(0)
+		default:
+			return uint64(0)
+		}
+	case abi.BoolTy:
+		return false
+	case abi.AddressTy:
+		return "0x" + strings.Repeat("00", 19) + "01"
+	case abi.BytesTy:
+		return "0x00"
+	case abi.FixedBytesTy:
+		return "0x" + strings.Repeat("00", t.Size)
+	case abi.HashTy:
+		return "0x" + strings.Repeat("00", 32)
+	case abi.SliceTy:
+		return []interface{}{DefaultValue(*t.Elem)}
+	case abi.ArrayTy:
+		values := make([]interface{}, t.Size)
+		for i := range values {
+			values[i] = DefaultValue(*t.Elem)
+		}
+		return values
+	default:
+		return "string"
+	}
+}
+
+// EncodeInvoke 将以参数名为键的交易参数编码为调用数据
+// 返回作为调用方法名的选择器与只包含 data 的交易参数
+func (c *Contract) EncodeInvoke(methodName string, kvs []*common.KeyValuePair) (string, []*common.KeyValuePair, error) {
+	method, ok := c.Methods[methodName]
+	if !ok {
+		return "", nil, fmt.Errorf("method %s not found in ABI", methodName)
+	}
+
+	args, err := method.args(kvs)
+	if err != nil {
+		return "", nil, err
+	}
+	data, err := c.ABI.Pack(methodName, args...)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to pack %s: %v", methodName, err)
+	}
+
+	return method.Selector, []*common.KeyValuePair{{Key: DataKey, Value: []byte(hex.EncodeToString(data))}}, nil
+}
+
+// 按参数顺序转换交易参数，缺少的参数使用初始值
+func (m *Method) args(kvs []*common.KeyValuePair) ([]interface{}, error) {
+	values := make(map[string][]byte, len(kvs))
+	for _, kv := range kvs {
+		values[kv.Key] = kv.Value
+	}
+
+	args := make([]interface{}, 0, len(m.Params))
+	for _, param := range m.Params {
+		var value interface{} = DefaultValue(param.Type)
+		if raw, ok := values[param.Name]; ok {
+			value = decodeRaw(param.Type, raw)
+		}
+		arg, err := toABIArg(param.Type, value)
+		if err != nil {
+			return nil, fmt.Errorf("param %s: %v", param.Name, err)
+		}
+		args = append(args, arg)
+	}
+	return args, nil
+}
+
+// CmcParams 生成 cmc 以 ABI 调用时的 --params 参数，如 [{"uint256":"1"},{"address":"0x.."}]
+func (c *Contract) CmcParams(methodName string, kvs []*common.KeyValuePair) ([]map[string]interface{}, error) {
+	method, ok := c.Methods[methodName]
+	if !ok {
+		return nil, fmt.Errorf("method %s not found in ABI", methodName)
+	}
+
+	args, err := method.args(kvs)
+	if err != nil {
+		return nil, err
+	}
+	params := make([]map[string]interface{}, 0, len(args))
+	for i, arg := range args {
+		params = append(params, map[string]interface{}{method.Params[i].Type.String(): arg})
+	}
+	return params, nil
+}
+
+// DeployPayload 部署所需的字节码（十六进制串）与构造函数参数，构造函数参数使用初始值
+func (c *Contract) DeployPayload() (string, []*common.KeyValuePair, error) {
+	byteCode, err := os.ReadFile(c.ByteCodePath)
+	if err != nil {
+		return "", nil, err
+	}
+	kvs := []*common.KeyValuePair{}
+
+	if inputs := c.ABI.Constructor.Inputs; len(inputs) > 0 {
+		args := make([]interface{}, 0, len(inputs))
+		for _, input := range inputs {
+			arg, err := toABIArg(input.Type, DefaultValue(input.Type))
+			if err != nil {
+				return "", nil, fmt.Errorf("constructor: %v", err)
+			}
+			args = append(args, arg)
+		}
+		data, err := c.ABI.Pack("", args...)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to pack constructor: %v", err)
+		}
+		kvs = append(kvs, &common.KeyValuePair{Key: DataKey, Value: []byte(hex.EncodeToString(data))})
+	}
+
+	return strings.TrimSpace(string(byteCode)), kvs, nil
+}
@@ -0,0 +1,317 @@
+/*
+	本文件主要用于：
+
+	1. 读取 solc --storage-layout 输出的存储布局
+
+	2. 将 EVM 合约读写集中的存储槽位还原为状态变量名：
+	   静态变量及结构体成员按槽位区间还原，动态数组与字节串按 keccak(slot) 起始的数据区还原为 label[i]，
+	   映射项以交易参数中出现过的值作为候选键计算 keccak(key . slot) 还原为 label[key]，最多还原两层映射
+	   无法还原的槽位保留为 0x 开头的十六进制串
+*/
+
+package evm
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"chainmaker.org/chainmaker/common/v2/evmutils"
+	"chainmaker.org/chainmaker/common/v2/evmutils/abi"
+)
+
+// 参与映射项还原的候选键数量上限
+const maxSlotCandidates = 256
+
+// 动态数组下标的还原上限
+const maxDynamicIndex = 1 << 16
+
+type StorageLayout struct {
+	Storage []*StorageVar           `json:"storage"`
+	Types   map[string]*StorageType `json:"types"`
+}
+
+type StorageVar struct {
+	Label  string `json:"label"`
+	Offset int    `json:"offset"`
+	Slot   string `json:"slot"`
+	Type   string `json:"type"`
+}
+
+type StorageType struct {
+	Encoding      string        `json:"encoding"` // inplace、mapping、dynamic_array、bytes
+	Label         string        `json:"label"`
+	NumberOfBytes string        `json:"numberOfBytes"`
+	Key           string        `json:"key"`
+	Value         string        `json:"value"`
+	Base          string        `json:"base"`
+	Members       []*StorageVar `json:"members"`
+}
+
+func LoadStorageLayout(path string) (*StorageLayout, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read storage layout: %v", err)
+	}
+	var layout *StorageLayout
+	if err := json.Unmarshal(data, &layout); err != nil {
+		return nil, fmt.Errorf("failed to parse storage layout %s: %v", path, err)
+	}
+	if layout == nil || layout.Types == nil {
+		return nil, fmt.Errorf("empty storage layout %s", path)
+	}
+	return layout, nil
+}
+
+// 类型占用的槽位数
+func (l *StorageLayout) slots(typeName string) *big.Int {
+	size, ok := new(big.Int).SetString(l.Types[typeName].numberOfBytes(), 10)
+	if !ok || size.Sign() == 0 {
+		return big.NewInt(1)
+	}
+	size.Add(size, big.NewInt(31))
+	return size.Div(size, big.NewInt(32))
+}
+
+func (t *StorageType) numberOfBytes() string {
+	if t == nil {
+		return "32"
+	}
+	return t.NumberOfBytes
+}
+
+// 在 base 起始、类型为 typeName、名称为 label 的位置中查找 slot，返回还原的名称
+func (l *StorageLayout) resolveInplace(label, typeName string, base, slot *big.Int) (string, bool) {
+	end := new(big.Int).Add(base, l.slots(typeName))
+	if slot.Cmp(base) < 0 || slot.Cmp(end) >= 0 {
+		return "", false
+	}
+
+	t := l.Types[typeName]
+	switch {
+	case t != nil && len(t.Members) > 0:
+		for _, member := range t.Members {
+			memberSlot, ok := new(big.Int).SetString(member.Slot, 10)
+			if !ok {
+				continue
+			}
+			if name, ok := l.resolveInplace(label+"."+member.Label, member.Type, memberSlot.Add(memberSlot, base), slot); ok {
+				return name, true
+			}
+		}
+	case t != nil && t.Encoding == "inplace" && t.Base != "":
+		// 定长数组，元素不足一个槽位时按槽位给出下标
+		elemSlots := l.slots(t.Base)
+		index := new(big.Int).Sub(slot, base)
+		index.Div(index, elemSlots)
+		elemBase := new(big.Int).Mul(index, elemSlots)
+		elemBase.Add(elemBase, base)
+		if name, ok := l.resolveInplace(fmt.Sprintf("%s[%s]", label, index), t.Base, elemBase, slot); ok {
+			return name, true
+		}
+	}
+	return label, true
+}
+
+// KeyName 将合约的存储键还原为状态变量名，inputs 为本次交易的参数，其中的值作为映射的候选键
+func (c *Contract) KeyName(key []byte, inputs map[string]interface{}) string {
+	slot := slotOf(key)
+	slotHex := fmt.Sprintf("0x%064x", slot)
+	if c.Layout == nil {
+		return slotHex
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, value := range inputs {
+		c.addCandidates(value)
+	}
+	if name, ok := c.slotNames[slotHex]; ok {
+		return name
+	}
+
+	name, ok := c.resolve(slot)
+	if !ok {
+		return slotHex
+	}
+	c.slotNames[slotHex] = name
+	return name
+}
+
+// 存储键为槽位的大端字节，也兼容十六进制串形式
+func slotOf(key []byte) *big.Int {
+	if len(key) > 32 {
+		if data, err := hex.DecodeString(string(key)); err == nil {
+			return new(big.Int).SetBytes(data)
+		}
+		if data, ok := hexBytes(string(key)); ok {
+			return new(big.Int).SetBytes(data)
+		}
+	}
+	return new(big.Int).SetBytes(key)
+}
+
+func (c *Contract) addCandidates(value interface{}) {
+	switch v := value.(type) {
+	case []interface{}:
+		for _, elem := range v {
+			c.addCandidates(elem)
+		}
+	case map[string]interface{}:
+		for _, elem := range v {
+			c.addCandidates(elem)
+		}
+	default:
+		if len(c.candidates) < maxSlotCandidates {
+			c.candidates[fmt.Sprint(v)] = struct{}{}
+		}
+	}
+}
+
+func (c *Contract) sortedCandidates() []string {
+	candidates := make([]string, 0, len(c.candidates))
+	for candidate := range c.candidates {
+		candidates = append(candidates, candidate)
+	}
+	sort.Strings(candidates)
+	return candidates
+}
+
+func (c *Contract) resolve(slot *big.Int) (string, bool) {
+	l := c.Layout
+	for _, v := range l.Storage {
+		base, ok := new(big.Int).SetString(v.Slot, 10)
+		if !ok {
+			continue
+		}
+		if name, ok := l.resolveInplace(v.Label, v.Type, base, slot); ok {
+			return name, true
+		}
+	}
+
+	candidates := c.sortedCandidates()
+	for _, v := range l.Storage {
+		base, ok := new(big.Int).SetString(v.Slot, 10)
+		if !ok {
+			continue
+		}
+		if name, ok := c.resolveDynamic(v.Label, v.Type, base, slot, candidates, 2); ok {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// 还原动态数组、字节串与映射的数据区，depth 为剩余可展开的映射层数
+func (c *Contract) resolveDynamic(label, typeName string, base, slot *big.Int, candidates []string, depth int) (string, bool) {
+	l := c.Layout
+	t := l.Types[typeName]
+	if t == nil {
+		return "", false
+	}
+
+	switch t.Encoding {
+	case "bytes":
+		start := keccakSlot(base)
+		if offset := new(big.Int).Sub(slot, start); offset.Sign() >= 0 && offset.Cmp(big.NewInt(maxDynamicIndex)) < 0 {
+			return label, true
+		}
+	case "dynamic_array":
+		start := keccakSlot(base)
+		offset := new(big.Int).Sub(slot, start)
+		elemSlots := l.slots(t.Base)
+		if offset.Sign() < 0 || offset.Cmp(new(big.Int).Mul(big.NewInt(maxDynamicIndex), elemSlots)) >= 0 {
+			break
+		}
+		index := new(big.Int).Div(offset, elemSlots)
+		elemBase := new(big.Int).Add(start, new(big.Int).Mul(index, elemSlots))
+		return l.resolveInplace(fmt.Sprintf("%s[%s]", label, index), t.Base, elemBase, slot)
+	case "mapping":
+		if depth == 0 {
+			break
+		}
+		for _, candidate := range candidates {
+			keyData, ok := l.encodeMappingKey(t.Key, candidate)
+			if !ok {
+				continue
+			}
+			valueBase := new(big.Int).SetBytes(evmutils.Keccak256(append(keyData, pad32(base)...)))
+			valueLabel := fmt.Sprintf("%s[%s]", label, candidate)
+			if name, ok := l.resolveInplace(valueLabel, t.Value, valueBase, slot); ok {
+				return name, true
+			}
+			if name, ok := c.resolveDynamic(valueLabel, t.Value, valueBase, slot, candidates, depth-1); ok {
+				return name, true
+			}
+		}
+	}
+	return "", false
+}
+
+// 映射键的编码：值类型按 ABI 规则补齐为 32 字节，string 与 bytes 直接取其字节
+func (l *StorageLayout) encodeMappingKey(keyType, candidate string) ([]byte, bool) {
+	t := l.Types[keyType]
+	if t == nil {
+		return nil, false
+	}
+	if t.Encoding == "bytes" {
+		if t.Label == "string" {
+			return []byte(candidate), true
+		}
+		return toBytes(candidate), true
+	}
+
+	label := t.Label
+	switch {
+	case strings.HasPrefix(label, "contract "):
+		label = "address"
+	case strings.HasPrefix(label, "enum "):
+		label = "uint8"
+	}
+
+	data := make([]byte, 32)
+	switch {
+	case label == "address":
+		arg, _ := toABIArg(abi.Type{T: abi.AddressTy, Size: 20}, candidate)
+		address, _ := hexBytes(arg.(string))
+		copy(data[12:], address)
+	case label == "bool":
+		arg, _ := toABIArg(abi.Type{T: abi.BoolTy}, candidate)
+		if arg.(bool) {
+			data[31] = 1
+		}
+	case strings.HasPrefix(label, "uint"), strings.HasPrefix(label, "int"):
+		abiType := abi.Type{T: abi.UintTy, Size: 256}
+		if strings.HasPrefix(label, "int") {
+			abiType.T = abi.IntTy
+		}
+		if size, err := strconv.Atoi(strings.TrimLeft(label, "uint")); err == nil {
+			abiType.Size = size
+		}
+		n := toABIInt(abiType, candidate)
+		if n.Sign() < 0 {
+			n.Add(n, new(big.Int).Lsh(big.NewInt(1), 256))
+		}
+		n.FillBytes(data)
+	case strings.HasPrefix(label, "bytes"):
+		copy(data, toBytes(candidate))
+	default:
+		return nil, false
+	}
+	return data, true
+}
+
+func keccakSlot(slot *big.Int) *big.Int {
+	return new(big.Int).SetBytes(evmutils.Keccak256(pad32(slot)))
+}
+
+func pad32(n *big.Int) []byte {
+	data := make([]byte, 32)
+	return n.FillBytes(data)
+}
@@ -0,0 +1,201 @@
+/*
+	本文件主要用于：
+
+	1. 记录配置矩阵中每个配置组合下、每个冲突交易对种子的实验结果
+
+	2. 生成对比表，行为配置组合 × 交易对种子，列为配置项取值与交易结果分类，
+	   每个配置组合另有一行汇总
+*/
+
+package fuzz
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// 配置矩阵目录下保存对比结果的文件名
+const MatrixResultFileName = "matrix.json"
+
+// 某个配置组合下单个交易对种子的实验结果
+type MatrixRow struct {
+	Cell       string              `json:"cell"`
+	Values     map[string]string   `json:"values"`
+	Seed       string              `json:"seed,omitempty"` // 交易对种子 ID
+	Functions  string              `json:"functions,omitempty"`
+	ResultDir  string              `json:"result_dir,omitempty"`
+	DurationMs int64               `json:"duration_ms"`
+	Outcomes   *ExperimentOutcomes `json:"outcomes,omitempty"`
+	Error      string              `json:"error,omitempty"`
+}
+
+// 所有比例与长时间实验的汇总
+func (r *MatrixRow) Total() *OutcomeTable {
+	if r.Outcomes == nil {
+		return &OutcomeTable{Label: "total", Counts: make(map[TxOutcome]int)}
+	}
+	return r.Outcomes.Total()
+}
+
+type MatrixResult struct {
+	Settings []string     `json:"settings"` // 矩阵中变化的配置项，同时也是对比表的列顺序
+	Rows     []*MatrixRow `json:"rows"`
+}
+
+func (m *MatrixResult) Add(row *MatrixRow) {
+	m.Rows = append(m.Rows, row)
+}
+
+// 对比表中的结果列
+var matrixColumns = []struct {
+	Name  string
+	Count func(t *OutcomeTable) int
+}{
+	{"total", func(t *OutcomeTable) int { return t.Total }},
+	{"success", func(t *OutcomeTable) int { return t.Counts[OutcomeCommittedSuccess] }},
+	{"contract_error", func(t *OutcomeTable) int { return t.Counts[OutcomeCommittedContractFail] }},
+	{"timeout", func(t *OutcomeTable) int { return t.Counts[OutcomeCommittedTimeout] }},
+	{"not_committed", func(t *OutcomeTable) int { return t.Counts[OutcomeAcceptedNotCommitted] }},
+	{"rejected", func(t *OutcomeTable) int {
+		rejected := 0
+		for outcome, count := range t.Counts {
+			if outcome.IsRejected() {
+				rejected += count
+			}
+		}
+		return rejected
+	}},
+	{"lost", func(t *OutcomeTable) int { return t.Lost() }},
+}
+
+// 按配置组合汇总，顺序与首次出现的顺序一致
+func (m *MatrixResult) CellTotals() []*MatrixRow {
+	totals := make([]*MatrixRow, 0)
+	index := make(map[string]*MatrixRow)
+
+	for _, row := range m.Rows {
+		total, ok := index[row.Cell]
+		if !ok {
+			total = &MatrixRow{Cell: row.Cell, Values: row.Values, Seed: "total", Outcomes: &ExperimentOutcomes{}}
+			index[row.Cell] = total
+			totals = append(totals, total)
+		}
+
+		total.DurationMs += row.DurationMs
+		if row.Outcomes != nil {
+			total.Outcomes.Add(row.Total())
+		}
+		if row.Error != "" && total.Error == "" {
+			total.Error = row.Error
+		}
+	}
+	return totals
+}
+
+// 对比表中结果列的名称
+func MatrixColumns() []string {
+	names := make([]string, 0, len(matrixColumns))
+	for _, column := range matrixColumns {
+		names = append(names, column.Name)
+	}
+	return names
+}
+
+// 各结果列上的交易数
+func (r *MatrixRow) Counts() []int {
+	total := r.Total()
+	counts := make([]int, 0, len(matrixColumns))
+	for _, column := range matrixColumns {
+		counts = append(counts, column.Count(total))
+	}
+	return counts
+}
+
+// 按配置组合排列的所有行，每个组合之后为该组合的汇总行
+func (m *MatrixResult) OrderedRows() []*MatrixRow {
+	rows := make([]*MatrixRow, 0)
+	for _, total := range m.CellTotals() {
+		for _, row := range m.Rows {
+			if row.Cell == total.Cell {
+				rows = append(rows, row)
+			}
+		}
+		rows = append(rows, total)
+	}
+	return rows
+}
+
+// 以文本表格形式输出对比结果
+func (m *MatrixResult) String() string {
+	var sb strings.Builder
+
+	header := append(append(append([]string{}, m.Settings...), "seed"), MatrixColumns()...)
+	header = append(header, "duration", "error")
+
+	rows := make([][]string, 0)
+	for _, row := range m.OrderedRows() {
+		line := make([]string, 0, len(header))
+		for _, setting := range m.Settings {
+			line = append(line, row.Values[setting])
+		}
+		line = append(line, row.Seed)
+		for _, count := range row.Counts() {
+			line = append(line, fmt.Sprint(count))
+		}
+		line = append(line, fmt.Sprintf("%.1fs", float64(row.DurationMs)/1000), row.Error)
+		rows = append(rows, line)
+	}
+
+	widths := make([]int, len(header))
+	for _, line := range append([][]string{header}, rows...) {
+		for i, value := range line {
+			if len(value) > widths[i] {
+				widths[i] = len(value)
+			}
+		}
+	}
+	for _, line := range append([][]string{header}, rows...) {
+		for i, value := range line {
+			sb.WriteString(fmt.Sprintf("%-*s ", widths[i], value))
+		}
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
+
+// 将对比结果保存为 JSON 与文本表格两份文件
+func (m *MatrixResult) SaveToFile(jsonPath, textPath string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化配置矩阵结果失败: %w", err)
+	}
+
+	if err := os.WriteFile(jsonPath, data, 0644); err != nil {
+		return fmt.Errorf("写入配置矩阵结果失败: %w", err)
+	}
+
+	if err := os.WriteFile(textPath, []byte(m.String()), 0644); err != nil {
+		return fmt.Errorf("写入配置矩阵对比表失败: %w", err)
+	}
+
+	return nil
+}
+
+// 从文件读取配置矩阵结果
+func LoadMatrixResultFromFile(filePath string) (*MatrixResult, error) {
+	var result *MatrixResult
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("读取配置矩阵结果失败: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("解析配置矩阵结果失败: %w", err)
+	}
+
+	return result, nil
+}
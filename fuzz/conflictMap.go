@@ -0,0 +1,326 @@
+/*
+	本文件主要用于：
+
+	1. 汇总函数 × 函数的冲突情况：
+			a. 两函数所有种子组合中的最大读写集相似度
+			b. 冲突类型（读写冲突 RW / 写写冲突 WW）
+			c. 冲突状态：已确认（读写集中存在完全相同的 key）/ 预测（可变异、可能冲突）/ 无
+
+	2. 统计合约 key（及 key 模板）被多少个函数对争用，找出热点 key
+
+	3. 生成热力图与热点 key 排名图
+*/
+
+package fuzz
+
+import (
+	"TransactionRwset/picture"
+	"TransactionRwset/utils"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+)
+
+type ConflictStatus string
+
+const (
+	ConflictNone      ConflictStatus = "none"
+	ConflictPredicted ConflictStatus = "predicted"
+	ConflictConfirmed ConflictStatus = "confirmed"
+)
+
+// 冲突状态的优先级，同一函数对取最高者
+func (s ConflictStatus) rank() int {
+	switch s {
+	case ConflictConfirmed:
+		return 2
+	case ConflictPredicted:
+		return 1
+	default:
+		return 0
+	}
+}
+
+const (
+	conflictKindReadWrite  = "RW"
+	conflictKindWriteWrite = "WW"
+)
+
+// 热点 key 排名图中展示的 key 模板数量
+var maxContentionKeys = 20
+
+// key 中的数字、长十六进制串替换为 *，得到 key 模板
+var keyVariablePattern = regexp.MustCompile(`[0-9a-fA-F]{16,}|[0-9]+`)
+
+func keyTemplate(key string) string {
+	return keyVariablePattern.ReplaceAllString(key, "*")
+}
+
+// 一个函数对的冲突情况
+type PairConflict struct {
+	FuncOne        string         `json:"func_one"`
+	FuncTwo        string         `json:"func_two"`
+	BestSimilarity float64        `json:"best_similarity"`
+	Kinds          []string       `json:"kinds"`
+	Status         ConflictStatus `json:"status"`
+	Keys           []string       `json:"keys"`          // 完全相同的冲突 key
+	KeyTemplates   []string       `json:"key_templates"` // 模板相同的争用 key
+}
+
+func (p *PairConflict) Kind() string {
+	return strings.Join(p.Kinds, "+")
+}
+
+// 被多个函数对争用的 key 模板
+type KeyContention struct {
+	KeyTemplate string   `json:"key_template"`
+	Pairs       int      `json:"pairs"`
+	Keys        []string `json:"keys"`
+}
+
+type ConflictMap struct {
+	Pairs map[string]*PairConflict `json:"pairs"`
+}
+
+func NewConflictMap() *ConflictMap {
+	return &ConflictMap{
+		Pairs: make(map[string]*PairConflict),
+	}
+}
+
+// 函数对的唯一标识，与两函数顺序无关
+func pairConflictKey(funcOne, funcTwo string) (string, string, string) {
+	if funcOne > funcTwo {
+		funcOne, funcTwo = funcTwo, funcOne
+	}
+	return funcOne + "|" + funcTwo, funcOne, funcTwo
+}
+
+func addUnique(list []string, items ...string) []string {
+	for _, item := range items {
+		exists := false
+		for _, v := range list {
+			if v == item {
+				exists = true
+				break
+			}
+		}
+		if !exists {
+			list = append(list, item)
+		}
+	}
+	sort.Strings(list)
+	return list
+}
+
+// 求两组 key 的交集，mapper 用于将 key 转化为比较对象
+func intersectKeys(a, b []string, mapper func(string) string) []string {
+	set := make(map[string]bool)
+	for _, key := range a {
+		set[mapper(key)] = true
+	}
+
+	result := make([]string, 0)
+	for _, key := range b {
+		if set[mapper(key)] {
+			result = addUnique(result, mapper(key))
+		}
+	}
+	return result
+}
+
+func identity(key string) string {
+	return key
+}
+
+// 记录一个种子对的冲突情况，mutable 表示该种子对是否可变异
+func (m *ConflictMap) Record(seedOne, seedTwo *FuncSeed, similarity float64, mutable bool) {
+	id, funcOne, funcTwo := pairConflictKey(seedOne.FunctionName, seedTwo.FunctionName)
+
+	pair, ok := m.Pairs[id]
+	if !ok {
+		pair = &PairConflict{
+			FuncOne:      funcOne,
+			FuncTwo:      funcTwo,
+			Kinds:        make([]string, 0),
+			Status:       ConflictNone,
+			Keys:         make([]string, 0),
+			KeyTemplates: make([]string, 0),
+		}
+		m.Pairs[id] = pair
+	}
+
+	pair.BestSimilarity = utils.Max(pair.BestSimilarity, similarity)
+
+	// 完全相同的 key
+	readWrite := append(intersectKeys(seedOne.ReadSet, seedTwo.WriteSet, identity), intersectKeys(seedOne.WriteSet, seedTwo.ReadSet, identity)...)
+	writeWrite := intersectKeys(seedOne.WriteSet, seedTwo.WriteSet, identity)
+
+	// 模板相同的 key
+	readWriteTemplates := append(intersectKeys(seedOne.ReadSet, seedTwo.WriteSet, keyTemplate), intersectKeys(seedOne.WriteSet, seedTwo.ReadSet, keyTemplate)...)
+	writeWriteTemplates := intersectKeys(seedOne.WriteSet, seedTwo.WriteSet, keyTemplate)
+
+	status := ConflictNone
+	switch {
+	case len(readWrite) > 0 || len(writeWrite) > 0:
+		status = ConflictConfirmed
+		if len(readWrite) > 0 {
+			pair.Kinds = addUnique(pair.Kinds, conflictKindReadWrite)
+		}
+		if len(writeWrite) > 0 {
+			pair.Kinds = addUnique(pair.Kinds, conflictKindWriteWrite)
+		}
+	case mutable || len(readWriteTemplates) > 0:
+		// 可变异的种子对必然由一方的读集与另一方的写集构成
+		status = ConflictPredicted
+		pair.Kinds = addUnique(pair.Kinds, conflictKindReadWrite)
+		if len(writeWriteTemplates) > 0 {
+			pair.Kinds = addUnique(pair.Kinds, conflictKindWriteWrite)
+		}
+	}
+
+	if status.rank() > pair.Status.rank() {
+		pair.Status = status
+	}
+
+	pair.Keys = addUnique(pair.Keys, append(readWrite, writeWrite...)...)
+	pair.KeyTemplates = addUnique(pair.KeyTemplates, append(readWriteTemplates, writeWriteTemplates...)...)
+}
+
+// 所有出现过的函数名，按字母序排列
+func (m *ConflictMap) FuncNames() []string {
+	names := make([]string, 0)
+	for _, pair := range m.Pairs {
+		names = addUnique(names, pair.FuncOne, pair.FuncTwo)
+	}
+	return names
+}
+
+// 按争用的函数对数量对 key 模板进行排名
+func (m *ConflictMap) KeyContentions() []*KeyContention {
+	contentions := make(map[string]*KeyContention)
+
+	for _, pair := range m.Pairs {
+		for _, template := range pair.KeyTemplates {
+			contention, ok := contentions[template]
+			if !ok {
+				contention = &KeyContention{KeyTemplate: template, Keys: make([]string, 0)}
+				contentions[template] = contention
+			}
+			contention.Pairs++
+
+			for _, key := range pair.Keys {
+				if keyTemplate(key) == template {
+					contention.Keys = addUnique(contention.Keys, key)
+				}
+			}
+		}
+	}
+
+	result := make([]*KeyContention, 0, len(contentions))
+	for _, contention := range contentions {
+		result = append(result, contention)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Pairs != result[j].Pairs {
+			return result[i].Pairs > result[j].Pairs
+		}
+		return result[i].KeyTemplate < result[j].KeyTemplate
+	})
+
+	return result
+}
+
+// 保存冲突矩阵 JSON、冲突热力图与热点 key 排名图
+func (m *ConflictMap) Save(baseDir string) error {
+	data, err := json.MarshalIndent(struct {
+		*ConflictMap
+		KeyContentions []*KeyContention `json:"key_contentions"`
+	}{m, m.KeyContentions()}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize ConflictMap: %v", err)
+	}
+
+	err = os.WriteFile(filepath.Join(baseDir, "conflict_map.json"), data, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write to file: %v", err)
+	}
+
+	if err := m.DrawHeatmap(filepath.Join(baseDir, "conflict_heatmap.png")); err != nil {
+		return fmt.Errorf("failed to draw conflict heatmap: %v", err)
+	}
+
+	if err := m.PlotKeyContention(filepath.Join(baseDir, "key_contention.png")); err != nil {
+		return fmt.Errorf("failed to plot key contention: %v", err)
+	}
+
+	return nil
+}
+
+// 绘制函数 × 函数冲突热力图
+func (m *ConflictMap) DrawHeatmap(savePath string) error {
+	funcNames := m.FuncNames()
+	if len(funcNames) == 0 {
+		return fmt.Errorf("no function pairs to draw")
+	}
+
+	cells := make([][]picture.HeatCell, len(funcNames))
+	for i, a := range funcNames {
+		cells[i] = make([]picture.HeatCell, len(funcNames))
+		for j, b := range funcNames {
+			id, _, _ := pairConflictKey(a, b)
+			if pair, ok := m.Pairs[id]; ok {
+				cells[i][j] = picture.HeatCell{
+					Similarity: pair.BestSimilarity,
+					Kind:       pair.Kind(),
+					Confirmed:  pair.Status == ConflictConfirmed,
+					Predicted:  pair.Status == ConflictPredicted,
+				}
+			}
+		}
+	}
+
+	return picture.DrawConflictHeatmap(funcNames, cells, savePath)
+}
+
+// 绘制热点 key 模板排名图，横轴为争用该 key 的函数对数量
+func (m *ConflictMap) PlotKeyContention(savePath string) error {
+	contentions := m.KeyContentions()
+	if len(contentions) == 0 {
+		return fmt.Errorf("no contended keys to plot")
+	}
+	if len(contentions) > maxContentionKeys {
+		contentions = contentions[:maxContentionKeys]
+	}
+
+	// 排名第一的 key 位于图的最上方
+	values := make(plotter.Values, len(contentions))
+	labels := make([]string, len(contentions))
+	for i, contention := range contentions {
+		values[len(contentions)-1-i] = float64(contention.Pairs)
+		labels[len(contentions)-1-i] = contention.KeyTemplate
+	}
+
+	p := plot.New()
+	p.Title.Text = "Key contention (function pairs per key template)"
+	p.X.Label.Text = "function pairs"
+	p.X.Min = 0
+
+	bars, err := plotter.NewBarChart(values, vg.Points(12))
+	if err != nil {
+		return err
+	}
+	bars.Horizontal = true
+	p.Add(bars)
+	p.NominalY(labels...)
+
+	return p.Save(10*vg.Inch, vg.Length(len(contentions))*vg.Points(24)+2*vg.Inch, savePath)
+}
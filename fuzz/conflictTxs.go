@@ -2,7 +2,6 @@ package fuzz
 
 import (
 	nodecontrol "TransactionRwset/nodeControl"
-	"TransactionRwset/picture"
 	"TransactionRwset/utils"
 	"encoding/json"
 	"fmt"
@@ -44,8 +43,12 @@ type Tx struct {
 	OnChain        bool                // 判断是否在链中
 	ExecuteResult  common.TxStatusCode // 判断交易执行情况
 	ExecuteMessage string
+	GasUsed        uint64              `json:",omitempty"` // 上链后执行消耗的 gas
 	SendStatus     common.TxStatusCode // 判断交易是否成功发送
 	SendMessage    string
+	SendError      string    // 发送交易时 SDK 返回的错误信息
+	Outcome        TxOutcome // 交易最终结果分类
+	Node           string    `json:",omitempty"` // 按节点分发时交易发往的节点
 }
 
 type Txs struct {
@@ -95,6 +98,7 @@ func generateAndTrackTransactions(aRatio, bRatio int, timeSleep int64, totalRoun
 	const maxWorkers = 20
 	for i := 0; i < maxWorkers; i++ {
 		go func() {
+			defer utils.RecoverPanic()
 			for task := range taskCh { // 从通道中获取任务
 				task() // 执行任务
 			}
@@ -111,40 +115,24 @@ func generateAndTrackTransactions(aRatio, bRatio int, timeSleep int64, totalRoun
 		wg.Add(aRatio + bRatio)
 
 		go func() {
+			defer utils.RecoverPanic()
 			for i := 0; i < aRatio; i++ {
 				// wg.Add(1)
 				taskCh <- func() {
 					defer wg.Done()
-					txid, message, status, _, _ := nodecontrol.ChainmakerController.UserContractInvoke(utils.GlobalContractInfo.ContractName, FuncOneName, FuncOneInput, false)
-					txs.Append(&Tx{
-						TxId:          txid,
-						OnChain:       false,
-						InPool:        false,
-						TimeStamp:     time.Now().Unix(),
-						ExecuteResult: -1,
-						SendStatus:    status,
-						SendMessage:   message,
-					})
+					txs.Append(sendTrackedTx(FuncOneName, FuncOneInput))
 				}
 			}
 		}()
 
 		// 分发 bRatio 次 FuncTwo 的任务
 		go func() {
+			defer utils.RecoverPanic()
 			for i := 0; i < bRatio; i++ {
 				// wg.Add(1)
 				taskCh <- func() {
 					defer wg.Done()
-					txid, message, status, _, _ := nodecontrol.ChainmakerController.UserContractInvoke(utils.GlobalContractInfo.ContractName, FuncTwoName, FuncTwoInput, false)
-					txs.Append(&Tx{
-						TxId:          txid,
-						OnChain:       false,
-						InPool:        false,
-						TimeStamp:     time.Now().Unix(),
-						ExecuteResult: -1,
-						SendStatus:    status,
-						SendMessage:   message,
-					})
+					txs.Append(sendTrackedTx(FuncTwoName, FuncTwoInput))
 				}
 			}
 		}()
@@ -163,10 +151,36 @@ func generateAndTrackTransactions(aRatio, bRatio int, timeSleep int64, totalRoun
 	return txs.GetAllTxs()
 }
 
+// 异步发送一笔实验交易，按节点分发时经由下一个节点的客户端发送
+func sendTrackedTx(funcName string, kvs []*common.KeyValuePair) *Tx {
+	client, node := nodecontrol.ChainmakerController.Client, ""
+	if nodeClient := nodecontrol.ChainmakerController.NextNodeClient(); nodeClient != nil {
+		client, node = nodeClient.Client, nodeClient.Name
+	}
+
+	txid, message, status, _, _, err := nodecontrol.ChainmakerController.UserContractInvokeVia(client, utils.GlobalContractInfo.ContractName, funcName, kvs, false)
+	sendError := ""
+	if err != nil {
+		sendError = err.Error()
+	}
+	return &Tx{
+		TxId:          txid,
+		OnChain:       false,
+		InPool:        false,
+		TimeStamp:     time.Now().Unix(),
+		ExecuteResult: -1,
+		SendStatus:    status,
+		SendMessage:   message,
+		SendError:     sendError,
+		Node:          node,
+	}
+}
+
 func WaitForEmptyPool() {
 	Log := utils.Log
 
 	Log.Log(utils.ConflictLog, "Waiting for the transaction pool to be empty...")
+	start := time.Now()
 	for {
 		status, err := nodecontrol.ChainmakerController.Client.GetPoolStatus()
 		if err != nil {
@@ -174,10 +188,26 @@ func WaitForEmptyPool() {
 			return
 		}
 		if status.CommonTxNumInPending+status.CommonTxNumInQueue == 0 {
-			Log.Log(utils.ConflictLog, "Transaction pool is empty.")
+			Log.Emit(&utils.Event{
+				Level:      utils.LevelInfo,
+				Phase:      utils.ConflictLog,
+				Type:       utils.EventTxPoolWaiting,
+				Message:    "Transaction pool is empty.",
+				DurationMs: time.Since(start).Milliseconds(),
+			})
 			return
 		}
-		Log.Log(utils.ConflictLog, fmt.Sprintf("Transaction pool is not empty, txs number: [%d]. Retrying in 30 seconds...", status.CommonTxNumInPending+status.CommonTxNumInQueue))
+		Log.Emit(&utils.Event{
+			Level:      utils.LevelInfo,
+			Phase:      utils.ConflictLog,
+			Type:       utils.EventTxPoolWaiting,
+			Message:    "Transaction pool is not empty. Retrying in 30 seconds...",
+			DurationMs: time.Since(start).Milliseconds(),
+			Fields: map[string]interface{}{
+				"pending": status.CommonTxNumInPending,
+				"queue":   status.CommonTxNumInQueue,
+			},
+		})
 		time.Sleep(30 * time.Second)
 	}
 }
@@ -190,6 +220,7 @@ func GetTxsTimeStampInfo(txs []*Tx) {
 	// 启动线程池
 	for i := 0; i < maxWorkers; i++ {
 		go func() {
+			defer utils.RecoverPanic()
 			for tx := range taskCh { // 从通道中获取任务
 				txInfo, err := nodecontrol.ChainmakerController.Client.GetTxByTxId(tx.TxId)
 				if err != nil {
@@ -199,7 +230,8 @@ func GetTxsTimeStampInfo(txs []*Tx) {
 				if txInfo != nil {
 					tx.OnChain = true
 					tx.ExecuteResult = txInfo.Transaction.Result.Code
-					tx.ExecuteMessage = txInfo.Transaction.Result.ContractResult.Message
+					tx.ExecuteMessage = txInfo.Transaction.Result.ContractResult.GetMessage()
+					tx.GasUsed = txInfo.Transaction.Result.ContractResult.GetGasUsed()
 					tx.TimeStamp = txInfo.Transaction.Payload.Timestamp
 				} else {
 					tx.OnChain = false
@@ -235,9 +267,11 @@ func RecordTxsResultWithPool(txs []*Tx) {
 	// 启动线程池
 	for i := 0; i < maxWorkers; i++ {
 		go func() {
+			defer utils.RecoverPanic()
 			for tx := range taskCh { // 从通道中获取任务
 				// 该交易已经上链，不需要再查询
 				if tx.OnChain == true {
+					wg.Done()
 					continue
 				}
 
@@ -249,6 +283,8 @@ func RecordTxsResultWithPool(txs []*Tx) {
 				if txInfo != nil {
 					tx.OnChain = true
 					tx.ExecuteResult = txInfo.Transaction.Result.Code
+					tx.ExecuteMessage = txInfo.Transaction.Result.ContractResult.GetMessage()
+					tx.GasUsed = txInfo.Transaction.Result.ContractResult.GetGasUsed()
 				} else {
 					tx.OnChain = false
 					txInpool, _, _ := nodecontrol.ChainmakerController.Client.GetTxsInPoolByTxIds([]string{tx.TxId})
@@ -274,42 +310,69 @@ func RecordTxsResultWithPool(txs []*Tx) {
 	wg.Wait()     // 等待所有任务完成
 }
 
-func ConflictPairSeedExperiment(f *FuncPairSeed) {
+// 返回本次实验的结果目录
+func ConflictPairSeedExperiment(f *FuncPairSeed) string {
+	return ConflictPairSeedExperimentIn(f, utils.Log.BaseDir)
+}
+
+// 在 baseDir 下创建实验目录并进行冲突交易实验，配置矩阵中每个配置组合使用各自的目录
+func ConflictPairSeedExperimentIn(f *FuncPairSeed, baseDir string) string {
 	Log := utils.Log
 
 	// 生成时间戳
 	timestamp := time.Now().Unix()
 
 	// 创建目标目录
-	targetDir := filepath.Join(Log.BaseDir, fmt.Sprintf("%s_%s_%d", f.SeedOne.FunctionName, f.SeedTwo.FunctionName, timestamp))
+	targetDir := filepath.Join(baseDir, fmt.Sprintf("%s_%s_%d", f.SeedOne.FunctionName, f.SeedTwo.FunctionName, timestamp))
 	err := os.MkdirAll(targetDir, os.ModePerm)
 	if err != nil {
 		fmt.Printf("failed to create directory: %v\n", err)
-		return
+		return ""
 	}
 
-	Log.Log(utils.ConflictLog, fmt.Sprintf("结果保存目录: [%s]", targetDir))
+	start := time.Now()
+	event := f.Event(utils.ConflictLog, utils.EventExperiment, "开始冲突交易实验")
+	event.Fields["result_dir"] = targetDir
+	Log.Emit(event)
+
+	// 保存本次实验使用的交易对种子
+	err = f.SaveToFile(filepath.Join(targetDir, PairSeedFileName))
+	if err != nil {
+		fmt.Println("保存交易对种子失败!", err)
+	}
+
+	outcomes := &ExperimentOutcomes{}
+	nodes := &NodeComparisons{}
 
 	for _, ratio := range ratios {
+		fromHeight := NodeCompareStart()
+		sampler := NewNodeSampler(SampleInterval)
+		sampler.Start()
 		txs := SendTxBatchAndQueryLater(ratio.A, ratio.B, f)
+		sampler.Stop()
+
+		ClassifyTxs(txs, true)
+		outcomes.Add(NewOutcomeTable(fmt.Sprintf("%d:%d", ratio.A, ratio.B), txs))
+		// 按节点分发时逐个节点查询交易与区块
+		nodes.Add(CompareNodes(fmt.Sprintf("%d:%d", ratio.A, ratio.B), txs, fromHeight))
+
 		// 准备保存目标文件
 		err := SaveTxsToFile(txs, filepath.Join(targetDir, fmt.Sprintf("%d_%d.json", ratio.A, ratio.B)))
 		if err != nil {
 			fmt.Println("保存结果至文件失败!", err)
 		}
 
-		err = DrawTransactionLossStatus(txs, filepath.Join(targetDir, fmt.Sprintf("%d_%d_loss.png", ratio.A, ratio.B)))
-		if err != nil {
-			fmt.Println("生成交易上链情况图失败!", err)
-		}
-
-		err = DrawTransactionExecuteStatus(txs, filepath.Join(targetDir, fmt.Sprintf("%d_%d_execute.png", ratio.A, ratio.B)))
-		if err != nil {
-			fmt.Println("生成交易执行情况图失败!", err)
-		}
+		saveNodeSamples(sampler, txs, filepath.Join(targetDir, fmt.Sprintf("%d_%d_samples", ratio.A, ratio.B)))
 	}
 
+	sampler := NewNodeSampler(SampleInterval)
+	sampler.Start()
 	txs := LongTermDDoSAttack(f)
+	sampler.Stop()
+
+	ClassifyTxs(txs, true)
+	outcomes.Add(NewOutcomeTable("long_term", txs))
+	saveNodeSamples(sampler, txs, filepath.Join(targetDir, "LongTermExperiment_samples"))
 
 	// 准备保存目标文件
 	err = SaveTxsToFile(txs, filepath.Join(targetDir, "LongTermExperiment.json"))
@@ -321,6 +384,46 @@ func ConflictPairSeedExperiment(f *FuncPairSeed) {
 	if err != nil {
 		fmt.Println("保存结果至文件失败!", err)
 	}
+
+	// 保存交易结果分类统计表
+	err = outcomes.SaveToFile(filepath.Join(targetDir, OutcomesFileName), filepath.Join(targetDir, "outcomes.txt"))
+	if err != nil {
+		fmt.Println("保存交易结果统计表失败!", err)
+	}
+	Log.Log(utils.ConflictLog, "交易结果统计:\n"+outcomes.String())
+
+	if len(nodes.Comparisons) > 0 {
+		err = nodes.SaveToFile(filepath.Join(targetDir, NodeComparisonFileName))
+		if err != nil {
+			fmt.Println("保存各节点比较结果失败!", err)
+		}
+		Log.Log(utils.ConflictLog, "各节点交易结果:\n"+nodes.String())
+	}
+
+	event = f.Event(utils.ConflictLog, utils.EventExperiment, "冲突交易实验结束")
+	event.DurationMs = time.Since(start).Milliseconds()
+	event.Fields["result_dir"] = targetDir
+	event.Fields["outcomes"] = outcomes.Tables
+	Log.Emit(event)
+
+	return targetDir
+}
+
+// 保存节点状态时间序列及其图像，filePrefix 不含扩展名
+func saveNodeSamples(sampler *NodeSampler, txs []*Tx, filePrefix string) {
+	if !sampler.Enabled() {
+		return
+	}
+
+	err := sampler.SaveToFile(filePrefix + ".json")
+	if err != nil {
+		fmt.Println("保存节点状态至文件失败!", err)
+	}
+
+	err = sampler.Plot(txs, filePrefix+".png")
+	if err != nil {
+		fmt.Println("生成节点状态图失败!", err)
+	}
 }
 
 // 短时间发送1w笔交易，直到交易池完全空后查询结果
@@ -330,8 +433,9 @@ func SendTxBatchAndQueryLater(ratioA, ratioB int, f *FuncPairSeed) []*Tx {
 	Log.Log(utils.ConflictLog, fmt.Sprintf("Running experiment with ratio A:B = %d:%d", ratioA, ratioB))
 
 	// 获取经过时间戳排序后的所有交易的TxId
+	start := time.Now()
 	txs := generateAndTrackTransactions(ratioA, ratioB, 0, 100, f)
-	Log.Log(utils.ConflictLog, fmt.Sprintf("Generated %d transactions", len(txs)))
+	logTxBatch(f, txs, fmt.Sprintf("%d:%d", ratioA, ratioB), time.Since(start))
 
 	WaitForEmptyPool()
 
@@ -341,21 +445,38 @@ func SendTxBatchAndQueryLater(ratioA, ratioB int, f *FuncPairSeed) []*Tx {
 
 }
 
-// 长时间每秒发送，连续发送十分钟，主要观察交易发送状态，不需要获取交易的各种结果
+// 长时间每秒发送，连续发送十分钟，发送结束后等待交易池清空，再查询各交易的上链结果用于分类统计
 func LongTermDDoSAttack(f *FuncPairSeed) []*Tx {
 	Log := utils.Log
 	Log.Log(utils.ConflictLog, "开始长时间交易发送测试")
 
+	start := time.Now()
 	txs := generateAndTrackTransactions(85, 85, 1000, 600, f)
+	logTxBatch(f, txs, "long_term", time.Since(start))
 
-	Log.Log(utils.ConflictLog, fmt.Sprintf("长时间发送交易完成, Generated %d transactions", len(txs)))
-
-	// 发送后等待交易池空
+	// 发送后等待交易池空，再查询各交易的上链结果
 	WaitForEmptyPool()
-	// RecordTxsResultWithPool(txs)
+	RecordTxsResultWithPool(txs)
 	return txs
 }
 
+// 记录一批交易的发送情况
+func logTxBatch(f *FuncPairSeed, txs []*Tx, label string, duration time.Duration) {
+	sendErrors := 0
+	for _, tx := range txs {
+		if tx.SendError != "" {
+			sendErrors++
+		}
+	}
+
+	event := f.Event(utils.ConflictLog, utils.EventTxBatch, fmt.Sprintf("Generated %d transactions", len(txs)))
+	event.DurationMs = duration.Milliseconds()
+	event.Fields["label"] = label
+	event.Fields["txs"] = len(txs)
+	event.Fields["send_errors"] = sendErrors
+	utils.Log.Emit(event)
+}
+
 // SaveTxsToFile 将 []*Tx 类型数据保存为可绘图的 JSON 文件
 func SaveTxsToFile(txs []*Tx, filePath string) error {
 	// 打开文件
@@ -375,33 +496,8 @@ func SaveTxsToFile(txs []*Tx, filePath string) error {
 	return nil
 }
 
-// 画交易丢失情况图
-func DrawTransactionLossStatus(txs []*Tx, filePath string) error {
-	txsLossStatus := make([]bool, 0)
-
-	for i := 0; i < 1000; i++ {
-		txsLossStatus = append(txsLossStatus, txs[i].InPool || txs[i].OnChain)
-	}
-
-	return picture.DrawTransactionGrid(txsLossStatus, filePath)
-}
-
-// 画交易执行情况图
-func DrawTransactionExecuteStatus(txs []*Tx, filePath string) error {
-	txsLossStatus := make([]bool, 0)
-
-	txs = txs[0:1000]
-
-	for _, tx := range txs {
-		txsLossStatus = append(txsLossStatus, tx.ExecuteResult == 0)
-	}
-
-	return picture.DrawTransactionGrid(txsLossStatus, filePath)
-}
-
-// 画交易发送情况图
-func PlotLostTransactions(txs []*Tx, savePath string) error {
-	// 加载支持中文的字体
+// 加载支持中文的字体，并设置为默认字体
+func loadPlotFont() error {
 	fontBytes, err := ioutil.ReadFile("picture/simhei.ttf") // 请确保 simhei.ttf 字体文件在程序运行的目录下
 	if err != nil {
 		return err
@@ -425,6 +521,15 @@ func PlotLostTransactions(txs []*Tx, savePath string) error {
 	// 设置默认字体
 	plot.DefaultFont = font.Font{Typeface: fontName}
 
+	return nil
+}
+
+// 画交易发送情况图
+func PlotLostTransactions(txs []*Tx, savePath string) error {
+	if err := loadPlotFont(); err != nil {
+		return err
+	}
+
 	// 计算每秒钟丢失的交易数
 	lostTxsPerSecond := make(map[int64]int)
 	var minTime, maxTime int64
@@ -436,7 +541,7 @@ func PlotLostTransactions(txs []*Tx, savePath string) error {
 		if maxTime == 0 || timestamp > maxTime {
 			maxTime = timestamp
 		}
-		if tx.SendStatus != common.TxStatusCode_SUCCESS {
+		if tx.Outcome.IsRejected() {
 			lostTxsPerSecond[timestamp]++
 		}
 	}
@@ -0,0 +1,241 @@
+/*
+	本文件主要用于：
+
+	1. 按读写集形状对种子去重：
+			a. 交易种子的形状：函数名 + 读集 key 模板 + 写集 key 模板 + 读写相关路径 + 事件主题序列与事件相关路径
+			b. 交易对种子的形状：两个交易种子形状（与顺序无关）+ 冲突 key 模板
+		每种形状只保留一个代表种子
+
+	2. 统计每类种子的形状数、种子数及重复数
+*/
+
+package fuzz
+
+import (
+	"TransactionRwset/utils"
+	"container/list"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// 语料中的种子类别
+const (
+	CorpusFuncSeed = "func_seed"
+	CorpusConflict = "conflict"
+	CorpusMutate   = "mutate"
+)
+
+// 结果目录下保存语料统计的文件名
+const CorpusStatsFileName = "corpus_stats.json"
+
+// 一种读写集形状
+type CorpusEntry struct {
+	Kind           string   `json:"kind"`
+	Shape          string   `json:"shape"`
+	Functions      []string `json:"functions"`
+	Representative string   `json:"representative"` // 代表种子的标识
+	Count          int      `json:"count"`          // 具有该形状的种子数（含代表种子）
+
+	element *list.Element
+}
+
+type Corpus struct {
+	Entries map[string]*CorpusEntry `json:"entries"`
+}
+
+// 某类种子的统计
+type CorpusStats struct {
+	Kind       string         `json:"kind"`
+	Shapes     int            `json:"shapes"`
+	Seeds      int            `json:"seeds"`
+	Duplicates int            `json:"duplicates"`
+	ByFunction map[string]int `json:"by_function"` // 函数（或函数对）- 形状数
+}
+
+func NewCorpus() *Corpus {
+	return &Corpus{
+		Entries: make(map[string]*CorpusEntry),
+	}
+}
+
+// 去重并排序后的 key 模板
+func keyTemplates(keys []string) []string {
+	templates := make([]string, 0, len(keys))
+	for _, key := range keys {
+		templates = addUnique(templates, keyTemplate(key))
+	}
+	return templates
+}
+
+func pathSignature(paths []ValuePath) []string {
+	signature := make([]string, 0, len(paths))
+	for _, path := range paths {
+		signature = addUnique(signature, corpusKey(path))
+	}
+	return signature
+}
+
+// SeedShape 交易种子的读写集形状
+// 发出的事件主题序列不同（如转账成功与失败分支）时视为不同形状
+func SeedShape(seed *FuncSeed) string {
+	return fmt.Sprintf("%s|R:%s|W:%s|RP:%s|WP:%s|E:%s|EP:%s",
+		seed.FunctionName,
+		strings.Join(keyTemplates(seed.ReadSet), ","),
+		strings.Join(keyTemplates(seed.WriteSet), ","),
+		strings.Join(pathSignature(seed.ReadRelatedValuePaths), ","),
+		strings.Join(pathSignature(seed.WriteRelatedValuePaths), ","),
+		strings.Join(eventTopics(seed.Events), ","),
+		strings.Join(pathSignature(seed.EventRelatedValuePaths), ","),
+	)
+}
+
+// PairShape 交易对种子的读写集形状，与两个种子的顺序无关
+func PairShape(pair *FuncPairSeed) string {
+	shapes := []string{SeedShape(pair.SeedOne), SeedShape(pair.SeedTwo)}
+	sort.Strings(shapes)
+
+	conflicts := keyTemplates(ConflictKeys(pair.SeedOne, pair.SeedTwo))
+	return fmt.Sprintf("%s || %s || C:%s", shapes[0], shapes[1], strings.Join(conflicts, ","))
+}
+
+func entryKey(kind, shape string) string {
+	hash := sha1.Sum([]byte(kind + "\n" + shape))
+	return kind + "-" + hex.EncodeToString(hash[:])[:16]
+}
+
+// AddFuncSeed 加入一个交易种子，返回该形状是否为首次出现
+func (c *Corpus) AddFuncSeed(seed *FuncSeed) bool {
+	shape := SeedShape(seed)
+	key := entryKey(CorpusFuncSeed, shape)
+
+	if entry, ok := c.Entries[key]; ok {
+		entry.Count++
+		return false
+	}
+
+	c.Entries[key] = &CorpusEntry{
+		Kind:           CorpusFuncSeed,
+		Shape:          shape,
+		Functions:      []string{seed.FunctionName},
+		Representative: seed.ID(),
+		Count:          1,
+	}
+	return true
+}
+
+// AddPair 将交易对种子加入对应列表，形状已存在时不重复加入，返回是否加入
+// 对于可变异种子，若新种子相似度更高，则替换原代表种子
+func (c *Corpus) AddPair(kind string, pair *FuncPairSeed, seeds *list.List) bool {
+	shape := PairShape(pair)
+	key := entryKey(kind, shape)
+
+	if entry, ok := c.Entries[key]; ok {
+		entry.Count++
+		if kind == CorpusMutate && entry.element != nil {
+			if current := entry.element.Value.(*FuncPairSeed); pair.MaxSimilarity > current.MaxSimilarity {
+				entry.element.Value = pair
+				entry.Representative = pair.ID()
+			}
+		}
+		return false
+	}
+
+	c.Entries[key] = &CorpusEntry{
+		Kind:           kind,
+		Shape:          shape,
+		Functions:      []string{pair.SeedOne.FunctionName, pair.SeedTwo.FunctionName},
+		Representative: pair.ID(),
+		Count:          1,
+		element:        seeds.PushBack(pair),
+	}
+	return true
+}
+
+// 从文件加载种子池后，重新关联列表中的种子，并补齐缺失的形状
+func (c *Corpus) attach(kind string, seeds *list.List) {
+	for e := seeds.Front(); e != nil; e = e.Next() {
+		pair := e.Value.(*FuncPairSeed)
+		key := entryKey(kind, PairShape(pair))
+
+		entry, ok := c.Entries[key]
+		if !ok {
+			entry = &CorpusEntry{
+				Kind:           kind,
+				Shape:          PairShape(pair),
+				Functions:      []string{pair.SeedOne.FunctionName, pair.SeedTwo.FunctionName},
+				Representative: pair.ID(),
+				Count:          1,
+			}
+			c.Entries[key] = entry
+		}
+		entry.element = e
+	}
+}
+
+// 变异过程中种子从可变异列表移出后，解除关联
+func (c *Corpus) detach(e *list.Element) {
+	for _, entry := range c.Entries {
+		if entry.element == e {
+			entry.element = nil
+		}
+	}
+}
+
+// Stats 按类别统计形状数、种子数与重复数
+func (c *Corpus) Stats() []*CorpusStats {
+	stats := make(map[string]*CorpusStats)
+	for _, kind := range []string{CorpusFuncSeed, CorpusConflict, CorpusMutate} {
+		stats[kind] = &CorpusStats{Kind: kind, ByFunction: make(map[string]int)}
+	}
+
+	for _, entry := range c.Entries {
+		stat, ok := stats[entry.Kind]
+		if !ok {
+			continue
+		}
+		stat.Shapes++
+		stat.Seeds += entry.Count
+		stat.Duplicates += entry.Count - 1
+		stat.ByFunction[strings.Join(entry.Functions, " × ")]++
+	}
+
+	return []*CorpusStats{stats[CorpusFuncSeed], stats[CorpusConflict], stats[CorpusMutate]}
+}
+
+func (c *Corpus) SaveStats(filePath string) error {
+	data, err := json.MarshalIndent(c.Stats(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize CorpusStats: %v", err)
+	}
+
+	err = os.WriteFile(filePath, data, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write to file: %v", err)
+	}
+
+	return nil
+}
+
+// 将语料统计写入事件流
+func (c *Corpus) LogStats() {
+	for _, stat := range c.Stats() {
+		utils.Log.Emit(&utils.Event{
+			Level:   utils.LevelInfo,
+			Phase:   utils.ExecutionLog,
+			Type:    utils.EventCorpusStats,
+			Message: fmt.Sprintf("%s: %d shapes / %d seeds (%d duplicates)", stat.Kind, stat.Shapes, stat.Seeds, stat.Duplicates),
+			Fields: map[string]interface{}{
+				"kind":        stat.Kind,
+				"shapes":      stat.Shapes,
+				"seeds":       stat.Seeds,
+				"duplicates":  stat.Duplicates,
+				"by_function": stat.ByFunction,
+			},
+		})
+	}
+}
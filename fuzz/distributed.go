@@ -0,0 +1,439 @@
+/*
+	本文件主要用于：
+
+	1. 分布式模式下多个 worker 通过共享目录协作（类似 AFL 的 -M/-S）：
+			<sync>/pool.json              由主 worker（编号 0）生成的初始交易对种子池
+			<sync>/<worker>/queue/        各 worker 发布的新冲突种子
+			<sync>/<worker>/idle          worker 本地队列为空、正在等待其他 worker 的种子
+			<sync>/<worker>/stats.json    各 worker 的同步统计
+			<sync>/claims/                冲突实验的认领标记
+
+	2. 任务划分：
+			a. 可变异交易对按种子 ID 的哈希分配给各 worker，其余 worker 将其移出变异队列
+			b. 冲突交易对在实验前以独占方式创建认领标记，读写集形状相同的交易对只由一个 worker 实验
+
+	3. 定期同步：将其他 worker 发布的冲突种子导入本地冲突队列（按读写集形状去重）；
+	   本地队列清空后等待，直至所有 worker 都处于等待状态或长时间没有新种子
+
+	4. 共享目录可能残留上一次运行的文件：主 worker 启动时清除旧的种子池、认领标记、
+	   各 worker 的队列与状态；其余 worker 只接受在自己启动之后发布的种子池
+*/
+
+package fuzz
+
+import (
+	"TransactionRwset/utils"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 共享目录中的文件与目录名
+const (
+	SyncPoolFileName  = "pool.json"
+	SyncQueueDirName  = "queue"
+	SyncClaimDirName  = "claims"
+	SyncStatsFileName = "stats.json"
+	syncIdleFileName  = "idle"
+)
+
+// 结果目录下保存本 worker 同步统计的文件名
+const SyncResultFileName = "sync_stats.json"
+
+type SyncOptions struct {
+	Dir         string        // 共享目录
+	WorkerID    string        // worker 名称，对应共享目录下的子目录
+	Index       int           // worker 编号，0 为主 worker
+	Workers     int           // worker 总数
+	Interval    time.Duration // 同步间隔
+	IdleTimeout time.Duration // 本地队列为空时最长等待时间
+}
+
+// 单个 worker 的同步统计
+type SyncStats struct {
+	Worker     string `json:"worker"`
+	Published  int    `json:"published"`   // 发布的冲突种子数
+	Imported   int    `json:"imported"`    // 导入的冲突种子数
+	Duplicates int    `json:"duplicates"`  // 因读写集形状已存在而未导入的种子数
+	Assigned   int    `json:"assigned"`    // 分配给本 worker 的可变异交易对数
+	ClaimsWon  int    `json:"claims_won"`  // 由本 worker 进行的冲突实验数
+	ClaimsLost int    `json:"claims_lost"` // 已被其他 worker 认领的冲突交易对数
+}
+
+type syncDir struct {
+	opts     *SyncOptions
+	stats    *SyncStats
+	imported map[string]bool // 已导入的种子文件
+	lastSync time.Time
+	started  time.Time
+}
+
+// 分布式模式下的同步目录，单机模式下为 nil，各方法均不做处理
+var Sync *syncDir
+
+func NewSync(opts *SyncOptions) (*syncDir, error) {
+	if opts.Workers <= 0 || opts.Index < 0 || opts.Index >= opts.Workers {
+		return nil, fmt.Errorf("invalid worker index %d of %d workers", opts.Index, opts.Workers)
+	}
+	if opts.WorkerID == "" {
+		opts.WorkerID = fmt.Sprintf("worker%d", opts.Index)
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = time.Second
+	}
+
+	s := &syncDir{
+		opts:     opts,
+		stats:    &SyncStats{Worker: opts.WorkerID},
+		imported: make(map[string]bool),
+		started:  time.Now().Truncate(time.Second), // 部分文件系统的修改时间只精确到秒
+	}
+	if s.Main() {
+		if err := s.reset(); err != nil {
+			return nil, fmt.Errorf("failed to reset sync directory: %v", err)
+		}
+	}
+	for _, dir := range []string{s.queueDir(), filepath.Join(opts.Dir, SyncClaimDirName)} {
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+			return nil, fmt.Errorf("failed to create sync directory: %v", err)
+		}
+	}
+	s.setIdle(false)
+	return s, nil
+}
+
+// 清除上一次运行留下的种子池、认领标记与各 worker 的队列、等待标记和统计；
+// 其余 worker 在种子池发布之前不会发布种子，此时清除不会丢失本次运行的文件
+func (s *syncDir) reset() error {
+	stale := []string{filepath.Join(s.opts.Dir, SyncPoolFileName), filepath.Join(s.opts.Dir, SyncClaimDirName)}
+	entries, err := os.ReadDir(s.opts.Dir)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() && entry.Name() != SyncClaimDirName {
+			dir := filepath.Join(s.opts.Dir, entry.Name())
+			stale = append(stale, filepath.Join(dir, SyncQueueDirName), filepath.Join(dir, syncIdleFileName), filepath.Join(dir, SyncStatsFileName))
+		}
+	}
+
+	for _, path := range stale {
+		if err := os.RemoveAll(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *syncDir) workerDir() string {
+	return filepath.Join(s.opts.Dir, s.opts.WorkerID)
+}
+
+func (s *syncDir) queueDir() string {
+	return filepath.Join(s.workerDir(), SyncQueueDirName)
+}
+
+// Main 是否为负责生成初始种子池的主 worker
+func (s *syncDir) Main() bool {
+	return s == nil || s.opts.Index == 0
+}
+
+// 先写入临时文件再重命名，其他 worker 不会读到写了一半的文件
+func writeFileAtomic(filePath string, write func(string) error) error {
+	tmp := filePath + ".tmp"
+	if err := write(tmp); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, filePath); err != nil {
+		return fmt.Errorf("failed to rename %s: %v", tmp, err)
+	}
+	return nil
+}
+
+// PublishPool 主 worker 将初始种子池写入共享目录
+func (s *syncDir) PublishPool(pool *FuncPairSeedsPool) error {
+	return writeFileAtomic(filepath.Join(s.opts.Dir, SyncPoolFileName), pool.writeFile)
+}
+
+// WaitForPool 等待主 worker 发布初始种子池并读取；早于本 worker 启动的种子池属于上一次运行，不予读取，
+// 因此非主 worker 需在主 worker 发布种子池之前启动
+func (s *syncDir) WaitForPool(timeout time.Duration) (*FuncPairSeedsPool, error) {
+	filePath := filepath.Join(s.opts.Dir, SyncPoolFileName)
+	deadline := time.Now().Add(timeout)
+	for {
+		if info, err := os.Stat(filePath); err == nil && !info.ModTime().Before(s.started) {
+			return LoadPairSeedPoolFromFile(filePath)
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("seed pool %s not published within %v", filePath, timeout)
+		}
+		time.Sleep(s.opts.Interval)
+	}
+}
+
+func (s *syncDir) owns(seed *FuncPairSeed) bool {
+	h := fnv.New32a()
+	h.Write([]byte(seed.ID()))
+	return int(h.Sum32()%uint32(s.opts.Workers)) == s.opts.Index
+}
+
+// Partition 只保留分配给本 worker 的可变异交易对，其余移出变异队列
+func (s *syncDir) Partition(pool *FuncPairSeedsPool) {
+	if s == nil {
+		return
+	}
+
+	for e := pool.MutateSeeds.Front(); e != nil; {
+		next := e.Next()
+		seed := e.Value.(*FuncPairSeed)
+		if s.owns(seed) {
+			s.stats.Assigned++
+		} else {
+			scheduleOf(seed).Retired = RetiredWorker
+			pool.retireSeed(e)
+		}
+		e = next
+	}
+	utils.Log.Log(utils.ExecutionLog, fmt.Sprintf("worker %s (%d/%d) takes %d mutable pairs", s.opts.WorkerID, s.opts.Index, s.opts.Workers, s.stats.Assigned))
+}
+
+// Claim 认领冲突交易对的实验，已被其他 worker 认领时返回 false
+func (s *syncDir) Claim(seed *FuncPairSeed) bool {
+	if s == nil {
+		return true
+	}
+
+	sum := sha1.Sum([]byte(PairShape(seed)))
+	filePath := filepath.Join(s.opts.Dir, SyncClaimDirName, hex.EncodeToString(sum[:]))
+	file, err := os.OpenFile(filePath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if os.IsExist(err) {
+		s.stats.ClaimsLost++
+		return false
+	}
+	if err != nil {
+		// 无法创建认领标记时仍进行实验，重复实验好过遗漏
+		fmt.Println(err)
+		s.stats.ClaimsWon++
+		return true
+	}
+	fmt.Fprintf(file, "%s %s\n", s.opts.WorkerID, seed.ID())
+	file.Close()
+	s.stats.ClaimsWon++
+	return true
+}
+
+// Publish 发布新发现的冲突种子
+func (s *syncDir) Publish(seed *FuncPairSeed) {
+	if s == nil {
+		return
+	}
+
+	filePath := filepath.Join(s.queueDir(), fmt.Sprintf("%06d_%s.json", s.stats.Published, seed.ID()))
+	if err := writeFileAtomic(filePath, seed.SaveToFile); err != nil {
+		fmt.Println(err)
+		return
+	}
+	s.stats.Published++
+}
+
+// Due 距离上次同步是否已超过同步间隔
+func (s *syncDir) Due() bool {
+	return s != nil && time.Since(s.lastSync) >= s.opts.Interval
+}
+
+// 其他 worker 的目录
+func (s *syncDir) otherWorkers() []string {
+	entries, err := os.ReadDir(s.opts.Dir)
+	if err != nil {
+		fmt.Println(err)
+		return nil
+	}
+
+	workers := make([]string, 0)
+	for _, entry := range entries {
+		if entry.IsDir() && entry.Name() != s.opts.WorkerID && entry.Name() != SyncClaimDirName {
+			workers = append(workers, entry.Name())
+		}
+	}
+	sort.Strings(workers)
+	return workers
+}
+
+// Import 导入其他 worker 发布的冲突种子，返回加入本地冲突队列的种子数
+func (s *syncDir) Import(pool *FuncPairSeedsPool) int {
+	if s == nil {
+		return 0
+	}
+	s.lastSync = time.Now()
+
+	added, duplicates := 0, 0
+	sources := make(map[string]int)
+	for _, worker := range s.otherWorkers() {
+		queueDir := filepath.Join(s.opts.Dir, worker, SyncQueueDirName)
+		entries, err := os.ReadDir(queueDir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			filePath := filepath.Join(queueDir, entry.Name())
+			if !strings.HasSuffix(entry.Name(), ".json") || s.imported[filePath] {
+				continue
+			}
+			s.imported[filePath] = true
+
+			seed, err := LoadPairSeedFromFile(filePath)
+			if err != nil {
+				fmt.Println(err)
+				continue
+			}
+			if !pool.corpus().AddPair(CorpusConflict, seed, pool.ConflictSeeds) {
+				duplicates++
+				continue
+			}
+			if pool.ConflictMap != nil {
+				pool.ConflictMap.Record(seed.SeedOne, seed.SeedTwo, seed.MaxSimilarity, true)
+			}
+			added++
+			sources[worker]++
+		}
+	}
+
+	s.stats.Imported += added
+	s.stats.Duplicates += duplicates
+	if added > 0 || duplicates > 0 {
+		utils.Log.Emit(&utils.Event{
+			Level:   utils.LevelInfo,
+			Phase:   utils.ExecutionLog,
+			Type:    utils.EventSeedSync,
+			Message: fmt.Sprintf("imported %d conflict seeds, %d duplicates", added, duplicates),
+			Fields: map[string]interface{}{
+				"sources":    sources,
+				"duplicates": duplicates,
+			},
+		})
+	}
+	return added
+}
+
+func (s *syncDir) setIdle(idle bool) {
+	filePath := filepath.Join(s.workerDir(), syncIdleFileName)
+	if !idle {
+		os.Remove(filePath)
+		return
+	}
+	if err := os.WriteFile(filePath, []byte(time.Now().Format(time.RFC3339)), 0644); err != nil {
+		fmt.Println(err)
+	}
+}
+
+// 其他 worker 是否都已启动并处于等待状态
+func (s *syncDir) othersIdle() bool {
+	workers := s.otherWorkers()
+	if len(workers) < s.opts.Workers-1 {
+		return false
+	}
+	for _, worker := range workers {
+		if _, err := os.Stat(filepath.Join(s.opts.Dir, worker, syncIdleFileName)); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// WaitForSeeds 本地队列为空时等待其他 worker 发布新种子，返回导入的种子数；
+// 所有 worker 都在等待、超出等待时间或时间预算耗尽时返回 0
+func (s *syncDir) WaitForSeeds(pool *FuncPairSeedsPool) int {
+	if s == nil {
+		return 0
+	}
+
+	start := time.Now()
+	for {
+		// 导入前清除等待标记，其他 worker 不会在本 worker 取得新种子时判定全部结束
+		s.setIdle(false)
+		if n := s.Import(pool); n > 0 {
+			return n
+		}
+		s.setIdle(true)
+
+		if s.othersIdle() {
+			s.setIdle(false)
+			n := s.Import(pool)
+			s.setIdle(n == 0)
+			return n
+		}
+		if Schedule.Expired() || (s.opts.IdleTimeout > 0 && time.Since(start) >= s.opts.IdleTimeout) {
+			return 0
+		}
+		time.Sleep(s.opts.Interval)
+	}
+}
+
+// Finish 标记本 worker 不再产生新种子，并保存同步统计
+func (s *syncDir) Finish() {
+	if s == nil {
+		return
+	}
+
+	s.setIdle(true)
+	for _, filePath := range []string{filepath.Join(s.workerDir(), SyncStatsFileName), filepath.Join(utils.Log.BaseDir, SyncResultFileName)} {
+		if err := s.stats.SaveToFile(filePath); err != nil {
+			fmt.Println(err)
+		}
+	}
+	utils.Log.Log(utils.ExecutionLog, fmt.Sprintf("worker %s: published %d, imported %d (%d duplicates), experiments claimed %d, lost %d",
+		s.stats.Worker, s.stats.Published, s.stats.Imported, s.stats.Duplicates, s.stats.ClaimsWon, s.stats.ClaimsLost))
+}
+
+func (st *SyncStats) SaveToFile(filePath string) error {
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize SyncStats: %v", err)
+	}
+
+	err = os.WriteFile(filePath, data, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write to file: %v", err)
+	}
+
+	return nil
+}
+
+func LoadSyncStats(filePath string) (*SyncStats, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &SyncStats{}
+	if err := json.Unmarshal(data, stats); err != nil {
+		return nil, fmt.Errorf("failed to parse SyncStats: %v", err)
+	}
+	return stats, nil
+}
+
+// LoadAllSyncStats 读取共享目录下各 worker 的同步统计
+func LoadAllSyncStats(dir string) []*SyncStats {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	all := make([]*SyncStats, 0)
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if stats, err := LoadSyncStats(filepath.Join(dir, entry.Name(), SyncStatsFileName)); err == nil {
+			all = append(all, stats)
+		}
+	}
+	return all
+}
@@ -0,0 +1,447 @@
+/*
+	本文件主要用于：
+
+	1. 交易信封模糊测试：合约参数保持种子中的取值，只修改交易信封本身：
+			a. 交易 ID：重复使用已上链交易的 ID、两笔不同交易使用相同 ID、空 ID、超长非法 ID
+			b. 时间戳：超出允许窗口的过去与未来时间、零值
+			c. 过期时间：已过期、极远的将来
+			d. gas 上限：零、极小、最大值、不设置
+			e. 参数：超大参数列表、超大参数值、重复的参数 key
+			f. Sequence 与链 ID
+		每个函数以种子池中的第一个交易种子为基础，按变体依次发送
+
+	2. 根据返回的状态码与信息判断交易在哪一环节被拒绝：
+			a. sdk：客户端签名或发送失败，未到达节点
+			b. api_validate：节点 ApiService.validate 拒绝（链配置、签名与交易校验）
+			c. tx_filter：交易过滤器判定交易 ID 已存在
+			d. tx_pool：交易池拒绝（时间戳超出窗口、交易池已满等）
+			e. accepted：节点已接收，但在等待时间内未返回执行结果
+			f. committed：已上链并返回执行结果
+*/
+
+package fuzz
+
+import (
+	nodecontrol "TransactionRwset/nodeControl"
+	"TransactionRwset/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"chainmaker.org/chainmaker/pb-go/v2/common"
+)
+
+// 结果目录下保存交易信封测试结果的文件名
+const EnvelopeResultFileName = "envelope.json"
+
+// 交易被拒绝或接收的环节
+const (
+	EnvelopeStageSDK       = "sdk"
+	EnvelopeStageValidate  = "api_validate"
+	EnvelopeStageTxFilter  = "tx_filter"
+	EnvelopeStagePool      = "tx_pool"
+	EnvelopeStageAccepted  = "accepted"
+	EnvelopeStageCommitted = "committed"
+)
+
+// 统计表中各环节的输出顺序
+var EnvelopeStages = []string{
+	EnvelopeStageSDK,
+	EnvelopeStageValidate,
+	EnvelopeStageTxFilter,
+	EnvelopeStagePool,
+	EnvelopeStageAccepted,
+	EnvelopeStageCommitted,
+}
+
+const (
+	// 超出节点时间戳校验窗口（默认 600 秒）的偏移
+	envelopeTimestampOffset = int64(3600)
+	// 超大参数列表的参数个数与超大参数值的字节数
+	envelopeManyParams = 10000
+	envelopeLargeValue = 4 << 20
+	// 信封变体额外添加的参数 key 前缀
+	envelopeParamKey = "__envelope"
+)
+
+type EnvelopeOptions struct {
+	PoolFile string // 为空时先生成种子池
+	Variants string // 逗号分隔的变体名，为空表示全部
+}
+
+// 一种交易信封变体
+// build 返回需要依次发送的 payload，除最后一个外均异步发送，最后一个等待执行结果
+type envelopeVariant struct {
+	name        string
+	description string
+	build       func(fresh func() (*common.Payload, error), baselineTxId string) ([]*common.Payload, error)
+}
+
+// 变体在当前条件下无法构造，如基准交易未上链时无法复用其交易 ID
+var errEnvelopeNotApplicable = errors.New("not applicable")
+
+func withPayload(modify func(payload *common.Payload)) func(func() (*common.Payload, error), string) ([]*common.Payload, error) {
+	return func(fresh func() (*common.Payload, error), _ string) ([]*common.Payload, error) {
+		payload, err := fresh()
+		if err != nil {
+			return nil, err
+		}
+		modify(payload)
+		return []*common.Payload{payload}, nil
+	}
+}
+
+var envelopeVariants = []*envelopeVariant{
+	{"baseline", "unmodified envelope", withPayload(func(p *common.Payload) {})},
+	{"reuse_tx_id", "reuse the tx ID of the committed baseline tx", func(fresh func() (*common.Payload, error), baselineTxId string) ([]*common.Payload, error) {
+		if baselineTxId == "" {
+			return nil, fmt.Errorf("baseline tx not committed: %w", errEnvelopeNotApplicable)
+		}
+		payload, err := fresh()
+		if err != nil {
+			return nil, err
+		}
+		payload.TxId = baselineTxId
+		return []*common.Payload{payload}, nil
+	}},
+	{"colliding_tx_id", "two different txs sent back to back with the same new tx ID", func(fresh func() (*common.Payload, error), _ string) ([]*common.Payload, error) {
+		first, err := fresh()
+		if err != nil {
+			return nil, err
+		}
+		second, err := fresh()
+		if err != nil {
+			return nil, err
+		}
+		second.TxId = first.TxId
+		second.Parameters = append(second.Parameters, &common.KeyValuePair{Key: envelopeParamKey, Value: []byte("collide")})
+		return []*common.Payload{first, second}, nil
+	}},
+	{"empty_tx_id", "empty tx ID", withPayload(func(p *common.Payload) { p.TxId = "" })},
+	{"malformed_tx_id", "300 characters of non-hex tx ID", withPayload(func(p *common.Payload) { p.TxId = strings.Repeat("z", 300) })},
+	{"timestamp_past", "timestamp one hour in the past", withPayload(func(p *common.Payload) { p.Timestamp -= envelopeTimestampOffset })},
+	{"timestamp_future", "timestamp one hour in the future", withPayload(func(p *common.Payload) { p.Timestamp += envelopeTimestampOffset })},
+	{"timestamp_zero", "zero timestamp", withPayload(func(p *common.Payload) { p.Timestamp = 0 })},
+	{"expiration_past", "expiration time before the timestamp", withPayload(func(p *common.Payload) { p.ExpirationTime = p.Timestamp - 60 })},
+	{"expiration_far", "expiration time ten years ahead", withPayload(func(p *common.Payload) { p.ExpirationTime = p.Timestamp + 10*365*24*3600 })},
+	{"gas_limit_zero", "gas limit 0", withPayload(func(p *common.Payload) { p.Limit = &common.Limit{GasLimit: 0} })},
+	{"gas_limit_one", "gas limit 1", withPayload(func(p *common.Payload) { p.Limit = &common.Limit{GasLimit: 1} })},
+	{"gas_limit_max", "gas limit MaxUint64", withPayload(func(p *common.Payload) { p.Limit = &common.Limit{GasLimit: math.MaxUint64} })},
+	{"no_limit", "no limit attached", withPayload(func(p *common.Payload) { p.Limit = nil })},
+	{"many_params", fmt.Sprintf("%d extra parameters", envelopeManyParams), withPayload(func(p *common.Payload) {
+		for i := 0; i < envelopeManyParams; i++ {
+			p.Parameters = append(p.Parameters, &common.KeyValuePair{Key: fmt.Sprintf("%s_%d", envelopeParamKey, i), Value: []byte("1")})
+		}
+	})},
+	{"large_param", fmt.Sprintf("one extra parameter of %d bytes", envelopeLargeValue), withPayload(func(p *common.Payload) {
+		p.Parameters = append(p.Parameters, &common.KeyValuePair{Key: envelopeParamKey, Value: []byte(strings.Repeat("a", envelopeLargeValue))})
+	})},
+	{"duplicate_param_keys", "every parameter repeated with a different value", withPayload(func(p *common.Payload) {
+		if len(p.Parameters) == 0 {
+			p.Parameters = append(p.Parameters, &common.KeyValuePair{Key: envelopeParamKey, Value: []byte("1")})
+		}
+		for _, kv := range append([]*common.KeyValuePair{}, p.Parameters...) {
+			p.Parameters = append(p.Parameters, &common.KeyValuePair{Key: kv.Key, Value: append(append([]byte{}, kv.Value...), "dup"...)})
+		}
+	})},
+	{"sequence_one", "sequence 1", withPayload(func(p *common.Payload) { p.Sequence = 1 })},
+	{"sequence_max", "sequence MaxUint64", withPayload(func(p *common.Payload) { p.Sequence = math.MaxUint64 })},
+	{"unknown_chain_id", "chain ID of a chain the node does not serve", withPayload(func(p *common.Payload) { p.ChainId += "_fuzz" })},
+}
+
+// 信封变体名列表
+func EnvelopeVariantNames() []string {
+	names := make([]string, 0, len(envelopeVariants))
+	for _, variant := range envelopeVariants {
+		names = append(names, variant.name)
+	}
+	return names
+}
+
+// 信封变体中的单笔交易
+type EnvelopeTx struct {
+	TxId         string `json:"tx_id"`
+	Stage        string `json:"stage"`
+	Code         string `json:"code"`
+	Message      string `json:"message,omitempty"`
+	ContractCode string `json:"contract_code,omitempty"` // 已上链时合约的执行结果
+	DurationMs   int64  `json:"duration_ms"`
+}
+
+// 一个函数在一种信封变体下的结果
+type EnvelopeResult struct {
+	Function    string        `json:"function"`
+	Variant     string        `json:"variant"`
+	Description string        `json:"description"`
+	Txs         []*EnvelopeTx `json:"txs"`
+}
+
+// 最后一笔交易的处理环节，作为该变体的结果
+func (r *EnvelopeResult) Stage() string {
+	if len(r.Txs) == 0 {
+		return ""
+	}
+	return r.Txs[len(r.Txs)-1].Stage
+}
+
+type EnvelopeReport struct {
+	PoolFile string            `json:"pool_file,omitempty"`
+	Results  []*EnvelopeResult `json:"results"`
+}
+
+// 各变体在各环节的结果数，键为变体名与环节
+func (r *EnvelopeReport) StageCounts() map[string]map[string]int {
+	counts := make(map[string]map[string]int)
+	for _, result := range r.Results {
+		if counts[result.Variant] == nil {
+			counts[result.Variant] = make(map[string]int)
+		}
+		counts[result.Variant][result.Stage()]++
+	}
+	return counts
+}
+
+// 根据响应判断交易在哪一环节被拒绝或接收
+func classifyEnvelope(resp *common.TxResponse, err error) string {
+	if resp == nil {
+		return EnvelopeStageSDK
+	}
+
+	message := resp.Message
+	if err != nil {
+		message += " " + err.Error()
+	}
+	switch {
+	case strings.Contains(message, "Add tx failed"):
+		if utils.MessageContains(message, "exist", "duplicate", "filter") {
+			return EnvelopeStageTxFilter
+		}
+		return EnvelopeStagePool
+	case strings.Contains(message, "ERR_CODE_"):
+		return EnvelopeStageValidate
+	case resp.ContractResult != nil:
+		return EnvelopeStageCommitted
+	case resp.Code == common.TxStatusCode_SUCCESS || resp.Code == common.TxStatusCode_TIMEOUT:
+		return EnvelopeStageAccepted
+	default:
+		return EnvelopeStageSDK
+	}
+}
+
+func sendEnvelope(payload *common.Payload, withSyncResult bool) *EnvelopeTx {
+	start := time.Now()
+	resp, err := nodecontrol.ChainmakerController.SendPayload(payload, withSyncResult)
+
+	tx := &EnvelopeTx{
+		TxId:       payload.TxId,
+		Stage:      classifyEnvelope(resp, err),
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+	if resp != nil {
+		tx.Code = resp.Code.String()
+		tx.Message = resp.Message
+		if resp.ContractResult != nil {
+			tx.ContractCode = fmt.Sprintf("%d", resp.ContractResult.Code)
+			if resp.ContractResult.Message != "" {
+				tx.Message = strings.TrimSpace(tx.Message + " " + resp.ContractResult.Message)
+			}
+		}
+	}
+	if err != nil {
+		tx.Message = strings.TrimSpace(tx.Message + " " + err.Error())
+	}
+	return tx
+}
+
+// 选出需要测试的变体
+func selectEnvelopeVariants(names string) ([]*envelopeVariant, error) {
+	if strings.TrimSpace(names) == "" {
+		return envelopeVariants, nil
+	}
+
+	variants := make([]*envelopeVariant, 0)
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		found := false
+		for _, variant := range envelopeVariants {
+			if variant.name == name {
+				variants = append(variants, variant)
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown envelope variant %q (variants: %s)", name, strings.Join(EnvelopeVariantNames(), ", "))
+		}
+	}
+	return variants, nil
+}
+
+// 每个函数取种子池中出现的第一个交易种子
+func envelopeSeeds(pool *FuncPairSeedsPool) []*FuncSeed {
+	seen := make(map[string]*FuncSeed)
+	for _, pair := range pool.AllPairSeeds() {
+		for _, seed := range []*FuncSeed{pair.SeedOne, pair.SeedTwo} {
+			if _, ok := seen[seed.FunctionName]; !ok {
+				seen[seed.FunctionName] = seed
+			}
+		}
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	seeds := make([]*FuncSeed, 0, len(names))
+	for _, name := range names {
+		seeds = append(seeds, seen[name])
+	}
+	return seeds
+}
+
+// FuzzEnvelope 以种子池中各函数的交易种子为基础，依次发送各种交易信封变体
+func FuzzEnvelope(pool *FuncPairSeedsPool, opts *EnvelopeOptions) (*EnvelopeReport, error) {
+	variants, err := selectEnvelopeVariants(opts.Variants)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &EnvelopeReport{PoolFile: opts.PoolFile, Results: make([]*EnvelopeResult, 0)}
+	for _, seed := range envelopeSeeds(pool) {
+		kvs := seed.convertMapToKeyValuePair(seed.FunctionInput)
+		fresh := func() (*common.Payload, error) {
+			return nodecontrol.ChainmakerController.NewInvokePayload(utils.GlobalContractInfo.ContractName, seed.FunctionName, kvs)
+		}
+
+		// 基准交易先行上链，供重复交易 ID 的变体使用
+		baselineTxId := ""
+		for _, variant := range variants {
+			payloads, err := variant.build(fresh, baselineTxId)
+			if errors.Is(err, errEnvelopeNotApplicable) {
+				utils.Log.Emit(&utils.Event{
+					Level:     utils.LevelInfo,
+					Phase:     utils.ExecutionLog,
+					Type:      utils.EventEnvelope,
+					Message:   fmt.Sprintf("%s: skipped, %v", variant.name, err),
+					SeedIDs:   []string{seed.ID()},
+					Functions: []string{seed.FunctionName},
+					Fields:    map[string]interface{}{"variant": variant.name},
+				})
+				continue
+			}
+			if err != nil {
+				// 无法构造交易时其余变体同样无法发送，跳过该函数
+				utils.Log.Emit(&utils.Event{
+					Level:     utils.LevelError,
+					Phase:     utils.ExecutionLog,
+					Type:      utils.EventEnvelope,
+					Message:   fmt.Sprintf("failed to build payload of %s: %v", seed.FunctionName, err),
+					SeedIDs:   []string{seed.ID()},
+					Functions: []string{seed.FunctionName},
+					Fields:    map[string]interface{}{"variant": variant.name},
+				})
+				break
+			}
+
+			result := &EnvelopeResult{Function: seed.FunctionName, Variant: variant.name, Description: variant.description}
+			for i, payload := range payloads {
+				result.Txs = append(result.Txs, sendEnvelope(payload, i == len(payloads)-1))
+			}
+			if variant.name == "baseline" && result.Stage() == EnvelopeStageCommitted {
+				baselineTxId = result.Txs[0].TxId
+			}
+			report.Results = append(report.Results, result)
+
+			last := result.Txs[len(result.Txs)-1]
+			level := utils.LevelInfo
+			if last.Stage == EnvelopeStageCommitted && variant.name != "baseline" {
+				// 非常规信封仍然上链，需要关注
+				level = utils.LevelWarn
+			}
+			utils.Log.Emit(&utils.Event{
+				Level:      level,
+				Phase:      utils.ExecutionLog,
+				Type:       utils.EventEnvelope,
+				Message:    fmt.Sprintf("%s: %s %s", variant.name, last.Stage, last.Message),
+				SeedIDs:    []string{seed.ID()},
+				Functions:  []string{seed.FunctionName},
+				DurationMs: last.DurationMs,
+				Fields: map[string]interface{}{
+					"variant": variant.name,
+					"txs":     result.Txs,
+				},
+			})
+		}
+	}
+
+	if err := report.SaveToFile(filepath.Join(utils.Log.BaseDir, EnvelopeResultFileName)); err != nil {
+		fmt.Println(err)
+	}
+	return report, nil
+}
+
+func (r *EnvelopeReport) SaveToFile(filePath string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize EnvelopeReport: %v", err)
+	}
+
+	err = os.WriteFile(filePath, data, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write to file: %v", err)
+	}
+
+	return nil
+}
+
+func LoadEnvelopeReportFromFile(filePath string) (*EnvelopeReport, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &EnvelopeReport{}
+	if err := json.Unmarshal(data, report); err != nil {
+		return nil, fmt.Errorf("failed to parse EnvelopeReport: %v", err)
+	}
+	return report, nil
+}
+
+// 以文本形式输出测试结果：各函数在各变体下的处理环节，以及各变体的汇总
+func (r *EnvelopeReport) String() string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("  %-20s %-22s %-12s %-28s %s\n", "function", "variant", "stage", "code", "message"))
+	for _, result := range r.Results {
+		last := result.Txs[len(result.Txs)-1]
+		message := last.Message
+		if len(message) > 120 {
+			message = message[:120] + "..."
+		}
+		sb.WriteString(fmt.Sprintf("  %-20s %-22s %-12s %-28s %s\n", result.Function, result.Variant, last.Stage, last.Code, message))
+	}
+
+	sb.WriteString("\nsummary\n")
+	counts := r.StageCounts()
+	for _, name := range EnvelopeVariantNames() {
+		stages, ok := counts[name]
+		if !ok {
+			continue
+		}
+		parts := make([]string, 0)
+		for _, stage := range EnvelopeStages {
+			if stages[stage] > 0 {
+				parts = append(parts, fmt.Sprintf("%s: %d", stage, stages[stage]))
+			}
+		}
+		sb.WriteString(fmt.Sprintf("  %-22s %s\n", name, strings.Join(parts, ", ")))
+	}
+	return sb.String()
+}
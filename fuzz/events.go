@@ -0,0 +1,224 @@
+/*
+	本文件主要用于：
+
+	1. 从交易执行结果中读取合约事件（主题、数据及发出顺序），作为交易种子行为的一部分，
+	   与读写集一同参与读写相关路径分析、种子形状去重以及重放比较
+
+	2. 事件与写集一致性检查，只检查表示状态变更的事件（如 transfer、mint、approve）：
+			a. 交易执行成功并发出此类事件，但写集为空
+			b. 事件数据中的地址、编号等均未出现在写集 key 中，例如发出 Transfer 事件却没有写入所有权
+		相同函数、相同检查、相同主题的问题只保留第一个示例并计数
+*/
+
+package fuzz
+
+import (
+	"TransactionRwset/utils"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"chainmaker.org/chainmaker/pb-go/v2/common"
+)
+
+// 结果目录下保存事件检查结果的文件名
+const EventOracleFileName = "event_oracle.json"
+
+const (
+	EventMismatchWithoutWrite = "event_without_write"
+	EventMismatchNotWritten   = "event_payload_not_written"
+)
+
+// 表示状态变更的事件主题
+var stateEventTopicPattern = regexp.MustCompile(`(?i)transfer|mint|burn|approv|issue|vote|create|delete|update|register|deposit|withdraw`)
+
+// 事件数据中参与检查的取值最大长度，更长的取值多为序列化的整体数据
+const maxEventValueLength = 128
+
+// 合约事件，按发出顺序保存
+type ContractEvent struct {
+	Topic string   `json:"topic"`
+	Data  []string `json:"data,omitempty"`
+}
+
+func (e *ContractEvent) String() string {
+	return fmt.Sprintf("%s(%s)", e.Topic, strings.Join(e.Data, ","))
+}
+
+// 读取交易执行结果中的合约事件
+func convertContractEvents(txInfo *common.TransactionInfoWithRWSet) []*ContractEvent {
+	events := make([]*ContractEvent, 0)
+	for _, event := range txInfo.GetTransaction().GetResult().GetContractResult().GetContractEvent() {
+		events = append(events, &ContractEvent{Topic: event.Topic, Data: event.EventData})
+	}
+	return events
+}
+
+// 两组事件的主题、数据及顺序是否完全一致
+func sameEvents(a, b []*ContractEvent) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].String() != b[i].String() {
+			return false
+		}
+	}
+	return true
+}
+
+// 事件主题序列，用于种子形状
+func eventTopics(events []*ContractEvent) []string {
+	topics := make([]string, 0, len(events))
+	for _, event := range events {
+		topics = append(topics, event.Topic)
+	}
+	return topics
+}
+
+// 事件与写集不一致的问题
+type EventMismatch struct {
+	Function string                 `json:"function"`
+	Kind     string                 `json:"kind"`
+	Event    *ContractEvent         `json:"event"`
+	Input    map[string]interface{} `json:"input"`
+	WriteSet []string               `json:"write_set"`
+	Message  string                 `json:"message"`
+	Count    int                    `json:"count"` // 出现次数
+}
+
+type eventOracle struct {
+	mu         sync.Mutex
+	mismatches map[string]*EventMismatch
+}
+
+// 全局事件检查结果
+var EventOracle = &eventOracle{mismatches: make(map[string]*EventMismatch)}
+
+// 检查一次成功执行的交易种子所发出的事件与其写集是否一致
+func (o *eventOracle) Check(seed *FuncSeed) {
+	for _, event := range seed.Events {
+		if kind, message := eventMismatch(event, seed.WriteSet); kind != "" {
+			o.record(&EventMismatch{
+				Function: seed.FunctionName,
+				Kind:     kind,
+				Event:    event,
+				Input:    seed.FunctionInput,
+				WriteSet: seed.WriteSet,
+				Message:  message,
+			})
+		}
+	}
+}
+
+// 只检查主题表示状态变化的事件，日志类事件不要求有对应的写操作
+func eventMismatch(event *ContractEvent, writeSet []string) (string, string) {
+	if !stateEventTopicPattern.MatchString(event.Topic) {
+		return "", ""
+	}
+	if len(writeSet) == 0 {
+		return EventMismatchWithoutWrite, fmt.Sprintf("event %s emitted without any write", event.Topic)
+	}
+
+	values := make([]string, 0, len(event.Data))
+	for _, value := range event.Data {
+		value = strings.ToLower(strings.TrimSpace(value))
+		if value == "" || value == "true" || value == "false" || len(value) > maxEventValueLength {
+			continue
+		}
+		values = append(values, value)
+	}
+	if len(values) == 0 {
+		return "", ""
+	}
+
+	for _, key := range writeSet {
+		key = strings.ToLower(key)
+		for _, value := range values {
+			if strings.Contains(key, value) {
+				return "", ""
+			}
+		}
+	}
+	return EventMismatchNotWritten, fmt.Sprintf("none of the values of event %s appears in the write set", event.String())
+}
+
+func (o *eventOracle) record(mismatch *EventMismatch) {
+	key := mismatch.Function + "|" + mismatch.Kind + "|" + mismatch.Event.Topic
+
+	o.mu.Lock()
+	existing, ok := o.mismatches[key]
+	if ok {
+		existing.Count++
+	} else {
+		mismatch.Count = 1
+		o.mismatches[key] = mismatch
+	}
+	o.mu.Unlock()
+
+	if ok {
+		return
+	}
+	utils.Log.Emit(&utils.Event{
+		Level:     utils.LevelWarn,
+		Phase:     utils.ExecutionLog,
+		Type:      utils.EventEventMismatch,
+		Message:   mismatch.Message,
+		Functions: []string{mismatch.Function},
+		WriteSet:  mismatch.WriteSet,
+		Fields: map[string]interface{}{
+			"kind":  mismatch.Kind,
+			"event": mismatch.Event,
+			"input": mismatch.Input,
+		},
+	})
+}
+
+// 按函数、检查类别与主题排序的检查结果
+func (o *eventOracle) Mismatches() []*EventMismatch {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	keys := make([]string, 0, len(o.mismatches))
+	for key := range o.mismatches {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	mismatches := make([]*EventMismatch, 0, len(keys))
+	for _, key := range keys {
+		mismatches = append(mismatches, o.mismatches[key])
+	}
+	return mismatches
+}
+
+func (o *eventOracle) Save(filePath string) error {
+	data, err := json.MarshalIndent(o.Mismatches(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize EventMismatch: %v", err)
+	}
+
+	err = os.WriteFile(filePath, data, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write to file: %v", err)
+	}
+
+	return nil
+}
+
+func LoadEventMismatches(filePath string) ([]*EventMismatch, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var mismatches []*EventMismatch
+	if err := json.Unmarshal(data, &mismatches); err != nil {
+		return nil, fmt.Errorf("failed to parse EventMismatch: %v", err)
+	}
+	return mismatches, nil
+}
@@ -0,0 +1,457 @@
+/*
+	本文件主要用于：
+
+	1. 记录每次同步执行交易消耗的 gas，以及执行时的输入大小（参数编码后的字节数）与
+	   状态大小（部署合约或恢复账本快照以来观察到写入过的不同 key 数量）
+
+	2. gas 搜索：对每个函数从 gas 消耗最高的交易种子出发，随机变异参数并重新执行，
+	   保留使 gas 增加的变异，达到 gas 上限的输入记录为 gas 耗尽异常；
+	   搜索得到的输入若产生新的读写集形状，同样加入交易种子池
+
+	3. gas 增长检测：同一函数的 gas 与输入大小或状态大小的秩相关系数足够高且增幅明显时报告为异常，
+	   随状态增长多为对状态的无界遍历（如 StoreMap 迭代器）
+*/
+
+package fuzz
+
+import (
+	nodecontrol "TransactionRwset/nodeControl"
+	"TransactionRwset/utils"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/mitchellh/copystructure"
+)
+
+// 结果目录下保存 gas 统计的文件名
+const GasProfileFileName = "gas_profile.json"
+
+// gas 搜索中每个函数最多执行的交易数，0 表示不进行搜索
+var GasSearchExecs = 30
+
+const (
+	GasAnomalyOutOfGas       = "out_of_gas"
+	GasAnomalyGrowsWithInput = "grows_with_input"
+	GasAnomalyGrowsWithState = "grows_with_state"
+)
+
+// gas 增长检测的阈值：最少样本数、最少不同取值数、最低秩相关系数、最大与最小 gas 之比
+const (
+	gasMinSamples     = 5
+	gasMinDistinct    = 3
+	gasMinCorrelation = 0.8
+	gasMinGrowth      = 1.5
+)
+
+// 每个函数最多记录的 gas 耗尽输入数
+const maxOutOfGasPerFunc = 5
+
+// 一次执行的 gas 样本
+type GasSample struct {
+	InputSize int    `json:"input_size"`
+	StateSize int    `json:"state_size"`
+	GasUsed   uint64 `json:"gas_used"`
+	OutOfGas  bool   `json:"out_of_gas,omitempty"`
+}
+
+// 单个函数的 gas 统计
+type GasStat struct {
+	Function         string                 `json:"function"`
+	Samples          int                    `json:"samples"`
+	Min              uint64                 `json:"min"`
+	Max              uint64                 `json:"max"`
+	Mean             uint64                 `json:"mean"`
+	OutOfGas         int                    `json:"out_of_gas"`
+	MaxInput         map[string]interface{} `json:"max_input,omitempty"` // gas 消耗最高的输入
+	InputCorrelation float64                `json:"input_correlation"`
+	StateCorrelation float64                `json:"state_correlation"`
+}
+
+type GasAnomaly struct {
+	Function    string                 `json:"function"`
+	Kind        string                 `json:"kind"`
+	Input       map[string]interface{} `json:"input,omitempty"`
+	GasUsed     uint64                 `json:"gas_used,omitempty"`
+	Correlation float64                `json:"correlation,omitempty"`
+	Message     string                 `json:"message"`
+}
+
+type GasProfile struct {
+	Limit     uint64        `json:"limit"`
+	Functions []*GasStat    `json:"functions"`
+	Anomalies []*GasAnomaly `json:"anomalies"`
+}
+
+type gasTracker struct {
+	mu       sync.Mutex
+	samples  map[string][]GasSample
+	maxInput map[string]map[string]interface{}
+	maxGas   map[string]uint64
+	written  map[string]struct{} // 观察到写入过的 key，近似合约状态大小
+	outOfGas []*GasAnomaly
+	seen     map[string]bool // 已记录的 gas 耗尽输入
+}
+
+// 全局 gas 记录
+var Gas = newGasTracker()
+
+func newGasTracker() *gasTracker {
+	return &gasTracker{
+		samples:  make(map[string][]GasSample),
+		maxInput: make(map[string]map[string]interface{}),
+		maxGas:   make(map[string]uint64),
+		written:  make(map[string]struct{}),
+		seen:     make(map[string]bool),
+	}
+}
+
+// 记录一次同步执行的 gas，未返回 gas 的执行不计入
+func (g *gasTracker) Sample(seed *FuncSeed) {
+	inputSize := 0
+	for _, kv := range seed.convertMapToKeyValuePair(seed.FunctionInput) {
+		inputSize += len(kv.Key) + len(kv.Value)
+	}
+
+	g.mu.Lock()
+	stateSize := len(g.written)
+	for _, key := range seed.WriteSet {
+		g.written[key] = struct{}{}
+	}
+	if seed.GasUsed > 0 || seed.OutOfGas {
+		g.samples[seed.FunctionName] = append(g.samples[seed.FunctionName], GasSample{
+			InputSize: inputSize,
+			StateSize: stateSize,
+			GasUsed:   seed.GasUsed,
+			OutOfGas:  seed.OutOfGas,
+		})
+		if _, ok := g.maxInput[seed.FunctionName]; !ok || seed.GasUsed > g.maxGas[seed.FunctionName] {
+			g.maxGas[seed.FunctionName] = seed.GasUsed
+			g.maxInput[seed.FunctionName] = seed.FunctionInput
+		}
+	}
+	g.mu.Unlock()
+
+	if seed.OutOfGas {
+		g.RecordOutOfGas(seed.FunctionName, seed.FunctionInput, seed.GasUsed, "")
+	}
+}
+
+// 记录 gas 耗尽的输入，相同输入只记录一次
+func (g *gasTracker) RecordOutOfGas(funcName string, input map[string]interface{}, gasUsed uint64, message string) {
+	data, _ := json.Marshal(input)
+	key := funcName + ":" + string(data)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.seen[key] {
+		return
+	}
+	g.seen[key] = true
+
+	count := 0
+	for _, anomaly := range g.outOfGas {
+		if anomaly.Function == funcName {
+			count++
+		}
+	}
+	if count >= maxOutOfGasPerFunc {
+		return
+	}
+
+	if message == "" {
+		message = fmt.Sprintf("gas used %d reached the limit %d", gasUsed, nodecontrol.InvokeGasLimit)
+	}
+	anomaly := &GasAnomaly{
+		Function: funcName,
+		Kind:     GasAnomalyOutOfGas,
+		Input:    input,
+		GasUsed:  gasUsed,
+		Message:  message,
+	}
+	g.outOfGas = append(g.outOfGas, anomaly)
+
+	utils.Log.Emit(anomaly.Event())
+}
+
+// 恢复账本快照后合约状态回到部署时，重新统计状态大小
+func (g *gasTracker) ResetState() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.written = make(map[string]struct{})
+}
+
+func (a *GasAnomaly) Event() *utils.Event {
+	fields := map[string]interface{}{
+		"kind":     a.Kind,
+		"gas_used": a.GasUsed,
+	}
+	if a.Input != nil {
+		fields["input"] = a.Input
+	}
+	if a.Kind != GasAnomalyOutOfGas {
+		fields["correlation"] = a.Correlation
+	}
+	return &utils.Event{
+		Level:     utils.LevelWarn,
+		Phase:     utils.ExecutionLog,
+		Type:      utils.EventGasAnomaly,
+		Message:   a.Message,
+		Functions: []string{a.Function},
+		Fields:    fields,
+	}
+}
+
+// 汇总各函数的 gas 统计与异常
+func (g *gasTracker) Profile() *GasProfile {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	profile := &GasProfile{
+		Limit:     nodecontrol.InvokeGasLimit,
+		Functions: make([]*GasStat, 0, len(g.samples)),
+		Anomalies: append([]*GasAnomaly{}, g.outOfGas...),
+	}
+
+	names := make([]string, 0, len(g.samples))
+	for name := range g.samples {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		samples := g.samples[name]
+		stat := &GasStat{Function: name, Samples: len(samples), Min: math.MaxUint64, MaxInput: g.maxInput[name]}
+		var sum uint64
+		inputs := make([]float64, len(samples))
+		states := make([]float64, len(samples))
+		gas := make([]float64, len(samples))
+		for i, sample := range samples {
+			if sample.GasUsed < stat.Min {
+				stat.Min = sample.GasUsed
+			}
+			if sample.GasUsed > stat.Max {
+				stat.Max = sample.GasUsed
+			}
+			if sample.OutOfGas {
+				stat.OutOfGas++
+			}
+			sum += sample.GasUsed
+			inputs[i], states[i], gas[i] = float64(sample.InputSize), float64(sample.StateSize), float64(sample.GasUsed)
+		}
+		stat.Mean = sum / uint64(len(samples))
+		stat.InputCorrelation = rankCorrelation(inputs, gas)
+		stat.StateCorrelation = rankCorrelation(states, gas)
+		profile.Functions = append(profile.Functions, stat)
+
+		if anomaly := growthAnomaly(stat, inputs, stat.InputCorrelation, GasAnomalyGrowsWithInput); anomaly != nil {
+			profile.Anomalies = append(profile.Anomalies, anomaly)
+		}
+		if anomaly := growthAnomaly(stat, states, stat.StateCorrelation, GasAnomalyGrowsWithState); anomaly != nil {
+			profile.Anomalies = append(profile.Anomalies, anomaly)
+		}
+	}
+
+	return profile
+}
+
+// gas 随输入或状态增长时返回异常
+func growthAnomaly(stat *GasStat, sizes []float64, correlation float64, kind string) *GasAnomaly {
+	if stat.Samples < gasMinSamples || distinctCount(sizes) < gasMinDistinct || correlation < gasMinCorrelation {
+		return nil
+	}
+	if stat.Min == 0 || float64(stat.Max) < float64(stat.Min)*gasMinGrowth {
+		return nil
+	}
+
+	message := fmt.Sprintf("gas grows with input size (%d -> %d)", stat.Min, stat.Max)
+	if kind == GasAnomalyGrowsWithState {
+		message = fmt.Sprintf("gas grows with contract state size (%d -> %d), possibly an unbounded iteration over state", stat.Min, stat.Max)
+	}
+	return &GasAnomaly{
+		Function:    stat.Function,
+		Kind:        kind,
+		Input:       stat.MaxInput,
+		GasUsed:     stat.Max,
+		Correlation: correlation,
+		Message:     message,
+	}
+}
+
+func distinctCount(values []float64) int {
+	seen := make(map[float64]bool)
+	for _, v := range values {
+		seen[v] = true
+	}
+	return len(seen)
+}
+
+// Spearman 秩相关系数，任一序列没有变化时为 0
+func rankCorrelation(x, y []float64) float64 {
+	if len(x) != len(y) || len(x) < 2 {
+		return 0
+	}
+	rx, ry := ranks(x), ranks(y)
+
+	var meanX, meanY float64
+	for i := range rx {
+		meanX += rx[i]
+		meanY += ry[i]
+	}
+	meanX /= float64(len(rx))
+	meanY /= float64(len(ry))
+
+	var cov, varX, varY float64
+	for i := range rx {
+		dx, dy := rx[i]-meanX, ry[i]-meanY
+		cov += dx * dy
+		varX += dx * dx
+		varY += dy * dy
+	}
+	if varX == 0 || varY == 0 {
+		return 0
+	}
+	return cov / math.Sqrt(varX*varY)
+}
+
+// 序列中各值的秩，相同的值取平均秩
+func ranks(values []float64) []float64 {
+	index := make([]int, len(values))
+	for i := range index {
+		index[i] = i
+	}
+	sort.SliceStable(index, func(i, j int) bool { return values[index[i]] < values[index[j]] })
+
+	result := make([]float64, len(values))
+	for i := 0; i < len(index); {
+		j := i
+		for j+1 < len(index) && values[index[j+1]] == values[index[i]] {
+			j++
+		}
+		rank := float64(i+j)/2 + 1
+		for k := i; k <= j; k++ {
+			result[index[k]] = rank
+		}
+		i = j + 1
+	}
+	return result
+}
+
+func (g *gasTracker) Save(filePath string) error {
+	data, err := json.MarshalIndent(g.Profile(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize GasProfile: %v", err)
+	}
+
+	err = os.WriteFile(filePath, data, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write to file: %v", err)
+	}
+
+	return nil
+}
+
+// 将 gas 随输入或状态增长的异常写入事件流，gas 耗尽异常在记录时已写入
+func (g *gasTracker) LogProfile() {
+	for _, anomaly := range g.Profile().Anomalies {
+		if anomaly.Kind != GasAnomalyOutOfGas {
+			utils.Log.Emit(anomaly.Event())
+		}
+	}
+}
+
+func LoadGasProfile(filePath string) (*GasProfile, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	profile := &GasProfile{}
+	if err := json.Unmarshal(data, profile); err != nil {
+		return nil, fmt.Errorf("failed to parse GasProfile: %v", err)
+	}
+	return profile, nil
+}
+
+// 搜索使各函数 gas 消耗最高的输入
+// 1. 以每个函数中 gas 消耗最高的交易种子为起点
+// 2. 随机选取一条参数路径进行变异并执行，gas 增加时保留变异，达到 gas 上限时停止该函数的搜索
+// 3. 搜索结束后，最优输入若产生新的读写集形状，加入该函数的交易种子
+func SearchMaxGas(pool *FuncSeedsPool) {
+	if GasSearchExecs <= 0 {
+		return
+	}
+	Log := utils.Log
+	rng := utils.Rand()
+
+	for _, funcName := range pool.FuncNames() {
+		seeds := pool.Pool[funcName]
+		if len(seeds) == 0 {
+			continue
+		}
+		best := seeds[0]
+		for _, seed := range seeds[1:] {
+			if seed.GasUsed > best.GasUsed {
+				best = seed
+			}
+		}
+		if len(best.ValuePaths) == 0 || best.OutOfGas || Latency.TimeoutProne(funcName) {
+			continue
+		}
+
+		start := best.GasUsed
+		improved := 0
+		for execs := 0; execs < GasSearchExecs && !best.OutOfGas && !Latency.TimeoutProne(funcName); execs++ {
+			copy, err := copystructure.Copy(best.FunctionInput)
+			if err != nil {
+				fmt.Println("Error:", err)
+				break
+			}
+			input := copy.(map[string]interface{})
+			path := best.ValuePaths[rng.Intn(len(best.ValuePaths))]
+			best.modifyField(input, path)
+
+			candidate := &FuncSeed{
+				FunctionName:           funcName,
+				FunctionInput:          input,
+				ValuePaths:             make([]ValuePath, 0),
+				ReadRelatedValuePaths:  make([]ValuePath, 0),
+				WriteRelatedValuePaths: make([]ValuePath, 0),
+			}
+			candidate.getValuePaths(input, []string{}, &candidate.ValuePaths)
+			candidate.getRWSets()
+
+			if candidate.GasUsed > best.GasUsed || candidate.OutOfGas {
+				best = candidate
+				improved++
+			}
+		}
+
+		Log.Emit(&utils.Event{
+			Level:     utils.LevelInfo,
+			Phase:     utils.ExecutionLog,
+			Type:      utils.EventGasSearch,
+			Message:   fmt.Sprintf("gas %d -> %d", start, best.GasUsed),
+			SeedIDs:   []string{best.ID()},
+			Functions: []string{funcName},
+			Fields: map[string]interface{}{
+				"input":      best.FunctionInput,
+				"improved":   improved,
+				"out_of_gas": best.OutOfGas,
+			},
+		})
+
+		if improved == 0 {
+			continue
+		}
+		utils.Decisions.Record("gas_seed", funcName, best.ID())
+		best.getRelatedValuePaths()
+		if pool.Corpus.AddFuncSeed(best) {
+			recordSeedValues(best)
+			pool.Pool[funcName] = append(pool.Pool[funcName], best)
+		}
+	}
+}
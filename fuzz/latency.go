@@ -0,0 +1,268 @@
+/*
+	本文件主要用于：
+
+	1. 对每次同步执行的探测交易（类型确认、读写集获取、相关路径探测、gas 搜索）计时，
+	   并区分执行超时与合约逻辑出错、参数类型不匹配
+
+	2. 维护各函数的耗时统计：样本数、平均耗时、P50/P95、最大耗时、超时次数，
+	   以及最慢的输入与触发超时的输入
+
+	3. 执行次数达到下限且超时比例达到阈值的函数视为易超时函数，后续不再对其进行相关路径探测、gas 搜索、
+	   变异与冲突实验，避免每笔交易都等待至超时
+*/
+
+package fuzz
+
+import (
+	nodecontrol "TransactionRwset/nodeControl"
+	"TransactionRwset/utils"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"chainmaker.org/chainmaker/pb-go/v2/common"
+)
+
+// 结果目录下保存耗时统计的文件名
+const LatencyProfileFileName = "latency_profile.json"
+
+// 超时次数占比达到该值的函数视为易超时函数，0 表示不根据超时调整预算
+var TimeoutProneRate = 0.5
+
+// 判定易超时函数所需的最少执行次数，避免首次执行超时即被排除
+var TimeoutProneMinSamples = 5
+
+// 每个函数最多记录的超时输入数
+const maxTimeoutInputsPerFunc = 5
+
+// 一次同步探测的结果
+type probeResult struct {
+	txId     string
+	message  string
+	code     common.TxStatusCode
+	gasUsed  uint64
+	success  bool
+	err      error
+	duration time.Duration
+	timedOut bool
+	outOfGas bool
+}
+
+// 同步执行一笔探测交易，记录其耗时并判断是否超时或耗尽 gas
+func probe(funcName string, input map[string]interface{}, kvs []*common.KeyValuePair) *probeResult {
+	start := time.Now()
+	txId, message, code, gasUsed, success, err := nodecontrol.ChainmakerController.UserContractInvoke(utils.GlobalContractInfo.ContractName, funcName, kvs, true)
+
+	result := &probeResult{
+		txId:     txId,
+		message:  message,
+		code:     code,
+		gasUsed:  gasUsed,
+		success:  success,
+		err:      err,
+		duration: time.Since(start),
+	}
+	if !success {
+		if err != nil {
+			message += " " + err.Error()
+		}
+		result.outOfGas = nodecontrol.IsOutOfGas(code, gasUsed, message)
+		result.timedOut = !result.outOfGas && nodecontrol.IsTimeout(code, message)
+	}
+
+	Latency.Record(funcName, input, result.duration, result.timedOut, message)
+	return result
+}
+
+type TimeoutInput struct {
+	Input      map[string]interface{} `json:"input"`
+	DurationMs int64                  `json:"duration_ms"`
+	Message    string                 `json:"message,omitempty"`
+}
+
+// 单个函数的耗时统计
+type LatencyStat struct {
+	Function      string                 `json:"function"`
+	Samples       int                    `json:"samples"`
+	MeanMs        int64                  `json:"mean_ms"`
+	P50Ms         int64                  `json:"p50_ms"`
+	P95Ms         int64                  `json:"p95_ms"`
+	MaxMs         int64                  `json:"max_ms"`
+	Timeouts      int                    `json:"timeouts"`
+	TimeoutProne  bool                   `json:"timeout_prone"`
+	SlowestInput  map[string]interface{} `json:"slowest_input,omitempty"`
+	TimeoutInputs []*TimeoutInput        `json:"timeout_inputs,omitempty"`
+}
+
+type LatencyProfile struct {
+	Functions []*LatencyStat `json:"functions"`
+}
+
+type latencyTracker struct {
+	mu       sync.Mutex
+	samples  map[string][]int64
+	timeouts map[string]int
+	slowest  map[string]map[string]interface{}
+	maxMs    map[string]int64
+	inputs   map[string][]*TimeoutInput
+	prone    map[string]bool // 已判定为易超时的函数
+}
+
+// 全局耗时记录
+var Latency = newLatencyTracker()
+
+func newLatencyTracker() *latencyTracker {
+	return &latencyTracker{
+		samples:  make(map[string][]int64),
+		timeouts: make(map[string]int),
+		slowest:  make(map[string]map[string]interface{}),
+		maxMs:    make(map[string]int64),
+		inputs:   make(map[string][]*TimeoutInput),
+		prone:    make(map[string]bool),
+	}
+}
+
+// 记录一次探测的耗时，函数首次被判定为易超时时写入事件流
+func (l *latencyTracker) Record(funcName string, input map[string]interface{}, duration time.Duration, timedOut bool, message string) {
+	ms := duration.Milliseconds()
+
+	l.mu.Lock()
+	if _, ok := l.slowest[funcName]; !ok || ms > l.maxMs[funcName] {
+		l.slowest[funcName] = input
+		l.maxMs[funcName] = ms
+	}
+	l.samples[funcName] = append(l.samples[funcName], ms)
+
+	if timedOut {
+		l.timeouts[funcName]++
+		if len(l.inputs[funcName]) < maxTimeoutInputsPerFunc {
+			l.inputs[funcName] = append(l.inputs[funcName], &TimeoutInput{Input: input, DurationMs: ms, Message: message})
+		}
+	}
+
+	becameProne := false
+	if !l.prone[funcName] && l.isProne(funcName) {
+		l.prone[funcName] = true
+		becameProne = true
+	}
+	timeouts, total := l.timeouts[funcName], len(l.samples[funcName])
+	l.mu.Unlock()
+
+	if timedOut {
+		utils.Log.Emit(&utils.Event{
+			Level:      utils.LevelWarn,
+			Phase:      utils.ExecutionLog,
+			Type:       utils.EventExecTimeout,
+			Message:    message,
+			Functions:  []string{funcName},
+			DurationMs: ms,
+			Fields:     map[string]interface{}{"input": input},
+		})
+	}
+	if becameProne {
+		utils.Log.Warn(utils.ExecutionLog, fmt.Sprintf("%s timed out in %d of %d probes, excluded from probing, mutation and conflict experiments", funcName, timeouts, total))
+	}
+}
+
+func (l *latencyTracker) isProne(funcName string) bool {
+	if TimeoutProneRate <= 0 || l.timeouts[funcName] == 0 || len(l.samples[funcName]) < TimeoutProneMinSamples {
+		return false
+	}
+	return float64(l.timeouts[funcName]) >= TimeoutProneRate*float64(len(l.samples[funcName]))
+}
+
+// TimeoutProne 函数是否为易超时函数
+func (l *latencyTracker) TimeoutProne(funcName string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.prone[funcName]
+}
+
+// 交易对中易超时的函数，没有时返回空串
+func timeoutProneFunc(seed *FuncPairSeed) string {
+	for _, funcSeed := range []*FuncSeed{seed.SeedOne, seed.SeedTwo} {
+		if Latency.TimeoutProne(funcSeed.FunctionName) {
+			return funcSeed.FunctionName
+		}
+	}
+	return ""
+}
+
+// 汇总各函数的耗时统计
+func (l *latencyTracker) Profile() *LatencyProfile {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	names := make([]string, 0, len(l.samples))
+	for name := range l.samples {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	profile := &LatencyProfile{Functions: make([]*LatencyStat, 0, len(names))}
+	for _, name := range names {
+		samples := append([]int64{}, l.samples[name]...)
+		sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+
+		var sum int64
+		for _, ms := range samples {
+			sum += ms
+		}
+		profile.Functions = append(profile.Functions, &LatencyStat{
+			Function:      name,
+			Samples:       len(samples),
+			MeanMs:        sum / int64(len(samples)),
+			P50Ms:         percentile(samples, 0.5),
+			P95Ms:         percentile(samples, 0.95),
+			MaxMs:         samples[len(samples)-1],
+			Timeouts:      l.timeouts[name],
+			TimeoutProne:  l.prone[name],
+			SlowestInput:  l.slowest[name],
+			TimeoutInputs: l.inputs[name],
+		})
+	}
+	return profile
+}
+
+// 已排序序列的分位数（最近秩法）
+func percentile(sorted []int64, p float64) int64 {
+	index := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if index < 0 {
+		index = 0
+	}
+	if index >= len(sorted) {
+		index = len(sorted) - 1
+	}
+	return sorted[index]
+}
+
+func (l *latencyTracker) Save(filePath string) error {
+	data, err := json.MarshalIndent(l.Profile(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize LatencyProfile: %v", err)
+	}
+
+	err = os.WriteFile(filePath, data, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write to file: %v", err)
+	}
+
+	return nil
+}
+
+func LoadLatencyProfile(filePath string) (*LatencyProfile, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	profile := &LatencyProfile{}
+	if err := json.Unmarshal(data, profile); err != nil {
+		return nil, fmt.Errorf("failed to parse LatencyProfile: %v", err)
+	}
+	return profile, nil
+}
@@ -17,12 +17,12 @@
 package fuzz
 
 import (
-	nodecontrol "TransactionRwset/nodeControl"
 	"TransactionRwset/utils"
 	"encoding/json"
 	"fmt"
 	"math"
 	"reflect"
+	"sort"
 
 	"chainmaker.org/chainmaker/pb-go/v2/common"
 	"github.com/google/go-cmp/cmp"
@@ -69,14 +69,17 @@ func calProduct(funcName string) {
 // 计算当前所有函数的匹配积，并取出最小的那个
 // 当product值返回为-1时，代表所有输入的类型都已确定
 func calMinProduct() (string, int) {
-	for funcName, _ := range utils.GlobalContractInfo.ContractFuncMap {
+	funcNames := utils.GlobalContractInfo.SortedFuncNames()
+	for _, funcName := range funcNames {
 		calProduct(funcName)
 	}
 
 	minProduct := math.MaxInt32
 	var minKey string
 
-	for key, val := range utils.GlobalContractInfo.ContractFuncMap {
+	// 按函数名顺序遍历，积相同时取字母序最小者，保证运行可复现
+	for _, key := range funcNames {
+		val := utils.GlobalContractInfo.ContractFuncMap[key]
 		// 只考虑 Product 大于 1 的值
 		if val.Product > 1 && val.Product < minProduct {
 			minProduct = val.Product
@@ -99,6 +102,7 @@ func generateCombinations(paramInputMap map[string][]interface{}) []map[string]i
 	for key := range paramInputMap {
 		keys = append(keys, key)
 	}
+	sort.Strings(keys)
 	// 初始化存储所有组合的切片
 	var combinations []map[string]interface{}
 
@@ -152,7 +156,7 @@ func generateInput(funcName string) []Pair {
 			paramInputMap[paramName] = make([]interface{}, 0)
 
 			// 将每个备用类型都加入
-			for _, value := range candidateTypes.Types {
+			for _, value := range candidateTypes.SortedTypeValues() {
 				// fmt.Println(types, value)
 				paramInputMap[paramName] = append(paramInputMap[paramName], value)
 			}
@@ -288,29 +292,71 @@ func ConfirmAllInputParamType() {
 			break
 		}
 
-		Log.Log(utils.ExecutionLog, fmt.Sprintf("[%d]开始对以下函数进行输入读写集测试:", cnt))
-		Log.Log(utils.ExecutionLog, fmt.Sprintf("[%d]function name  :%s", cnt, funcName))
-		Log.Log(utils.ExecutionLog, fmt.Sprintf("[%d]input list     :\n %v", cnt, inputList))
-		Log.Log(utils.ExecutionLog, fmt.Sprintf("[%d]input list len :[%d]", cnt, len(inputList)))
+		Log.Emit(&utils.Event{
+			Level:     utils.LevelInfo,
+			Phase:     utils.ExecutionLog,
+			Type:      utils.EventParamConfirm,
+			Message:   fmt.Sprintf("[%d]开始对以下函数进行输入读写集测试", cnt),
+			Functions: []string{funcName},
+			Fields:    map[string]interface{}{"round": cnt, "inputs": len(inputList)},
+		})
 
 		flag := false
 
 		for i, input := range inputList {
-			Log.Log(utils.ExecutionLog, fmt.Sprintf("[%d]执行第[%d]轮,     input : %v", cnt, i, input))
-			_, _, _, success, err := nodecontrol.ChainmakerController.UserContractInvoke(utils.GlobalContractInfo.ContractName, funcName, input.KeyValuePair, true)
-			Log.Log(utils.ExecutionLog, fmt.Sprintf("[%d]执行成功与否:[%v], err  : %v", cnt, success, err))
+			result := probe(funcName, input.KeyValue, input.KeyValuePair)
+
+			event := &utils.Event{
+				Level:      utils.LevelDebug,
+				Phase:      utils.ExecutionLog,
+				Type:       utils.EventParamConfirm,
+				Message:    fmt.Sprintf("[%d]执行第[%d]轮", cnt, i),
+				Functions:  []string{funcName},
+				DurationMs: result.duration.Milliseconds(),
+				Fields: map[string]interface{}{
+					"round":    cnt,
+					"input":    input.KeyValue,
+					"success":  result.success,
+					"gas_used": result.gasUsed,
+				},
+			}
+			if result.err != nil {
+				event.Fields["error"] = result.err.Error()
+			}
 
-			if err == nil && success {
-				Log.Log(utils.ExecutionLog, fmt.Sprintf("[%d]confirm success! funcName:[%s]/KeyValue:[%s]", cnt, funcName, input.KeyValuePair))
+			if result.err == nil && result.success {
+				event.Level = utils.LevelInfo
+				event.Message = fmt.Sprintf("[%d]confirm success!", cnt)
+				ConfirmParam(input.KeyValue)
+				flag = true
+			} else if result.outOfGas {
+				// gas 耗尽说明参数已被合约正确解析，不属于类型不匹配，同样确认该组类型并记录为 gas 异常
+				event.Level = utils.LevelWarn
+				event.Message = fmt.Sprintf("[%d]confirm with out of gas!", cnt)
+				event.Fields["out_of_gas"] = true
 				ConfirmParam(input.KeyValue)
+				Gas.RecordOutOfGas(funcName, input.KeyValue, result.gasUsed, result.message)
 				flag = true
+			} else if result.timedOut {
+				// 执行超时同样说明合约已开始执行，不属于类型不匹配
+				event.Level = utils.LevelWarn
+				event.Message = fmt.Sprintf("[%d]confirm with execution timeout!", cnt)
+				event.Fields["timed_out"] = true
+				ConfirmParam(input.KeyValue)
+				flag = true
+			}
+			Log.Emit(event)
+
+			// 易超时函数的每个候选输入都需等待至超时，已确认类型后不再继续探测
+			if flag && Latency.TimeoutProne(funcName) {
+				break
 			}
 		}
 
 		// flag没有发生改变，代表没有找到符合需求的输入
 		// 将对应func下的param全置为string
 		if !flag {
-			Log.Log(utils.ExecutionLog, fmt.Sprintf("[%d]we can't find param type, and we will set all param as string", cnt))
+			Log.Warn(utils.ExecutionLog, fmt.Sprintf("[%d]we can't find param type, and we will set all param as string: %s", cnt, funcName))
 			params := utils.GlobalContractInfo.ContractFuncMap[funcName].ParamsNameList
 			for _, param := range params {
 				utils.GlobalContractInfo.ParamAndCandidateTypes[param].Confirm = true
@@ -0,0 +1,283 @@
+/*
+	本文件主要用于：
+
+	1. 对冲突交易对种子进行最小化，使其保持完全相同的冲突 key 与冲突类型：
+			a. 与读写集无关的参数重置为该类型的默认值
+			b. 与读写集相关的参数尝试更短的字符串、更小的数值
+		每次替换后重新执行两个交易，冲突 key 与冲突类型均不变才保留替换，
+		因此构成冲突 key 的参数取值会被保留
+
+	2. 最小化前在当前链状态下重新执行一次两个交易，冲突可以复现才进行最小化；
+	   交易对种子只包含两笔交易，没有可以删减的前置交易
+*/
+
+package fuzz
+
+import (
+	"TransactionRwset/utils"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/copystructure"
+)
+
+// 结果目录下保存最小化交易对种子的文件名
+const MinimizedPairSeedFileName = "pair_seed_min.json"
+
+// 最小化过程中最多执行的交易数，0 表示不进行最小化
+var MaxMinimizeExecs = 200
+
+// 冲突类型 + 具体的冲突 key，最小化前后须完全一致
+func exactConflicts(seedOne, seedTwo *FuncSeed) []string {
+	conflicts := make([]string, 0)
+	for _, key := range ConflictKeys(seedOne, seedTwo) {
+		conflicts = addUnique(conflicts, conflictKindReadWrite+":"+key)
+	}
+	for _, key := range intersectKeys(seedOne.WriteSet, seedTwo.WriteSet, identity) {
+		conflicts = addUnique(conflicts, conflictKindWriteWrite+":"+key)
+	}
+	return conflicts
+}
+
+type minimizer struct {
+	target []string
+	execs  int
+}
+
+func (m *minimizer) exhausted() bool {
+	return m.execs+2 > MaxMinimizeExecs
+}
+
+// 重新执行两个交易，判断冲突类型与冲突 key 是否保持不变
+func (m *minimizer) check(pair *FuncPairSeed) bool {
+	pair.SeedOne.getRWSets()
+	pair.SeedTwo.getRWSets()
+	m.execs += 2
+
+	return strings.Join(exactConflicts(pair.SeedOne, pair.SeedTwo), "\n") == strings.Join(m.target, "\n")
+}
+
+// MinimizeConflictSeed 返回最小化后的交易对种子，原种子不变
+func MinimizeConflictSeed(pair *FuncPairSeed) (*FuncPairSeed, error) {
+	if MaxMinimizeExecs <= 0 {
+		return nil, fmt.Errorf("minimization disabled")
+	}
+
+	copy, err := copystructure.Copy(pair)
+	if err != nil {
+		return nil, err
+	}
+	minimized := copy.(*FuncPairSeed)
+	minimized.Minimized = nil
+	minimized.Schedule = nil
+
+	m := &minimizer{target: exactConflicts(pair.SeedOne, pair.SeedTwo)}
+	if len(m.target) == 0 {
+		return nil, fmt.Errorf("seed %s has no confirmed conflict key", pair.ID())
+	}
+
+	if !m.check(minimized) {
+		return nil, fmt.Errorf("conflict of seed %s does not reproduce on current state", pair.ID())
+	}
+
+	// 被替换为更简单值的参数路径
+	simplified := make([]string, 0)
+	for _, seed := range []*FuncSeed{minimized.SeedOne, minimized.SeedTwo} {
+		related := make(map[string]bool)
+		for _, path := range append(append([]ValuePath{}, seed.ReadRelatedValuePaths...), seed.WriteRelatedValuePaths...) {
+			related[strings.Join(path, "/")] = true
+		}
+
+		for _, path := range seed.ValuePaths {
+			current, ok := lookupValue(seed.FunctionInput, path)
+			if !ok {
+				continue
+			}
+
+			for _, candidate := range simplerValues(current, related[strings.Join(path, "/")]) {
+				if m.exhausted() {
+					break
+				}
+
+				setValue(seed.FunctionInput, path, candidate)
+				if m.check(minimized) {
+					current = candidate
+					simplified = append(simplified, seed.FunctionName+":"+strings.Join(path, "/"))
+					break
+				}
+				setValue(seed.FunctionInput, path, current)
+			}
+		}
+	}
+
+	// 最后一次检查可能失败，使用最终输入重新获取读写集
+	m.check(minimized)
+	minimized.MaxSimilarity = calculateMaxSimilarity(minimized.SeedOne, minimized.SeedTwo)
+
+	event := minimized.Event(utils.ConflictLog, utils.EventSeedMinimized, "conflict seed minimized")
+	event.Fields["original_id"] = pair.ID()
+	event.Fields["executions"] = m.execs
+	event.Fields["conflict_keys"] = m.target
+	event.Fields["simplified_paths"] = simplified
+	utils.Log.Emit(event)
+
+	return minimized, nil
+}
+
+// 比当前值更简单的候选值，按从简单到复杂排列
+// 与读写集无关的参数只尝试默认值
+func simplerValues(value interface{}, related bool) []interface{} {
+	// JSON 中的 null 没有类型，无法构造默认值
+	if value == nil {
+		return nil
+	}
+	zero := reflect.Zero(reflect.TypeOf(value)).Interface()
+	if !related {
+		if reflect.DeepEqual(value, zero) {
+			return nil
+		}
+		return []interface{}{zero}
+	}
+
+	candidates := make([]interface{}, 0)
+	add := func(candidate interface{}) {
+		if reflect.DeepEqual(candidate, value) {
+			return
+		}
+		for _, c := range candidates {
+			if reflect.DeepEqual(c, candidate) {
+				return
+			}
+		}
+		candidates = append(candidates, candidate)
+	}
+
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.String:
+		runes := []rune(v.String())
+		add("")
+		add("a")
+		for length := 1; length < len(runes); length *= 2 {
+			add(string(runes[:length]))
+		}
+		if len(runes) > 0 {
+			add(string(runes[:len(runes)-1]))
+		}
+		add(strings.ToLower(v.String()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		x := v.Int()
+		for _, c := range []int64{0, 1, x / 2, x - sign(x)} {
+			if abs(c) < abs(x) {
+				add(reflect.ValueOf(c).Convert(v.Type()).Interface())
+			}
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		x := v.Uint()
+		for _, c := range []uint64{0, 1, x / 2} {
+			if c < x {
+				add(reflect.ValueOf(c).Convert(v.Type()).Interface())
+			}
+		}
+	case reflect.Float32, reflect.Float64:
+		x := v.Float()
+		for _, c := range []float64{0, 1, float64(int64(x)), x / 2} {
+			if c*c < x*x {
+				add(reflect.ValueOf(c).Convert(v.Type()).Interface())
+			}
+		}
+	case reflect.Bool:
+		add(false)
+	case reflect.Slice:
+		if bytes, ok := value.([]byte); ok {
+			add([]byte{})
+			add(bytes[:len(bytes)/2])
+		}
+	}
+
+	// 更短、更小的值排在前面
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return len(fmt.Sprint(candidates[i])) < len(fmt.Sprint(candidates[j]))
+	})
+	return candidates
+}
+
+func sign(x int64) int64 {
+	switch {
+	case x > 0:
+		return 1
+	case x < 0:
+		return -1
+	}
+	return 0
+}
+
+func abs(x int64) int64 {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// 根据路径设置叶子节点的值
+func setValue(data interface{}, path ValuePath, value interface{}) bool {
+	if len(path) == 0 {
+		return false
+	}
+
+	switch v := data.(type) {
+	case map[string]interface{}:
+		if len(path) == 1 {
+			v[path[0]] = value
+			return true
+		}
+		next, ok := v[path[0]]
+		if !ok {
+			return false
+		}
+		return setValue(next, path[1:], value)
+	case []interface{}:
+		var index int
+		if _, err := fmt.Sscanf(path[0], "[%d]", &index); err != nil || index < 0 || index >= len(v) {
+			return false
+		}
+		if len(path) == 1 {
+			v[index] = value
+			return true
+		}
+		return setValue(v[index], path[1:], value)
+	}
+	return false
+}
+
+// 对冲突种子进行最小化，并将结果保存至实验目录
+func minimizeAndSave(seed *FuncPairSeed, targetDir string) {
+	if MaxMinimizeExecs <= 0 || seed.Minimized != nil {
+		return
+	}
+
+	start := time.Now()
+	minimized, err := MinimizeConflictSeed(seed)
+	if err != nil {
+		utils.Log.Warn(utils.ConflictLog, fmt.Sprintf("minimize seed %s failed: %v", seed.ID(), err))
+		return
+	}
+	seed.Minimized = minimized
+	utils.Log.Log(utils.ConflictLog, fmt.Sprintf("minimize seed %s -> %s in %v", seed.ID(), minimized.ID(), time.Since(start)))
+
+	if targetDir == "" {
+		return
+	}
+
+	// 原始种子与最小化种子存放在一起
+	if err := seed.SaveToFile(filepath.Join(targetDir, PairSeedFileName)); err != nil {
+		fmt.Println("保存交易对种子失败!", err)
+	}
+	if err := minimized.SaveToFile(filepath.Join(targetDir, MinimizedPairSeedFileName)); err != nil {
+		fmt.Println("保存最小化交易对种子失败!", err)
+	}
+}
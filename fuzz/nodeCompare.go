@@ -0,0 +1,361 @@
+/*
+	本文件主要用于：
+
+	1. 按节点分发实验交易时，统计各节点接收交易的结果：发送数、被拒绝数、丢失数、
+	   各类结果数量及拒绝率，用于比较各节点在相同负载下的表现
+
+	2. 逐个节点查询每一笔交易是否上链、执行结果与所在区块高度，各节点看到的结果不一致时记为分歧；
+	   查询前等待各节点同步到默认客户端的区块高度，仍未同步的高度上的交易不参与比较
+
+	3. 比较实验期间各节点上每个区块的哈希与交易数，找出区块内容不一致的高度
+*/
+
+package fuzz
+
+import (
+	nodecontrol "TransactionRwset/nodeControl"
+	"TransactionRwset/utils"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 冲突实验目录下保存各节点比较结果的文件名
+const NodeComparisonFileName = "nodes.json"
+
+// 每组交易最多保留的分歧交易示例数
+const maxTxDivergenceExamples = 50
+
+// 比较前等待落后节点同步区块的最长时间
+const nodeSyncTimeout = 30 * time.Second
+
+// 单个节点上查询到的交易状态
+type NodeTxView struct {
+	Node        string `json:"node"`
+	OnChain     bool   `json:"on_chain"`
+	InPool      bool   `json:"in_pool"`
+	Code        string `json:"code,omitempty"`
+	BlockHeight uint64 `json:"block_height,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+func (v *NodeTxView) key() string {
+	return fmt.Sprintf("%v|%s|%d", v.OnChain, v.Code, v.BlockHeight)
+}
+
+// 各节点查询结果不一致的交易
+type TxDivergence struct {
+	TxId   string        `json:"tx_id"`
+	SentTo string        `json:"sent_to,omitempty"`
+	Views  []*NodeTxView `json:"views"`
+}
+
+// 各节点区块内容不一致的高度
+type BlockDivergence struct {
+	Height   uint64            `json:"height"`
+	Hashes   map[string]string `json:"hashes"`
+	TxCounts map[string]int    `json:"tx_counts"`
+}
+
+// 单个节点接收交易的统计
+type NodeStat struct {
+	Node          string            `json:"node"`
+	Weight        int               `json:"weight"`
+	Sent          int               `json:"sent"`
+	Rejected      int               `json:"rejected"`
+	Lost          int               `json:"lost"`
+	RejectionRate float64           `json:"rejection_rate"`
+	Counts        map[TxOutcome]int `json:"counts"`
+}
+
+// 一组交易（某个比例）的各节点比较结果
+type NodeComparison struct {
+	Label          string             `json:"label"`
+	Nodes          []*NodeStat        `json:"nodes"`
+	DivergentTxs   int                `json:"divergent_txs"`
+	TxDivergences  []*TxDivergence    `json:"tx_divergences,omitempty"`
+	FromHeight     uint64             `json:"from_height"`
+	ToHeight       uint64             `json:"to_height"`
+	BlockDivergent []*BlockDivergence `json:"block_divergences,omitempty"`
+}
+
+// 一次冲突实验中各组交易的比较结果
+type NodeComparisons struct {
+	Comparisons []*NodeComparison `json:"comparisons"`
+}
+
+// 按节点分发时的起始区块高度，未按节点分发时返回 0
+func NodeCompareStart() uint64 {
+	if len(nodecontrol.ChainmakerController.NodeClients()) == 0 {
+		return 0
+	}
+	height, err := nodecontrol.ChainmakerController.Client.GetCurrentBlockHeight()
+	if err != nil {
+		fmt.Println("查询区块高度失败：", err)
+	}
+	return height
+}
+
+// CompareNodes 统计各节点接收交易的结果，并逐个节点查询交易与区块，未按节点分发时返回 nil
+// 调用前交易需已分类
+func CompareNodes(label string, txs []*Tx, fromHeight uint64) *NodeComparison {
+	clients := nodecontrol.ChainmakerController.NodeClients()
+	if len(clients) == 0 {
+		return nil
+	}
+
+	comparison := &NodeComparison{Label: label, FromHeight: fromHeight}
+	stats := make(map[string]*NodeStat)
+	for _, client := range clients {
+		stat := &NodeStat{Node: client.Name, Weight: client.Weight, Counts: make(map[TxOutcome]int)}
+		stats[client.Name] = stat
+		comparison.Nodes = append(comparison.Nodes, stat)
+	}
+	for _, tx := range txs {
+		stat, ok := stats[tx.Node]
+		if !ok {
+			continue
+		}
+		stat.Sent++
+		stat.Counts[tx.Outcome]++
+		if tx.Outcome.IsRejected() {
+			stat.Rejected++
+		}
+		if tx.Outcome.IsLost() {
+			stat.Lost++
+		}
+	}
+	for _, stat := range comparison.Nodes {
+		if stat.Sent > 0 {
+			stat.RejectionRate = float64(stat.Rejected) / float64(stat.Sent)
+		}
+	}
+
+	// 交易结果由默认客户端查询，其当前高度覆盖了所有已上链的交易
+	syncedHeight := waitNodesSynced(clients)
+	compareTxViews(comparison, clients, txs, syncedHeight)
+	compareBlocks(comparison, clients)
+
+	utils.Log.Emit(&utils.Event{
+		Level:   nodeComparisonLevel(comparison),
+		Phase:   utils.ConflictLog,
+		Type:    utils.EventNodeCompare,
+		Message: fmt.Sprintf("%s: %d divergent txs, %d divergent blocks", label, comparison.DivergentTxs, len(comparison.BlockDivergent)),
+		Fields: map[string]interface{}{
+			"label": label,
+			"nodes": comparison.Nodes,
+		},
+	})
+	return comparison
+}
+
+func nodeComparisonLevel(comparison *NodeComparison) utils.LogLevel {
+	if comparison.DivergentTxs > 0 || len(comparison.BlockDivergent) > 0 {
+		return utils.LevelWarn
+	}
+	return utils.LevelInfo
+}
+
+// 所有节点都已提交的最低区块高度
+func minNodeHeight(clients []*nodecontrol.NodeClient) (uint64, error) {
+	minHeight := uint64(0)
+	for i, client := range clients {
+		height, err := client.Client.GetCurrentBlockHeight()
+		if err != nil {
+			return 0, fmt.Errorf("查询 %s 区块高度失败：%v", client.Name, err)
+		}
+		if i == 0 || height < minHeight {
+			minHeight = height
+		}
+	}
+	return minHeight, nil
+}
+
+// 等待各节点同步到默认客户端的当前区块高度，返回所有节点都已提交的最低高度
+func waitNodesSynced(clients []*nodecontrol.NodeClient) uint64 {
+	target, err := nodecontrol.ChainmakerController.Client.GetCurrentBlockHeight()
+	if err != nil {
+		fmt.Println("查询区块高度失败：", err)
+	}
+
+	deadline := time.Now().Add(nodeSyncTimeout)
+	for {
+		minHeight, err := minNodeHeight(clients)
+		if err != nil {
+			fmt.Println(err)
+		} else if minHeight >= target || time.Now().After(deadline) {
+			return minHeight
+		}
+		if time.Now().After(deadline) {
+			return 0
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+}
+
+// 逐个节点查询每笔被接收的交易，比较各节点的结果
+// 落后节点尚未提交的高度上的交易（某个节点看到的区块高度超过 syncedHeight）不计为分歧
+func compareTxViews(comparison *NodeComparison, clients []*nodecontrol.NodeClient, txs []*Tx, syncedHeight uint64) {
+	type task struct {
+		index int
+		tx    *Tx
+	}
+	views := make([][]*NodeTxView, len(txs))
+	taskCh := make(chan task)
+	var wg sync.WaitGroup
+
+	for i := 0; i < maxWorkers; i++ {
+		go func() {
+			defer utils.RecoverPanic()
+			for t := range taskCh {
+				row := make([]*NodeTxView, 0, len(clients))
+				for _, client := range clients {
+					row = append(row, queryNodeTx(client, t.tx.TxId))
+				}
+				views[t.index] = row
+				wg.Done()
+			}
+		}()
+	}
+	for i, tx := range txs {
+		// 在 RPC 阶段即被拒绝的交易不会出现在任何节点上
+		if tx.TxId == "" || tx.Outcome.IsRejected() {
+			continue
+		}
+		wg.Add(1)
+		taskCh <- task{index: i, tx: tx}
+	}
+	close(taskCh)
+	wg.Wait()
+
+	for i, row := range views {
+		if len(row) == 0 || !viewsSynced(row, syncedHeight) {
+			continue
+		}
+		divergent := false
+		for _, view := range row[1:] {
+			if view.key() != row[0].key() {
+				divergent = true
+				break
+			}
+		}
+		if !divergent {
+			continue
+		}
+		comparison.DivergentTxs++
+		if len(comparison.TxDivergences) < maxTxDivergenceExamples {
+			comparison.TxDivergences = append(comparison.TxDivergences, &TxDivergence{TxId: txs[i].TxId, SentTo: txs[i].Node, Views: row})
+		}
+	}
+}
+
+// 交易所在区块是否已由所有节点提交
+func viewsSynced(row []*NodeTxView, syncedHeight uint64) bool {
+	for _, view := range row {
+		if view.OnChain && view.BlockHeight > syncedHeight {
+			return false
+		}
+	}
+	return true
+}
+
+func queryNodeTx(client *nodecontrol.NodeClient, txId string) *NodeTxView {
+	view := &NodeTxView{Node: client.Name}
+	txInfo, err := client.Client.GetTxByTxId(txId)
+	if txInfo != nil {
+		view.OnChain = true
+		view.Code = txInfo.Transaction.Result.Code.String()
+		view.BlockHeight = txInfo.BlockHeight
+		return view
+	}
+
+	txInPool, _, _ := client.Client.GetTxsInPoolByTxIds([]string{txId})
+	view.InPool = len(txInPool) > 0
+	if err != nil && !view.InPool && !strings.Contains(strings.ToLower(err.Error()), "not found") {
+		view.Error = err.Error()
+	}
+	return view
+}
+
+// 比较实验期间各节点上每个区块的哈希与交易数
+func compareBlocks(comparison *NodeComparison, clients []*nodecontrol.NodeClient) {
+	// 只比较所有节点都已提交的高度
+	toHeight, err := minNodeHeight(clients)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	comparison.ToHeight = toHeight
+
+	for height := comparison.FromHeight + 1; height <= toHeight; height++ {
+		divergence := &BlockDivergence{Height: height, Hashes: make(map[string]string), TxCounts: make(map[string]int)}
+		hashes := make(map[string]bool)
+		for _, client := range clients {
+			block, err := client.Client.GetBlockByHeight(height, false)
+			if err != nil {
+				divergence.Hashes[client.Name] = "error: " + err.Error()
+			} else {
+				divergence.Hashes[client.Name] = hex.EncodeToString(block.Block.Header.BlockHash)
+				divergence.TxCounts[client.Name] = int(block.Block.Header.TxCount)
+			}
+			hashes[divergence.Hashes[client.Name]] = true
+		}
+		if len(hashes) > 1 {
+			comparison.BlockDivergent = append(comparison.BlockDivergent, divergence)
+		}
+	}
+}
+
+func (c *NodeComparisons) Add(comparison *NodeComparison) {
+	if comparison != nil {
+		c.Comparisons = append(c.Comparisons, comparison)
+	}
+}
+
+func (c *NodeComparisons) SaveToFile(filePath string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize NodeComparisons: %v", err)
+	}
+
+	err = os.WriteFile(filePath, data, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write to file: %v", err)
+	}
+
+	return nil
+}
+
+func LoadNodeComparisonsFromFile(filePath string) (*NodeComparisons, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	comparisons := &NodeComparisons{}
+	if err := json.Unmarshal(data, comparisons); err != nil {
+		return nil, fmt.Errorf("failed to parse NodeComparisons: %v", err)
+	}
+	return comparisons, nil
+}
+
+// 以文本形式输出各节点的统计
+func (c *NodeComparisons) String() string {
+	var sb strings.Builder
+	for _, comparison := range c.Comparisons {
+		sb.WriteString(fmt.Sprintf("%s: %d divergent txs, %d divergent blocks (height %d-%d)\n",
+			comparison.Label, comparison.DivergentTxs, len(comparison.BlockDivergent), comparison.FromHeight+1, comparison.ToHeight))
+		nodes := append([]*NodeStat{}, comparison.Nodes...)
+		sort.Slice(nodes, func(i, j int) bool { return nodes[i].Node < nodes[j].Node })
+		for _, stat := range nodes {
+			sb.WriteString(fmt.Sprintf("  %-8s weight %-3d sent %-6d rejected %-6d lost %-6d rejection rate %.2f%%\n",
+				stat.Node, stat.Weight, stat.Sent, stat.Rejected, stat.Lost, stat.RejectionRate*100))
+		}
+	}
+	return sb.String()
+}
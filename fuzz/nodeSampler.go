@@ -0,0 +1,255 @@
+/*
+	本文件主要用于：
+
+	在实验进行期间，后台按固定间隔采集节点状态，得到时间序列：
+			a. 交易池中 pending / queue 交易数量
+			b. 当前区块高度
+			c. 每个新区块的出块间隔与交易数量
+
+	采集结果与交易 JSON 一同保存，并与交易发送速率绘制在同一张图中，
+	用于直接观察交易池溢出以及积压交易的消化过程
+*/
+
+package fuzz
+
+import (
+	nodecontrol "TransactionRwset/nodeControl"
+	"TransactionRwset/utils"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/plotutil"
+	"gonum.org/v1/plot/vg"
+)
+
+// 节点状态采集间隔，可通过命令行参数修改，不大于 0 时不采集
+var SampleInterval = 200 * time.Millisecond
+
+// 某一时刻的交易池与区块高度
+type PoolSample struct {
+	TimeStamp   int64  `json:"timestamp"` // 采集时间 (Unix 毫秒)
+	PendingTxs  int64  `json:"pending_txs"`
+	QueueTxs    int64  `json:"queue_txs"`
+	BlockHeight uint64 `json:"block_height"`
+}
+
+// 实验期间产生的一个新区块
+type BlockSample struct {
+	Height     uint64 `json:"height"`
+	TimeStamp  int64  `json:"timestamp"`   // 区块时间戳 (Unix 秒)
+	ObservedAt int64  `json:"observed_at"` // 采集到该区块的时间 (Unix 毫秒)
+	Interval   int64  `json:"interval"`    // 与上一区块的出块间隔 (秒)
+	TxCount    uint32 `json:"tx_count"`
+}
+
+type NodeSampler struct {
+	Interval     time.Duration  `json:"-"`
+	IntervalMs   int64          `json:"interval_ms"`
+	PoolSamples  []*PoolSample  `json:"pool_samples"`
+	BlockSamples []*BlockSample `json:"block_samples"`
+
+	mu         sync.Mutex
+	stopCh     chan struct{}
+	doneCh     chan struct{}
+	lastHeight uint64
+	lastBlock  int64
+}
+
+func NewNodeSampler(interval time.Duration) *NodeSampler {
+	return &NodeSampler{
+		Interval:     interval,
+		IntervalMs:   interval.Milliseconds(),
+		PoolSamples:  make([]*PoolSample, 0),
+		BlockSamples: make([]*BlockSample, 0),
+	}
+}
+
+// Enabled 采集间隔大于 0 时才进行采集
+func (s *NodeSampler) Enabled() bool {
+	return s.Interval > 0
+}
+
+// 启动后台采集，未启用时 Stop 可直接返回
+func (s *NodeSampler) Start() {
+	s.stopCh = make(chan struct{})
+	s.doneCh = make(chan struct{})
+	if !s.Enabled() {
+		close(s.doneCh)
+		return
+	}
+
+	// 以当前区块作为起点，只记录实验期间产生的新区块
+	height, err := nodecontrol.ChainmakerController.Client.GetCurrentBlockHeight()
+	if err == nil {
+		s.lastHeight = height
+		if block, err := nodecontrol.ChainmakerController.Client.GetBlockByHeight(height, false); err == nil {
+			s.lastBlock = block.Block.Header.BlockTimestamp
+		}
+	}
+
+	go func() {
+		defer utils.RecoverPanic()
+		defer close(s.doneCh)
+
+		ticker := time.NewTicker(s.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.stopCh:
+				return
+			case <-ticker.C:
+				s.sample()
+			}
+		}
+	}()
+}
+
+// 停止采集，并等待后台 goroutine 退出
+func (s *NodeSampler) Stop() {
+	close(s.stopCh)
+	<-s.doneCh
+}
+
+func (s *NodeSampler) sample() {
+	client := nodecontrol.ChainmakerController.Client
+	now := time.Now().UnixMilli()
+
+	sample := &PoolSample{TimeStamp: now}
+
+	status, err := client.GetPoolStatus()
+	if err != nil {
+		fmt.Println("查询交易池失败：", err)
+	} else {
+		sample.PendingTxs = int64(status.CommonTxNumInPending)
+		sample.QueueTxs = int64(status.CommonTxNumInQueue)
+	}
+
+	height, err := client.GetCurrentBlockHeight()
+	if err != nil {
+		fmt.Println("查询区块高度失败：", err)
+	} else {
+		sample.BlockHeight = height
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.PoolSamples = append(s.PoolSamples, sample)
+
+	for h := s.lastHeight + 1; h <= height; h++ {
+		block, err := client.GetBlockByHeight(h, false)
+		if err != nil {
+			fmt.Println("查询区块失败：", h, err)
+			break
+		}
+
+		header := block.Block.Header
+		blockSample := &BlockSample{
+			Height:     h,
+			TimeStamp:  header.BlockTimestamp,
+			ObservedAt: now,
+			TxCount:    header.TxCount,
+		}
+		if s.lastBlock != 0 {
+			blockSample.Interval = header.BlockTimestamp - s.lastBlock
+		}
+
+		s.BlockSamples = append(s.BlockSamples, blockSample)
+		s.lastHeight = h
+		s.lastBlock = header.BlockTimestamp
+	}
+}
+
+// 将采集结果保存为 JSON 文件
+func (s *NodeSampler) SaveToFile(filePath string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化节点状态失败: %w", err)
+	}
+
+	if err := os.WriteFile(filePath, data, 0644); err != nil {
+		return fmt.Errorf("写入节点状态失败: %w", err)
+	}
+
+	return nil
+}
+
+// 将交易池大小、每区块交易数与交易发送速率绘制在同一张图中
+func (s *NodeSampler) Plot(txs []*Tx, savePath string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.PoolSamples) == 0 {
+		return fmt.Errorf("no node samples to plot")
+	}
+
+	if err := loadPlotFont(); err != nil {
+		return err
+	}
+
+	// 以第一次采集的时间为零点
+	start := s.PoolSamples[0].TimeStamp
+
+	var pending, queue plotter.XYs
+	for _, sample := range s.PoolSamples {
+		x := float64(sample.TimeStamp-start) / 1000
+		pending = append(pending, plotter.XY{X: x, Y: float64(sample.PendingTxs)})
+		queue = append(queue, plotter.XY{X: x, Y: float64(sample.QueueTxs)})
+	}
+
+	var blockTxs plotter.XYs
+	for _, block := range s.BlockSamples {
+		blockTxs = append(blockTxs, plotter.XY{X: float64(block.ObservedAt-start) / 1000, Y: float64(block.TxCount)})
+	}
+
+	// 计算每秒钟发送的交易数
+	sendPerSecond := make(map[int64]int)
+	var minTime, maxTime int64
+	for _, tx := range txs {
+		if minTime == 0 || tx.TimeStamp < minTime {
+			minTime = tx.TimeStamp
+		}
+		if tx.TimeStamp > maxTime {
+			maxTime = tx.TimeStamp
+		}
+		sendPerSecond[tx.TimeStamp]++
+	}
+
+	var sendRate plotter.XYs
+	for t := minTime; minTime != 0 && t <= maxTime; t++ {
+		sendRate = append(sendRate, plotter.XY{X: float64(t*1000-start) / 1000, Y: float64(sendPerSecond[t])})
+	}
+
+	p := plot.New()
+
+	p.Title.Text = "交易池与出块情况"
+	p.Title.Padding = vg.Points(20)
+	p.X.Label.Text = "时间（秒）"
+	p.Y.Label.Text = "交易数"
+	p.X.Padding = vg.Points(20)
+	p.Y.Padding = vg.Points(20)
+
+	lines := []interface{}{"pending", pending, "queue", queue}
+	if len(blockTxs) > 0 {
+		lines = append(lines, "每区块交易数", blockTxs)
+	}
+	if len(sendRate) > 0 {
+		lines = append(lines, "发送速率（笔/秒）", sendRate)
+	}
+
+	if err := plotutil.AddLines(p, lines...); err != nil {
+		return err
+	}
+	p.Legend.Top = true
+
+	return p.Save(10*vg.Inch, 6*vg.Inch, savePath)
+}
@@ -25,11 +25,13 @@ import (
 	nodecontrol "TransactionRwset/nodeControl"
 	"TransactionRwset/utils"
 	"container/list"
+	"crypto/sha1"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"math/rand"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -64,6 +66,9 @@ type FuncPairSeed struct {
 	SeedTwo       *FuncSeed `json:"seed_two"`
 	MaxSimilarity float64   `json:"max_similarity"`
 	Mutability    bool      `json:"mutability"`
+
+	Schedule  *SeedSchedule `json:"schedule,omitempty"`  // 变异调度状态
+	Minimized *FuncPairSeed `json:"minimized,omitempty"` // 最小化后的冲突种子
 }
 
 func (f *FuncPairSeed) String() string {
@@ -80,11 +85,74 @@ func (f *FuncPairSeed) String() string {
 	)
 }
 
+// 交易对种子的标识，由两个交易种子的标识拼接而成
+func (f *FuncPairSeed) ID() string {
+	return f.SeedOne.ID() + "-" + f.SeedTwo.ID()
+}
+
+// 构造描述该交易对种子的日志事件
+func (f *FuncPairSeed) Event(phase utils.LogType, eventType string, message string) *utils.Event {
+	return &utils.Event{
+		Level:      utils.LevelInfo,
+		Phase:      phase,
+		Type:       eventType,
+		Message:    message,
+		SeedIDs:    []string{f.SeedOne.ID(), f.SeedTwo.ID()},
+		Functions:  []string{f.SeedOne.FunctionName, f.SeedTwo.FunctionName},
+		Similarity: utils.Similarity(f.MaxSimilarity),
+		Fields: map[string]interface{}{
+			"pair_id":    f.ID(),
+			"mutability": f.Mutability,
+			"seed_one":   f.SeedOne.rwSetFields(),
+			"seed_two":   f.SeedTwo.rwSetFields(),
+		},
+	}
+}
+
+// 冲突实验目录下保存交易对种子的文件名
+const PairSeedFileName = "pair_seed.json"
+
+// 将单个交易对种子保存至文件
+func (f *FuncPairSeed) SaveToFile(filePath string) error {
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize FuncPairSeed: %v", err)
+	}
+
+	err = os.WriteFile(filePath, data, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write to file: %v", err)
+	}
+
+	return nil
+}
+
+// 从文件读取单个交易对种子
+func LoadPairSeedFromFile(filePath string) (*FuncPairSeed, error) {
+	var seed *FuncPairSeed
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %v", err)
+	}
+
+	err = json.Unmarshal(data, &seed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to deserialize FuncPairSeed: %v", err)
+	}
+
+	return seed, nil
+}
+
 type FuncPairSeedsPool struct {
 	ConflictSeeds *list.List      `json:"-"` // 不直接序列化，使用辅助字段
 	MutateSeeds   *list.List      `json:"-"`
+	RetiredSeeds  *list.List      `json:"-"`              // 因平台期、预算等原因退出变异的种子
 	ConflictList  []*FuncPairSeed `json:"conflict_seeds"` // 用于序列化
 	MutateList    []*FuncPairSeed `json:"mutate_seeds"`
+	RetiredList   []*FuncPairSeed `json:"retired_seeds,omitempty"`
+	ConflictMap   *ConflictMap    `json:"conflict_map,omitempty"` // 函数对冲突情况汇总
+	Corpus        *Corpus         `json:"corpus,omitempty"`       // 读写集形状语料
 }
 
 func (f *FuncPairSeedsPool) PrintFuncPairSeedsPool() {
@@ -92,35 +160,49 @@ func (f *FuncPairSeedsPool) PrintFuncPairSeedsPool() {
 	conflictList := f.ConflictSeeds
 	testList := f.MutateSeeds
 
-	Log.Log(utils.ConflictLog, fmt.Sprintf("当前冲突交易对: [%d], 可变异交易对: [%d]", conflictList.Len(), testList.Len()))
-
-	Log.Log(utils.ConflictLog, "------------------conflictList------------------")
+	Log.Emit(&utils.Event{
+		Level:   utils.LevelInfo,
+		Phase:   utils.ConflictLog,
+		Type:    utils.EventPoolSummary,
+		Message: fmt.Sprintf("当前冲突交易对: [%d], 可变异交易对: [%d]", conflictList.Len(), testList.Len()),
+		Fields: map[string]interface{}{
+			"conflict_seeds": conflictList.Len(),
+			"mutate_seeds":   testList.Len(),
+		},
+	})
+
+	// 种子明细数量较多，仅在 debug 等级下输出
 	for e := conflictList.Front(); e != nil; e = e.Next() {
-		Log.Log(utils.ConflictLog, fmt.Sprint(e.Value.(*FuncPairSeed)))
+		event := e.Value.(*FuncPairSeed).Event(utils.ConflictLog, utils.EventPairSeed, "conflictList")
+		event.Level = utils.LevelDebug
+		Log.Emit(event)
 	}
 
-	Log.Log(utils.ConflictLog, "-------------------mutateList-------------------")
-
 	for e := testList.Front(); e != nil; e = e.Next() {
-		Log.Log(utils.ConflictLog, fmt.Sprint(e.Value.(*FuncPairSeed)))
+		event := e.Value.(*FuncPairSeed).Event(utils.ConflictLog, utils.EventPairSeed, "mutateList")
+		event.Level = utils.LevelDebug
+		Log.Emit(event)
 	}
-
 }
 
 // 保存到文件
 func (pool *FuncPairSeedsPool) SaveToFile(baseDir string) error {
 	timestamp := time.Now().Format("20060102_150405")
+	filePath := filepath.Join(baseDir, fmt.Sprintf("func_pair_seeds_pool_%s_%s.json", utils.GlobalContractInfo.ContractName, timestamp))
+	return pool.writeFile(filePath)
+}
 
+func (pool *FuncPairSeedsPool) writeFile(filePath string) error {
 	// 将 *list.List 转换为切片
 	pool.ConflictList = listToSlice(pool.ConflictSeeds)
 	pool.MutateList = listToSlice(pool.MutateSeeds)
+	pool.RetiredList = listToSlice(pool.RetiredSeeds)
 
 	// 序列化为 JSON
 	data, err := json.MarshalIndent(pool, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to serialize FuncPairSeedsPool: %v", err)
 	}
-	filePath := filepath.Join(baseDir, fmt.Sprintf("func_pair_seeds_pool_%s_%s.json", utils.GlobalContractInfo.ContractName, timestamp))
 
 	// 写入文件
 	err = os.WriteFile(filePath, data, 0644)
@@ -148,33 +230,80 @@ func LoadPairSeedPoolFromFile(filename string) (*FuncPairSeedsPool, error) {
 	// 将切片还原为 *list.List
 	pool.ConflictSeeds = sliceToList(pool.ConflictList)
 	pool.MutateSeeds = sliceToList(pool.MutateList)
+	pool.RetiredSeeds = sliceToList(pool.RetiredList)
+
+	if pool.Corpus == nil {
+		pool.Corpus = NewCorpus()
+	}
+	pool.Corpus.attach(CorpusConflict, pool.ConflictSeeds)
+	pool.Corpus.attach(CorpusMutate, pool.MutateSeeds)
 
 	return pool, nil
 }
 
-func (f *FuncPairSeedsPool) ConflictTxsFirstSeedInPool() {
+// 已保存账本快照时，实验与最小化前都会将链恢复至快照，恢复失败时返回错误且种子保留在池中
+func (f *FuncPairSeedsPool) ConflictTxsFirstSeedInPool() error {
 	Log := utils.Log
 	e := f.ConflictSeeds.Front()
 
 	if e == nil {
 		Log.Log(utils.ConflictLog, "we don't have seed to generate conflict txs!")
-		return
+		return nil
 	}
 
 	seed := e.Value.(*FuncPairSeed)
-	Log.Log(utils.ConflictLog, fmt.Sprintf("we will start use this seed:%s", seed))
 
-	ConflictPairSeedExperiment(seed)
+	// 易超时函数的每笔交易都会执行至超时，不进行大批量的冲突实验
+	if funcName := timeoutProneFunc(seed); funcName != "" {
+		f.retireConflictSeed(e, RetiredTimeout, utils.LevelWarn, funcName+" is timeout-prone")
+		return nil
+	}
 
+	// 分布式模式下读写集形状相同的交易对只由一个 worker 实验
+	if !Sync.Claim(seed) {
+		f.retireConflictSeed(e, RetiredClaimed, utils.LevelInfo, "claimed by another worker")
+		return nil
+	}
+
+	utils.Decisions.Record("schedule_conflict", seed.ID(), "run")
+	Log.Emit(seed.Event(utils.ConflictLog, utils.EventExperiment, "we will start use this seed"))
+
+	if err := restoreLedger(); err != nil {
+		return err
+	}
+	targetDir := ConflictPairSeedExperiment(seed)
+
+	// 实验结束后再进行最小化，避免最小化执行的交易影响实验
+	if MaxMinimizeExecs > 0 && seed.Minimized == nil {
+		if err := restoreLedger(); err != nil {
+			return err
+		}
+		minimizeAndSave(seed, targetDir)
+	}
+
+	f.corpus().detach(e)
 	f.ConflictSeeds.Remove(e)
+	return nil
+}
+
+// 将链恢复至部署合约后的账本快照，未保存快照时不做处理
+func restoreLedger() error {
+	if !nodecontrol.ChainmakerController.HasLedgerSnapshot() {
+		return nil
+	}
+	if err := nodecontrol.ChainmakerController.RestoreLedger(); err != nil {
+		return fmt.Errorf("failed to restore ledger snapshot: %v", err)
+	}
+	Gas.ResetState()
+	return nil
 }
 
 // 新增变异逻辑，对种子对下的种子字段进行随机变异
-// 取出首个种子，变异10000次，每次变异后执行对比maxsimilarity
-func (f *FuncPairSeedsPool) MutateFirstSeedInPool() {
+// 由调度器选取优先级最高的种子，按分配的能量变异若干次，每次变异后执行对比maxsimilarity
+func (f *FuncPairSeedsPool) MutateNextSeedInPool() {
 	Log := utils.Log
 
-	e := f.MutateSeeds.Front()
+	e := Schedule.Next(f.MutateSeeds)
 
 	if e == nil {
 		Log.Log(utils.FuzzLog, "we don't have seed to mutate!")
@@ -182,10 +311,28 @@ func (f *FuncPairSeedsPool) MutateFirstSeedInPool() {
 	}
 
 	seed := e.Value.(*FuncPairSeed)
-	rand.Seed(time.Now().UnixNano())
-
-	Log.Log(utils.FuzzLog, fmt.Sprintf("we will start mutate this seed:%s", seed))
-	for i := 0; i < 10000; i++ {
+	seedID := seed.ID()
+	rng := utils.Rand()
+
+	schedule := scheduleOf(seed)
+	if timeoutProneFunc(seed) != "" {
+		utils.Decisions.Record("mutate", seedID, RetiredTimeout)
+		schedule.Retired = RetiredTimeout
+		f.retireSeed(e)
+		return
+	}
+	similarityBefore := seed.MaxSimilarity
+	energy := Schedule.Energy(seed)
+	schedule.Energy = energy
+	utils.Decisions.Record("schedule_mutate", seedID, fmt.Sprintf("energy:%d", energy))
+
+	start := time.Now()
+	event := seed.Event(utils.FuzzLog, utils.EventMutateStart, "we will start mutate this seed")
+	event.Fields["energy"] = energy
+	Log.Emit(event)
+
+	executions := 0
+	for i := 0; i < energy && !Schedule.Expired() && timeoutProneFunc(seed) == ""; i++ {
 		// 深拷贝一个种子用于变异
 		copy, err := copystructure.Copy(seed)
 		if err != nil {
@@ -208,97 +355,153 @@ func (f *FuncPairSeedsPool) MutateFirstSeedInPool() {
 		mutataParamLen := a + b + c + d
 		// 该种子无需变异
 		if mutataParamLen == 0 {
+			utils.Decisions.Record("mutate", seedID, "immutable")
+			schedule.Retired = RetiredImmutable
+			f.retireSeed(e)
 			return
 		}
 
-		r := rand.Intn(mutataParamLen)
+		r := rng.Intn(mutataParamLen)
 
 		var path ValuePath
+		var mutator string
 		switch {
 		case r < a:
 			path = mutateSeed.SeedOne.ReadRelatedValuePaths[r]
-			mutateSeed.SeedOne.modifyField(mutateSeed.SeedOne.FunctionInput, path)
+			mutator = mutateSeed.SeedOne.modifyField(mutateSeed.SeedOne.FunctionInput, path)
 			mutateSeed.SeedOne.getRWSets()
 		case r < a+b:
 			path = mutateSeed.SeedOne.WriteRelatedValuePaths[r-a]
-			mutateSeed.SeedOne.modifyField(mutateSeed.SeedOne.FunctionInput, path)
+			mutator = mutateSeed.SeedOne.modifyField(mutateSeed.SeedOne.FunctionInput, path)
 			mutateSeed.SeedOne.getRWSets()
 		case r < a+b+c:
 			path = mutateSeed.SeedTwo.ReadRelatedValuePaths[r-a-b]
-			mutateSeed.SeedTwo.modifyField(mutateSeed.SeedTwo.FunctionInput, path)
+			mutator = mutateSeed.SeedTwo.modifyField(mutateSeed.SeedTwo.FunctionInput, path)
 			mutateSeed.SeedTwo.getRWSets()
 		case r < a+b+c+d:
 			path = mutateSeed.SeedTwo.WriteRelatedValuePaths[r-a-b-c]
-			mutateSeed.SeedTwo.modifyField(mutateSeed.SeedTwo.FunctionInput, path)
+			mutator = mutateSeed.SeedTwo.modifyField(mutateSeed.SeedTwo.FunctionInput, path)
 			mutateSeed.SeedTwo.getRWSets()
 		}
 
 		mutateSeed.MaxSimilarity = calculateMaxSimilarity(mutateSeed.SeedOne, mutateSeed.SeedTwo)
+		executions++
+
+		improved := mutateSeed.MaxSimilarity > seed.MaxSimilarity
+		utils.CreditMutator(mutator, improved, mutateSeed.MaxSimilarity > 0.99)
+		if improved {
+			// 使相似度提升的取值加入语料，供后续拼接使用
+			recordSeedValues(mutateSeed.SeedOne)
+			recordSeedValues(mutateSeed.SeedTwo)
+		}
 
 		if mutateSeed.MaxSimilarity > 0.99 {
+			Schedule.Finish(seed, executions, similarityBefore)
+			mutateSeed.Schedule = nil
+			f.corpus().detach(e)
 			f.MutateSeeds.Remove(e)
-			f.ConflictSeeds.PushBack(mutateSeed)
-			Log.Log(utils.FuzzLog, fmt.Sprintf("we find new conflict Seed!: %s", mutateSeed))
+			// 已有相同形状的冲突种子时不再重复进行冲突实验
+			if !f.corpus().AddPair(CorpusConflict, mutateSeed, f.ConflictSeeds) {
+				Log.Log(utils.FuzzLog, "conflict seed with the same rwset shape already exists: "+mutateSeed.ID())
+			} else {
+				Sync.Publish(mutateSeed)
+			}
+			if f.ConflictMap != nil {
+				f.ConflictMap.Record(mutateSeed.SeedOne, mutateSeed.SeedTwo, mutateSeed.MaxSimilarity, true)
+			}
+			utils.Decisions.Record("mutate", seedID, fmt.Sprintf("found:%d:%s", i, mutateSeed.ID()))
+			event := mutateSeed.Event(utils.FuzzLog, utils.EventMutateFound, "we find new conflict Seed!")
+			event.DurationMs = time.Since(start).Milliseconds()
+			event.Fields["iterations"] = i + 1
+			event.Fields["mutator"] = mutator
+			Log.Emit(event)
 			return
 		}
 
-		if mutateSeed.MaxSimilarity > seed.MaxSimilarity {
+		if improved {
 			*seed = *mutateSeed
+			// 调度状态不随变异结果复制
+			seed.Schedule = schedule
 		}
 	}
-	Log.Log(utils.FuzzLog, fmt.Sprintf("we don't find confict seed in this round:%s", seed))
-	f.MutateSeeds.MoveToBack(e)
-}
 
-// Helper function to check if a seed pair already exists in a list based on function names
-func containsFuncPairByName(list *list.List, seed *FuncPairSeed) bool {
+	retired := Schedule.Finish(seed, executions, similarityBefore)
+	utils.Decisions.Record("mutate", seedID, "miss:"+seed.ID())
+	event = seed.Event(utils.FuzzLog, utils.EventMutateMiss, "we don't find confict seed in this round")
+	event.DurationMs = time.Since(start).Milliseconds()
+	event.Fields["executions"] = executions
+	event.Fields["schedule"] = schedule
+	Log.Emit(event)
 
-	seedOne := seed.SeedOne
-	seedTwo := seed.SeedTwo
+	if retired != "" {
+		f.retireSeed(e)
+	}
+}
 
-	// Generate a unique, order-independent identifier for the new pair
-	newPair := map[string]struct{}{
-		seedOne.FunctionName: {},
-		seedTwo.FunctionName: {},
+func (f *FuncPairSeedsPool) corpus() *Corpus {
+	if f.Corpus == nil {
+		f.Corpus = NewCorpus()
 	}
+	return f.Corpus
+}
 
-	for e := list.Front(); e != nil; e = e.Next() {
-		pair := e.Value.(*FuncPairSeed)
+// 将种子移出变异队列
+func (f *FuncPairSeedsPool) retireSeed(e *list.Element) {
+	seed := f.moveToRetired(f.MutateSeeds, e)
 
-		// Generate the identifier for the existing pair in the list
-		existingPair := map[string]struct{}{
-			pair.SeedOne.FunctionName: {},
-			pair.SeedTwo.FunctionName: {},
-		}
+	event := seed.Event(utils.FuzzLog, utils.EventSeedRetired, "seed retired: "+scheduleOf(seed).Retired)
+	event.Fields["schedule"] = seed.Schedule
+	utils.Log.Emit(event)
+}
 
-		// Compare the two maps
-		if len(newPair) == len(existingPair) {
-			match := true
-			for fn := range newPair {
-				if _, exists := existingPair[fn]; !exists {
-					match = false
-					break
-				}
-			}
-			if match {
-				return true
-			}
-		}
+// 冲突种子不进行实验，直接退出种子池
+func (f *FuncPairSeedsPool) retireConflictSeed(e *list.Element, reason string, level utils.LogLevel, message string) {
+	seed := e.Value.(*FuncPairSeed)
+	utils.Decisions.Record("schedule_conflict", seed.ID(), reason)
+	scheduleOf(seed).Retired = reason
+	f.moveToRetired(f.ConflictSeeds, e)
+
+	event := seed.Event(utils.ConflictLog, utils.EventSeedRetired, "skip conflict experiment: "+message)
+	event.Level = level
+	event.Fields["reason"] = reason
+	utils.Log.Emit(event)
+}
+
+// 将种子从所在列表移入退出列表
+func (f *FuncPairSeedsPool) moveToRetired(l *list.List, e *list.Element) *FuncPairSeed {
+	f.corpus().detach(e)
+	seed := l.Remove(e).(*FuncPairSeed)
+	if f.RetiredSeeds == nil {
+		f.RetiredSeeds = list.New()
+	}
+	f.RetiredSeeds.PushBack(seed)
+	return seed
+}
+
+// 全局时间预算耗尽时，剩余种子全部退出变异
+func (f *FuncPairSeedsPool) RetireAll(reason string) {
+	for e := f.MutateSeeds.Front(); e != nil; e = f.MutateSeeds.Front() {
+		scheduleOf(e.Value.(*FuncPairSeed)).Retired = reason
+		f.retireSeed(e)
 	}
-	return false
 }
 
 func NewFuncPairSeedsPool(funcSeedsPool *FuncSeedsPool) *FuncPairSeedsPool {
 	Log := utils.Log
 	funcSeedsList := make([]*FuncSeed, 0)
-	pool := funcSeedsPool.Pool
-	for _, list := range pool {
-		funcSeedsList = append(funcSeedsList, list...)
+	for _, funcName := range funcSeedsPool.FuncNames() {
+		funcSeedsList = append(funcSeedsList, funcSeedsPool.Pool[funcName]...)
 	}
 
 	funcPairSeedsPool := &FuncPairSeedsPool{
 		ConflictSeeds: list.New(),
 		MutateSeeds:   list.New(),
+		RetiredSeeds:  list.New(),
+		ConflictMap:   NewConflictMap(),
+		Corpus:        funcSeedsPool.Corpus,
+	}
+	if funcPairSeedsPool.Corpus == nil {
+		funcPairSeedsPool.Corpus = NewCorpus()
 	}
 
 	// Traverse each possible combination of funcSeed
@@ -319,18 +522,17 @@ func NewFuncPairSeedsPool(funcSeedsPool *FuncSeedsPool) *FuncPairSeedsPool {
 			// Calculate mutability
 			pairSeed.Mutability = conflictPotential(seedOne, seedTwo)
 
-			// Check if the pair already exists based on function names
+			funcPairSeedsPool.ConflictMap.Record(seedOne, seedTwo, pairSeed.MaxSimilarity, pairSeed.Mutability)
+
+			// 读写集形状相同的交易对只保留一个代表
 			if pairSeed.MaxSimilarity > 0.99 {
-				if !containsFuncPairByName(funcPairSeedsPool.ConflictSeeds, pairSeed) {
-					funcPairSeedsPool.ConflictSeeds.PushBack(pairSeed)
-				}
+				funcPairSeedsPool.Corpus.AddPair(CorpusConflict, pairSeed, funcPairSeedsPool.ConflictSeeds)
 			} else if pairSeed.Mutability {
-				if !containsFuncPairByName(funcPairSeedsPool.MutateSeeds, pairSeed) {
-					funcPairSeedsPool.MutateSeeds.PushBack(pairSeed)
-				}
+				funcPairSeedsPool.Corpus.AddPair(CorpusMutate, pairSeed, funcPairSeedsPool.MutateSeeds)
 			}
-			Log.Log(utils.ExecutionLog, "生成交易对种子：")
-			Log.Log(utils.ExecutionLog, fmt.Sprint(pairSeed))
+			event := pairSeed.Event(utils.ExecutionLog, utils.EventPairSeed, "生成交易对种子")
+			event.Level = utils.LevelDebug
+			Log.Emit(event)
 		}
 	}
 
@@ -388,16 +590,52 @@ func calculateMaxSimilarity(baseTxRwSet, compareTxRwSet *FuncSeed) float64 {
 	return maxSimilarity
 }
 
+// 获取两个种子读写集之间完全一致的key（A读B写或A写B读），即发生冲突的key
+func ConflictKeys(seedOne, seedTwo *FuncSeed) []string {
+	keys := make([]string, 0)
+	seen := make(map[string]bool)
+
+	collect := func(reads, writes []string) {
+		for _, read := range reads {
+			for _, write := range writes {
+				if read == write && !seen[read] {
+					seen[read] = true
+					keys = append(keys, read)
+				}
+			}
+		}
+	}
+
+	collect(seedOne.ReadSet, seedTwo.WriteSet)
+	collect(seedTwo.ReadSet, seedOne.WriteSet)
+
+	sort.Strings(keys)
+	return keys
+}
+
 type FuncSeedsPool struct {
 	// 函数名 - 对应函数名下的种子
 	Pool map[string][]*FuncSeed
+	// 读写集形状语料，每种形状只保留一个种子
+	Corpus *Corpus
+}
+
+// 按函数名排序的函数列表
+func (f *FuncSeedsPool) FuncNames() []string {
+	names := make([]string, 0, len(f.Pool))
+	for name := range f.Pool {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
 }
 
 func (f *FuncSeedsPool) PrintFuncSeedsPool() {
-	for key, value := range f.Pool {
-		utils.Log.Log(utils.FuncSeedsLog, fmt.Sprintf("-------------------------  FuncName: [%s]  -------------------------", key))
+	for _, key := range f.FuncNames() {
+		value := f.Pool[key]
+		utils.Log.Section(utils.FuncSeedsLog, fmt.Sprintf("FuncName: [%s]", key))
 		for _, seed := range value {
-			utils.Log.Log(utils.FuncSeedsLog, fmt.Sprint(seed))
+			utils.Log.Emit(seed.Event(utils.FuncSeedsLog, utils.EventFuncSeed, ""))
 		}
 	}
 }
@@ -405,11 +643,20 @@ func (f *FuncSeedsPool) PrintFuncSeedsPool() {
 // 为所有funcName都生成对应的FuncSeed
 func NewFuncSeedsPool() *FuncSeedsPool {
 	funcSeedsPool := &FuncSeedsPool{
-		Pool: make(map[string][]*FuncSeed),
+		Pool:   make(map[string][]*FuncSeed),
+		Corpus: NewCorpus(),
 	}
 
-	for funcName := range utils.GlobalContractInfo.ContractFuncMap {
-		funcSeedsPool.Pool[funcName] = generateNewFuncSeedList(funcName)
+	// 按固定顺序生成，保证随机数的使用顺序可复现
+	for _, funcName := range utils.GlobalContractInfo.SortedFuncNames() {
+		// 读写集形状相同的种子只保留第一个
+		seeds := make([]*FuncSeed, 0)
+		for _, seed := range generateNewFuncSeedList(funcName) {
+			if funcSeedsPool.Corpus.AddFuncSeed(seed) {
+				seeds = append(seeds, seed)
+			}
+		}
+		funcSeedsPool.Pool[funcName] = seeds
 	}
 
 	return funcSeedsPool
@@ -421,8 +668,14 @@ type FuncSeed struct {
 	ValuePaths             []ValuePath            `json:"value_paths"`
 	ReadRelatedValuePaths  []ValuePath            `json:"read_related_value_paths"`
 	WriteRelatedValuePaths []ValuePath            `json:"write_related_value_paths"`
+	EventRelatedValuePaths []ValuePath            `json:"event_related_value_paths,omitempty"`
 	ReadSet                []string               `json:"read_set"`
 	WriteSet               []string               `json:"write_set"`
+	Events                 []*ContractEvent       `json:"events,omitempty"` // 按发出顺序保存的合约事件
+	GasUsed                uint64                 `json:"gas_used,omitempty"`
+	OutOfGas               bool                   `json:"out_of_gas,omitempty"`
+	DurationMs             int64                  `json:"duration_ms,omitempty"`
+	TimedOut               bool                   `json:"timed_out,omitempty"`
 }
 
 func (f *FuncSeed) String() string {
@@ -434,19 +687,78 @@ func (f *FuncSeed) String() string {
 				ValuePaths: %v,
 				ReadRelatedValuePaths: %v,
 				WriteRelatedValuePaths: %v,
+				EventRelatedValuePaths: %v,
 				ReadSet: %v,
-				WriteSet: %v
+				WriteSet: %v,
+				Events: %v,
+				GasUsed: %d,
+				OutOfGas: %v,
+				DurationMs: %d,
+				TimedOut: %v
 			}`,
 		f.FunctionName,
 		f.FunctionInput,
 		f.ValuePaths,
 		f.ReadRelatedValuePaths,
 		f.WriteRelatedValuePaths,
+		f.EventRelatedValuePaths,
 		f.ReadSet,
 		f.WriteSet,
+		f.Events,
+		f.GasUsed,
+		f.OutOfGas,
+		f.DurationMs,
+		f.TimedOut,
 	)
 }
 
+// 交易种子的标识，由函数名与输入内容计算得到，相同输入的种子标识相同
+func (f *FuncSeed) ID() string {
+	input, err := json.Marshal(f.FunctionInput)
+	if err != nil {
+		input = []byte(fmt.Sprint(f.FunctionInput))
+	}
+
+	hash := sha1.Sum(append([]byte(f.FunctionName+":"), input...))
+	return hex.EncodeToString(hash[:])[:12]
+}
+
+// 日志中记录的种子输入及读写集
+func (f *FuncSeed) rwSetFields() map[string]interface{} {
+	return map[string]interface{}{
+		"id":        f.ID(),
+		"function":  f.FunctionName,
+		"input":     f.FunctionInput,
+		"read_set":  f.ReadSet,
+		"write_set": f.WriteSet,
+	}
+}
+
+// 构造描述该交易种子的日志事件
+func (f *FuncSeed) Event(phase utils.LogType, eventType string, message string) *utils.Event {
+	return &utils.Event{
+		Level:     utils.LevelInfo,
+		Phase:     phase,
+		Type:      eventType,
+		Message:   message,
+		SeedIDs:   []string{f.ID()},
+		Functions: []string{f.FunctionName},
+		ReadSet:   f.ReadSet,
+		WriteSet:  f.WriteSet,
+		Fields: map[string]interface{}{
+			"input":                     f.FunctionInput,
+			"read_related_value_paths":  f.ReadRelatedValuePaths,
+			"write_related_value_paths": f.WriteRelatedValuePaths,
+			"event_related_value_paths": f.EventRelatedValuePaths,
+			"events":                    f.Events,
+			"gas_used":                  f.GasUsed,
+			"out_of_gas":                f.OutOfGas,
+			"duration_ms":               f.DurationMs,
+			"timed_out":                 f.TimedOut,
+		},
+	}
+}
+
 // 为某个Func生成所有param对应confirmValue所生成的FuncSeed
 // 1. 对每个FuncSeed生成叶子节点路径
 // 2. 获取该输入下读写集
@@ -491,6 +803,7 @@ func generateNewFuncSeedList(funcName string) []*FuncSeed {
 	var newFuncSeedList []*FuncSeed
 
 	for _, functionInput := range result {
+		start := time.Now()
 		funcSeed := &FuncSeed{
 			FunctionName:           funcName,
 			FunctionInput:          functionInput,
@@ -510,9 +823,12 @@ func generateNewFuncSeedList(funcName string) []*FuncSeed {
 		// 获取该funcSeed读写集变化相关变量
 		funcSeed.getRelatedValuePaths()
 
-		// deubg:
-		Log.Log(utils.ExecutionLog, "生成交易种子：")
-		Log.Log(utils.ExecutionLog, fmt.Sprint(funcSeed))
+		recordSeedValues(funcSeed)
+
+		utils.Decisions.Record("func_seed", funcName, funcSeed.ID())
+		event := funcSeed.Event(utils.ExecutionLog, utils.EventFuncSeed, "生成交易种子")
+		event.DurationMs = time.Since(start).Milliseconds()
+		Log.Emit(event)
 
 		newFuncSeedList = append(newFuncSeedList, funcSeed)
 	}
@@ -524,9 +840,14 @@ func generateNewFuncSeedList(funcName string) []*FuncSeed {
 func (f *FuncSeed) getValuePaths(data interface{}, parentPath ValuePath, paths *[]ValuePath) {
 	switch v := data.(type) {
 	case map[string]interface{}:
-		for key, value := range v {
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
 			currentPath := append(append((make([]string, 0)), parentPath...), key)
-			f.getValuePaths(value, currentPath, paths)
+			f.getValuePaths(v[key], currentPath, paths)
 		}
 	case []interface{}:
 		for index, value := range v {
@@ -548,19 +869,28 @@ func (f *FuncSeed) convertRwSetToStringList(txTwSet *common.TxRWSet) ([]string,
 
 	if txTwSet != nil && txTwSet.TxReads != nil {
 		for _, txRead := range txTwSet.TxReads {
-			ReadSet = append(ReadSet, string(txRead.Key))
+			ReadSet = append(ReadSet, f.rwSetKeyName(txRead.ContractName, txRead.Key))
 		}
 	}
 
 	if txTwSet != nil && txTwSet.TxWrites != nil {
 		for _, txWrite := range txTwSet.TxWrites {
-			WriteSet = append(WriteSet, string(txWrite.Key))
+			WriteSet = append(WriteSet, f.rwSetKeyName(txWrite.ContractName, txWrite.Key))
 		}
 	}
 
 	return ReadSet, WriteSet
 }
 
+// EVM 合约自身的读写集键为存储槽位，按存储布局还原为状态变量名，其余键保持原样
+func (f *FuncSeed) rwSetKeyName(contractName string, key []byte) string {
+	contract := utils.GlobalContractInfo.EVM
+	if contract == nil || contractName != utils.GlobalContractInfo.ContractName {
+		return string(key)
+	}
+	return contract.KeyName(key, f.FunctionInput)
+}
+
 // 将一个map[string]interface{}类型转化为key value pair类型
 func (f *FuncSeed) convertMapToKeyValuePair(input map[string]interface{}) []*common.KeyValuePair {
 	KeyValuePair := make([]*common.KeyValuePair, 0)
@@ -586,14 +916,24 @@ func (f *FuncSeed) convertMapToKeyValuePair(input map[string]interface{}) []*com
 // 获取读写集
 // 提交交易执行请求
 // 根据交易id进行查询
+// 同时记录本次执行消耗的 gas、耗时及是否耗尽 gas 或超时
 func (f *FuncSeed) getRWSets() {
 	keyValuePair := f.convertMapToKeyValuePair(f.FunctionInput)
 
-	txid, _, _, _, _ := nodecontrol.ChainmakerController.UserContractInvoke(utils.GlobalContractInfo.ContractName, f.FunctionName, keyValuePair, true)
+	result := probe(f.FunctionName, f.FunctionInput, keyValuePair)
+	f.GasUsed = result.gasUsed
+	f.OutOfGas = result.outOfGas
+	f.DurationMs = result.duration.Milliseconds()
+	f.TimedOut = result.timedOut
 
-	txInfo, _ := nodecontrol.ChainmakerController.Client.GetTxWithRWSetByTxId(txid)
+	txInfo, _ := nodecontrol.ChainmakerController.Client.GetTxWithRWSetByTxId(result.txId)
 
 	f.ReadSet, f.WriteSet = f.convertRwSetToStringList(txInfo.RwSet)
+	f.Events = convertContractEvents(txInfo)
+	if result.success {
+		EventOracle.Check(f)
+	}
+	Gas.Sample(f)
 }
 
 // 获取读写相关变量
@@ -602,13 +942,18 @@ func (f *FuncSeed) getRWSets() {
 
 	2. 在原始input上根据path进行改动并执行交易
 
-	3. 计算变异后与变异前的读写集，判断是否发生变化
+	3. 计算变异后与变异前的读写集及合约事件，判断是否发生变化
 
 	4. 将path加入对应relatedValuePaths
 */
 func (f *FuncSeed) getRelatedValuePaths() {
 	// 1. 遍历path
 	for _, path := range f.ValuePaths {
+		// 易超时函数的读写集没有意义，不再逐个路径探测
+		if Latency.TimeoutProne(f.FunctionName) {
+			return
+		}
+
 		// 2. 根据path对input进行变异
 		copy, err := copystructure.Copy(f.FunctionInput)
 		if err != nil {
@@ -627,9 +972,9 @@ func (f *FuncSeed) getRelatedValuePaths() {
 
 		mutateKeyValuePair := f.convertMapToKeyValuePair(mutateInput)
 
-		txid, _, _, _, _ := nodecontrol.ChainmakerController.UserContractInvoke(utils.GlobalContractInfo.ContractName, f.FunctionName, mutateKeyValuePair, true)
+		result := probe(f.FunctionName, mutateInput, mutateKeyValuePair)
 
-		txInfo, _ := nodecontrol.ChainmakerController.Client.GetTxWithRWSetByTxId(txid)
+		txInfo, _ := nodecontrol.ChainmakerController.Client.GetTxWithRWSetByTxId(result.txId)
 
 		// 3. 计算读写集差异
 		ReadSet, WriteSet := f.convertRwSetToStringList(txInfo.RwSet)
@@ -644,23 +989,34 @@ func (f *FuncSeed) getRelatedValuePaths() {
 			f.WriteRelatedValuePaths = append(f.WriteRelatedValuePaths, path)
 		}
 
+		if !sameEvents(f.Events, convertContractEvents(txInfo)) {
+			f.EventRelatedValuePaths = append(f.EventRelatedValuePaths, path)
+		}
+
 	}
 
 }
 
 // 修改字段值的函数
-func (f *FuncSeed) modifyField(data interface{}, path ValuePath) {
+// 对 path 指向的叶子节点进行变异，返回所使用的变异算子
+func (f *FuncSeed) modifyField(data interface{}, path ValuePath) string {
+	return f.modifyFieldAt(data, path, path)
+}
+
+func (f *FuncSeed) modifyFieldAt(data interface{}, path ValuePath, fullPath ValuePath) string {
 	if len(path) == 0 {
-		return
+		return ""
 	}
 	switch v := data.(type) {
 	case map[string]interface{}:
 		key := path[0]
 		if len(path) == 1 {
-			v[key] = utils.GenerateDiffValue(v[key])
+			var mutator string
+			v[key], mutator = utils.MutateValue(v[key], corpusDonors(fullPath))
+			return mutator
 		} else {
 			if nextData, exists := v[key]; exists {
-				f.modifyField(nextData, path[1:])
+				return f.modifyFieldAt(nextData, path[1:], fullPath)
 			} else {
 				// 错误处理：路径不存在
 			}
@@ -671,16 +1027,18 @@ func (f *FuncSeed) modifyField(data interface{}, path ValuePath) {
 			index, err := strconv.Atoi(indexStr[1 : len(indexStr)-1])
 			if err != nil {
 				// 错误处理：索引解析失败
-				return
+				return ""
 			}
 			if index < 0 || index >= len(v) {
 				// 错误处理：索引越界
-				return
+				return ""
 			}
 			if len(path) == 1 {
-				v[index] = utils.GenerateDiffValue(v[index])
+				var mutator string
+				v[index], mutator = utils.MutateValue(v[index], corpusDonors(fullPath))
+				return mutator
 			} else {
-				f.modifyField(v[index], path[1:])
+				return f.modifyFieldAt(v[index], path[1:], fullPath)
 			}
 		} else {
 			// 错误处理：无效的索引格式
@@ -688,6 +1046,7 @@ func (f *FuncSeed) modifyField(data interface{}, path ValuePath) {
 	default:
 		// 错误处理：非预期的类型
 	}
+	return ""
 }
 
 // // 用于判断两个input之间是否存在不同的参数（理论上只会有一个参数不同）
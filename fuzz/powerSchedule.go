@@ -0,0 +1,170 @@
+/*
+	本文件主要用于：
+
+	1. 为可变异交易对种子分配能量（本轮变异次数），综合考虑：
+			a. 当前读写集相似度，越接近冲突能量越高
+			b. 相似度梯度，上一轮的相似度提升幅度
+			c. 最近是否有提升，长期没有提升的种子能量衰减
+			d. 读写相关路径数量，可变异路径越多能量越高
+			e. 年龄，被调度的轮数越多能量越低
+
+	2. 按优先级而非先进先出选取下一个变异的种子
+
+	3. 停止条件：
+			a. 全局时间预算，超出后不再进行变异
+			b. 平台期检测，连续若干轮没有提升的种子退出变异
+			c. 每个交易对的最大执行次数
+			d. 交易对中包含易超时函数
+			e. 分布式模式下由其他 worker 负责的交易对
+*/
+
+package fuzz
+
+import (
+	"container/list"
+	"math"
+	"time"
+)
+
+// 种子退出变异的原因
+const (
+	RetiredPlateau    = "plateau"
+	RetiredExecBudget = "exec_budget"
+	RetiredTimeBudget = "time_budget"
+	RetiredImmutable  = "immutable"
+	RetiredTimeout    = "timeout_prone"
+	RetiredWorker     = "other_worker"
+	RetiredClaimed    = "claimed"
+)
+
+// 单个交易对种子的调度状态
+type SeedSchedule struct {
+	Rounds       int     `json:"rounds"`        // 被调度的轮数
+	Executions   int     `json:"executions"`    // 累计变异执行次数
+	LastImproved int     `json:"last_improved"` // 最近一次提升相似度时的全局轮次
+	StaleRounds  int     `json:"stale_rounds"`  // 连续没有提升的轮数
+	Gradient     float64 `json:"gradient"`      // 最近一轮的相似度提升幅度
+	Energy       int     `json:"energy"`        // 最近一轮分配的能量
+	Retired      string  `json:"retired,omitempty"`
+}
+
+type PowerSchedule struct {
+	BaseEnergy      int           // 基础能量
+	MinEnergy       int           // 每轮最少变异次数
+	MaxEnergy       int           // 每轮最多变异次数
+	TimeBudget      time.Duration // 变异阶段的全局时间预算，0 表示不限制
+	PlateauRounds   int           // 连续多少轮没有提升视为进入平台期，0 表示不检测
+	MaxExecsPerPair int           // 每个交易对的最大执行次数，0 表示不限制
+
+	round int
+	start time.Time
+}
+
+// 全局调度器，参数可通过命令行设置
+var Schedule = &PowerSchedule{
+	BaseEnergy:      2000,
+	MinEnergy:       100,
+	MaxEnergy:       10000,
+	PlateauRounds:   5,
+	MaxExecsPerPair: 50000,
+}
+
+// 开始计时
+func (p *PowerSchedule) Start() {
+	p.start = time.Now()
+}
+
+// 是否已超出全局时间预算
+func (p *PowerSchedule) Expired() bool {
+	return p.TimeBudget > 0 && !p.start.IsZero() && time.Since(p.start) >= p.TimeBudget
+}
+
+func scheduleOf(seed *FuncPairSeed) *SeedSchedule {
+	if seed.Schedule == nil {
+		seed.Schedule = &SeedSchedule{}
+	}
+	return seed.Schedule
+}
+
+func relatedPathCount(seed *FuncPairSeed) int {
+	return len(seed.SeedOne.ReadRelatedValuePaths) + len(seed.SeedOne.WriteRelatedValuePaths) +
+		len(seed.SeedTwo.ReadRelatedValuePaths) + len(seed.SeedTwo.WriteRelatedValuePaths)
+}
+
+// 种子的优先级，同时作为能量分配的依据
+func (p *PowerSchedule) priority(seed *FuncPairSeed) float64 {
+	s := scheduleOf(seed)
+
+	score := 0.5 + seed.MaxSimilarity
+	score *= 1 + 10*s.Gradient
+	score *= 1 + math.Log2(1+float64(relatedPathCount(seed)))/4
+	score /= math.Sqrt(1 + float64(s.Rounds)/2)
+
+	// 上一轮有提升的种子加倍，尚未被调度过的种子不做衰减
+	if s.Rounds > 0 {
+		if s.StaleRounds == 0 {
+			score *= 2
+		} else {
+			score /= 1 + float64(s.StaleRounds)/2
+		}
+	}
+
+	return score
+}
+
+// Energy 本轮分配给该种子的变异次数
+func (p *PowerSchedule) Energy(seed *FuncPairSeed) int {
+	energy := int(float64(p.BaseEnergy) * p.priority(seed))
+	if energy < p.MinEnergy {
+		energy = p.MinEnergy
+	}
+	if energy > p.MaxEnergy {
+		energy = p.MaxEnergy
+	}
+
+	if p.MaxExecsPerPair > 0 {
+		remaining := p.MaxExecsPerPair - scheduleOf(seed).Executions
+		if energy > remaining {
+			energy = remaining
+		}
+	}
+	return energy
+}
+
+// Next 选取优先级最高的种子，优先级相同时取靠前者
+func (p *PowerSchedule) Next(seeds *list.List) *list.Element {
+	var best *list.Element
+	bestScore := -1.0
+
+	for e := seeds.Front(); e != nil; e = e.Next() {
+		score := p.priority(e.Value.(*FuncPairSeed))
+		if score > bestScore {
+			best, bestScore = e, score
+		}
+	}
+	return best
+}
+
+// Finish 记录一轮变异结果，返回该种子是否应退出变异以及原因
+func (p *PowerSchedule) Finish(seed *FuncPairSeed, executions int, similarityBefore float64) string {
+	p.round++
+
+	s := scheduleOf(seed)
+	s.Rounds++
+	s.Executions += executions
+	s.Gradient = math.Max(0, seed.MaxSimilarity-similarityBefore)
+	if s.Gradient > 0 {
+		s.LastImproved = p.round
+		s.StaleRounds = 0
+	} else {
+		s.StaleRounds++
+	}
+
+	switch {
+	case p.MaxExecsPerPair > 0 && s.Executions >= p.MaxExecsPerPair:
+		s.Retired = RetiredExecBudget
+	case p.PlateauRounds > 0 && s.StaleRounds >= p.PlateauRounds:
+		s.Retired = RetiredPlateau
+	}
+	return s.Retired
+}
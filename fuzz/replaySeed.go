@@ -0,0 +1,488 @@
+/*
+	本文件主要用于：
+
+	1. 从保存的交易对种子池中选出单个交易种子或交易对种子：
+			a. 按下标选择，下标按文件中 conflict_seeds、mutate_seeds、retired_seeds 的顺序排列
+			b. 按交易对种子 ID 或交易种子 ID 选择
+
+	2. 将选中的种子在链上重放 N 次，顺序或并发发送，每次记录：
+			a. 每笔交易的执行结果、区块高度、交易下标与读写集
+			b. 两笔交易是否位于同一区块，以及区块 DAG 中是否存在依赖边
+			c. 实际的冲突 key，以及读写集是否与种子中保存的一致
+
+	3. 输出与重放过程等价的 cmc 命令，便于手动复现
+*/
+
+package fuzz
+
+import (
+	nodecontrol "TransactionRwset/nodeControl"
+	"TransactionRwset/utils"
+	"container/list"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"chainmaker.org/chainmaker/pb-go/v2/common"
+)
+
+// 结果目录下保存重放结果的文件名
+const ReplayResultFileName = "replay.json"
+
+type ReplayOptions struct {
+	PoolFile   string
+	Selector   string // 种子下标、交易对种子 ID 或交易种子 ID
+	Times      int
+	Concurrent bool
+	Minimized  bool   // 若种子已最小化，则重放最小化后的种子
+	Cmc        bool   // 输出等价的 cmc 命令
+	ChainID    string // cmc 命令使用的链 ID
+}
+
+// 重放中的单笔交易
+type ReplayTx struct {
+	Function    string           `json:"function"`
+	TxId        string           `json:"tx_id"`
+	Code        string           `json:"code"`
+	Message     string           `json:"message,omitempty"`
+	SendError   string           `json:"send_error,omitempty"`
+	BlockHeight uint64           `json:"block_height"`
+	TxIndex     uint32           `json:"tx_index"`
+	GasUsed     uint64           `json:"gas_used,omitempty"`
+	ReadSet     []string         `json:"read_set"`
+	WriteSet    []string         `json:"write_set"`
+	RWSetSame   bool             `json:"rwset_same"` // 读写集是否与种子中保存的一致
+	Events      []*ContractEvent `json:"events,omitempty"`
+	EventsSame  bool             `json:"events_same"` // 合约事件是否与种子中保存的一致
+	DurationMs  int64            `json:"duration_ms"`
+}
+
+// 一次重放
+type ReplayRun struct {
+	Index        int         `json:"index"`
+	Txs          []*ReplayTx `json:"txs"`
+	SameBlock    bool        `json:"same_block"`
+	DagEdge      bool        `json:"dag_edge"`      // DAG 中两笔交易之间存在直接依赖
+	DagPath      bool        `json:"dag_path"`      // DAG 中两笔交易之间存在依赖路径
+	ConflictKeys []string    `json:"conflict_keys"` // 冲突类型:冲突 key
+}
+
+type ReplayResult struct {
+	PoolFile    string       `json:"pool_file"`
+	Selector    string       `json:"selector"`
+	SeedID      string       `json:"seed_id"`
+	Minimized   bool         `json:"minimized"`
+	Concurrent  bool         `json:"concurrent"`
+	Seeds       []*FuncSeed  `json:"seeds"`
+	Runs        []*ReplayRun `json:"runs"`
+	CmcCommands []string     `json:"cmc_commands,omitempty"`
+}
+
+// 按文件中的顺序列出种子池中的所有交易对种子
+func (f *FuncPairSeedsPool) AllPairSeeds() []*FuncPairSeed {
+	seeds := make([]*FuncPairSeed, 0)
+	for _, l := range []*list.List{f.ConflictSeeds, f.MutateSeeds, f.RetiredSeeds} {
+		seeds = append(seeds, listToSlice(l)...)
+	}
+	return seeds
+}
+
+// SelectReplaySeeds 根据选择器选出需要重放的交易种子，返回种子及其 ID
+// 选中交易对种子时返回两个交易种子，选中交易种子时只返回一个
+func SelectReplaySeeds(pool *FuncPairSeedsPool, selector string, minimized bool) ([]*FuncSeed, string, error) {
+	pairs := pool.AllPairSeeds()
+
+	pick := func(pair *FuncPairSeed) *FuncPairSeed {
+		if minimized && pair.Minimized != nil {
+			return pair.Minimized
+		}
+		return pair
+	}
+
+	for _, pair := range pairs {
+		if pair.ID() == selector {
+			pair = pick(pair)
+			return []*FuncSeed{pair.SeedOne, pair.SeedTwo}, pair.ID(), nil
+		}
+	}
+
+	for _, pair := range pairs {
+		pair = pick(pair)
+		for _, seed := range []*FuncSeed{pair.SeedOne, pair.SeedTwo} {
+			if seed.ID() == selector {
+				return []*FuncSeed{seed}, seed.ID(), nil
+			}
+		}
+	}
+
+	index, err := strconv.Atoi(selector)
+	if err != nil {
+		return nil, "", fmt.Errorf("no seed matches %q", selector)
+	}
+	if index < 0 || index >= len(pairs) {
+		return nil, "", fmt.Errorf("seed index %d out of range [0, %d)", index, len(pairs))
+	}
+
+	pair := pick(pairs[index])
+	return []*FuncSeed{pair.SeedOne, pair.SeedTwo}, pair.ID(), nil
+}
+
+// 发送一笔交易并查询其上链结果与读写集
+func replayTx(seed *FuncSeed) *ReplayTx {
+	start := time.Now()
+	tx := &ReplayTx{Function: seed.FunctionName, ReadSet: []string{}, WriteSet: []string{}}
+
+	txid, message, status, gasUsed, _, err := nodecontrol.ChainmakerController.UserContractInvoke(utils.GlobalContractInfo.ContractName, seed.FunctionName, seed.convertMapToKeyValuePair(seed.FunctionInput), true)
+	tx.TxId = txid
+	tx.GasUsed = gasUsed
+	tx.Code = status.String()
+	tx.Message = message
+	if err != nil {
+		tx.SendError = strings.TrimSpace(err.Error())
+	}
+
+	if txid != "" {
+		txInfo, err := nodecontrol.ChainmakerController.Client.GetTxWithRWSetByTxId(txid)
+		if err != nil {
+			fmt.Println("查询交易出现错误!", txid, err)
+		}
+		if txInfo != nil {
+			tx.BlockHeight = txInfo.BlockHeight
+			tx.TxIndex = txInfo.TxIndex
+			if txInfo.Transaction != nil && txInfo.Transaction.Result != nil {
+				tx.Code = txInfo.Transaction.Result.Code.String()
+				if txInfo.Transaction.Result.ContractResult != nil {
+					tx.Message = txInfo.Transaction.Result.ContractResult.Message
+				}
+			}
+			tx.ReadSet, tx.WriteSet = seed.convertRwSetToStringList(txInfo.RwSet)
+			tx.Events = convertContractEvents(txInfo)
+		}
+	}
+
+	tx.RWSetSame = sameKeys(tx.ReadSet, seed.ReadSet) && sameKeys(tx.WriteSet, seed.WriteSet)
+	tx.EventsSame = sameEvents(tx.Events, seed.Events)
+	tx.DurationMs = time.Since(start).Milliseconds()
+	return tx
+}
+
+func sameKeys(a, b []string) bool {
+	return strings.Join(addUnique(nil, a...), "\n") == strings.Join(addUnique(nil, b...), "\n")
+}
+
+// 区块 DAG 中两笔交易之间是否存在直接依赖，以及是否存在依赖路径
+func dagRelation(dag *common.DAG, one, two uint32) (bool, bool) {
+	if dag == nil || int(one) >= len(dag.Vertexes) || int(two) >= len(dag.Vertexes) || one == two {
+		return false, false
+	}
+
+	// DAG 中每个顶点记录其依赖的交易下标，依赖只会指向下标更小的交易
+	from, to := two, one
+	if one > two {
+		from, to = one, two
+	}
+
+	edge := false
+	for _, neighbor := range dag.Vertexes[from].GetNeighbors() {
+		if neighbor == to {
+			edge = true
+		}
+	}
+
+	visited := make(map[uint32]bool)
+	stack := []uint32{from}
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if visited[current] {
+			continue
+		}
+		visited[current] = true
+
+		for _, neighbor := range dag.Vertexes[current].GetNeighbors() {
+			if neighbor == to {
+				return edge, true
+			}
+			if int(neighbor) < len(dag.Vertexes) {
+				stack = append(stack, neighbor)
+			}
+		}
+	}
+	return edge, false
+}
+
+func replayOnce(index int, seeds []*FuncSeed, concurrent bool) *ReplayRun {
+	run := &ReplayRun{Index: index, Txs: make([]*ReplayTx, len(seeds)), ConflictKeys: []string{}}
+
+	if concurrent {
+		var wg sync.WaitGroup
+		for i, seed := range seeds {
+			wg.Add(1)
+			go func(i int, seed *FuncSeed) {
+				defer utils.RecoverPanic()
+				defer wg.Done()
+				run.Txs[i] = replayTx(seed)
+			}(i, seed)
+		}
+		wg.Wait()
+	} else {
+		for i, seed := range seeds {
+			run.Txs[i] = replayTx(seed)
+		}
+	}
+
+	if len(run.Txs) != 2 {
+		return run
+	}
+
+	one, two := run.Txs[0], run.Txs[1]
+	run.ConflictKeys = exactConflicts(&FuncSeed{ReadSet: one.ReadSet, WriteSet: one.WriteSet}, &FuncSeed{ReadSet: two.ReadSet, WriteSet: two.WriteSet})
+
+	run.SameBlock = one.BlockHeight != 0 && one.BlockHeight == two.BlockHeight
+	if run.SameBlock {
+		block, err := nodecontrol.ChainmakerController.Client.GetBlockByHeight(one.BlockHeight, false)
+		if err != nil {
+			fmt.Println("查询区块出现错误!", one.BlockHeight, err)
+		} else if block != nil && block.Block != nil {
+			run.DagEdge, run.DagPath = dagRelation(block.Block.Dag, one.TxIndex, two.TxIndex)
+		}
+	}
+
+	return run
+}
+
+// ReplaySeed 按选项重放种子，并将结果保存至本次结果目录
+func ReplaySeed(opts *ReplayOptions) (*ReplayResult, error) {
+	pool, err := LoadPairSeedPoolFromFile(opts.PoolFile)
+	if err != nil {
+		return nil, err
+	}
+
+	seeds, id, err := SelectReplaySeeds(pool, opts.Selector, opts.Minimized)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Times <= 0 {
+		opts.Times = 1
+	}
+
+	result := &ReplayResult{
+		PoolFile:   opts.PoolFile,
+		Selector:   opts.Selector,
+		SeedID:     id,
+		Minimized:  opts.Minimized,
+		Concurrent: opts.Concurrent,
+		Seeds:      seeds,
+	}
+
+	for i := 0; i < opts.Times; i++ {
+		run := replayOnce(i, seeds, opts.Concurrent)
+		result.Runs = append(result.Runs, run)
+
+		event := &utils.Event{
+			Level:   utils.LevelInfo,
+			Phase:   utils.ExecutionLog,
+			Type:    utils.EventSeedReplay,
+			Message: fmt.Sprintf("replay %s [%d/%d]", id, i+1, opts.Times),
+			SeedIDs: []string{id},
+			Fields: map[string]interface{}{
+				"run":           run.Index,
+				"same_block":    run.SameBlock,
+				"dag_edge":      run.DagEdge,
+				"conflict_keys": run.ConflictKeys,
+				"txs":           run.Txs,
+			},
+		}
+		for _, seed := range seeds {
+			event.Functions = append(event.Functions, seed.FunctionName)
+		}
+		utils.Log.Emit(event)
+	}
+
+	if opts.Cmc {
+		result.CmcCommands = CmcCommands(seeds, result.Runs, opts.Concurrent, opts.ChainID)
+	}
+
+	if err := result.SaveToFile(filepath.Join(utils.Log.BaseDir, ReplayResultFileName)); err != nil {
+		fmt.Println(err)
+	}
+
+	return result, nil
+}
+
+func (r *ReplayResult) SaveToFile(filePath string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize ReplayResult: %v", err)
+	}
+
+	err = os.WriteFile(filePath, data, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write to file: %v", err)
+	}
+
+	return nil
+}
+
+// 以文本形式输出重放结果：每次重放的交易明细，以及多次重放的汇总
+func (r *ReplayResult) String() string {
+	var sb strings.Builder
+
+	mode := "sequential"
+	if r.Concurrent {
+		mode = "concurrent"
+	}
+	sb.WriteString(fmt.Sprintf("seed: %s (%s), runs: %d, mode: %s\n", r.SeedID, r.Selector, len(r.Runs), mode))
+	for i, seed := range r.Seeds {
+		input, _ := json.Marshal(seed.FunctionInput)
+		sb.WriteString(fmt.Sprintf("  tx%d %s %s\n", i+1, seed.FunctionName, input))
+	}
+
+	sameBlock, dagEdge, conflicts := 0, 0, 0
+	stable := make([]int, len(r.Seeds))
+	stableEvents := make([]int, len(r.Seeds))
+	for _, run := range r.Runs {
+		sb.WriteString(fmt.Sprintf("\nrun %d\n", run.Index))
+		sb.WriteString(fmt.Sprintf("  %-4s %-20s %-24s %8s %6s %6s %6s  %s\n", "tx", "function", "code", "height", "index", "rwset", "events", "tx id"))
+		for i, tx := range run.Txs {
+			rwset := "same"
+			if !tx.RWSetSame {
+				rwset = "diff"
+			} else {
+				stable[i]++
+			}
+			events := "same"
+			if !tx.EventsSame {
+				events = "diff"
+			} else {
+				stableEvents[i]++
+			}
+			sb.WriteString(fmt.Sprintf("  %-4s %-20s %-24s %8d %6d %6s %6s  %s\n", fmt.Sprintf("tx%d", i+1), tx.Function, tx.Code, tx.BlockHeight, tx.TxIndex, rwset, events, tx.TxId))
+			if tx.SendError != "" {
+				sb.WriteString(fmt.Sprintf("       error: %s\n", tx.SendError))
+			}
+			sb.WriteString(fmt.Sprintf("       read:  %s\n", strings.Join(tx.ReadSet, ", ")))
+			sb.WriteString(fmt.Sprintf("       write: %s\n", strings.Join(tx.WriteSet, ", ")))
+			if len(tx.Events) > 0 {
+				events := make([]string, 0, len(tx.Events))
+				for _, event := range tx.Events {
+					events = append(events, event.String())
+				}
+				sb.WriteString(fmt.Sprintf("       event: %s\n", strings.Join(events, ", ")))
+			}
+		}
+
+		if len(run.Txs) == 2 {
+			sb.WriteString(fmt.Sprintf("  same block: %v, dag edge: %v, dag path: %v\n", run.SameBlock, run.DagEdge, run.DagPath))
+			sb.WriteString(fmt.Sprintf("  conflict keys: %s\n", strings.Join(run.ConflictKeys, ", ")))
+			if run.SameBlock {
+				sameBlock++
+			}
+			if run.DagEdge {
+				dagEdge++
+			}
+			if len(run.ConflictKeys) > 0 {
+				conflicts++
+			}
+		}
+	}
+
+	sb.WriteString("\nsummary\n")
+	for i, seed := range r.Seeds {
+		sb.WriteString(fmt.Sprintf("  tx%d %s rwset same as seed: %d/%d, events same as seed: %d/%d\n", i+1, seed.FunctionName, stable[i], len(r.Runs), stableEvents[i], len(r.Runs)))
+	}
+	if len(r.Seeds) == 2 {
+		sb.WriteString(fmt.Sprintf("  conflict: %d/%d, same block: %d/%d, dag edge: %d/%d\n", conflicts, len(r.Runs), sameBlock, len(r.Runs), dagEdge, len(r.Runs)))
+	}
+
+	if len(r.CmcCommands) > 0 {
+		sb.WriteString("\ncmc\n")
+		for _, command := range r.CmcCommands {
+			sb.WriteString(command + "\n")
+		}
+	}
+
+	return sb.String()
+}
+
+// cmc 的 --params 参数，所有参数值均以字符串传递
+func cmcParams(seed *FuncSeed) string {
+	params := make(map[string]string)
+	for _, kv := range seed.convertMapToKeyValuePair(seed.FunctionInput) {
+		params[kv.Key] = string(kv.Value)
+	}
+	data, _ := json.Marshal(params)
+	return "'" + strings.ReplaceAll(string(data), "'", `'\''`) + "'"
+}
+
+// EVM 合约的 cmc --params 参数，按 ABI 参数顺序给出类型与取值，由 cmc 编码
+func cmcEVMParams(seed *FuncSeed) string {
+	params, err := utils.GlobalContractInfo.EVM.CmcParams(seed.FunctionName, seed.convertMapToKeyValuePair(seed.FunctionInput))
+	if err != nil {
+		fmt.Println(err)
+	}
+	data, _ := json.Marshal(params)
+	return "'" + strings.ReplaceAll(string(data), "'", `'\''`) + "'"
+}
+
+// CmcCommands 生成与重放过程等价的 cmc 命令
+// 并发模式下交易在后台异步发送，随后使用 wait 等待
+func CmcCommands(seeds []*FuncSeed, runs []*ReplayRun, concurrent bool, chainID string) []string {
+	sdkConf := "--sdk-conf-path=" + nodecontrol.SDKConfPath()
+	commands := make([]string, 0)
+
+	for _, seed := range seeds {
+		invokeName := seed.FunctionName
+		if info, ok := utils.GlobalContractInfo.ContractFuncMap[seed.FunctionName]; ok {
+			invokeName = info.InvokeName
+		}
+
+		var command string
+		if contract := utils.GlobalContractInfo.EVM; contract != nil {
+			// EVM 合约由 cmc 按 ABI 编码，使用方法名调用
+			command = fmt.Sprintf("./cmc client contract user invoke \\\n--contract-name=%s \\\n--method=%s \\\n--abi-file-path=%s \\\n%s \\\n--params=%s \\\n--sync-result=%v",
+				utils.GlobalContractInfo.ContractName, seed.FunctionName, contract.ABIPath, sdkConf, cmcEVMParams(seed), !concurrent)
+		} else {
+			command = fmt.Sprintf("./cmc client contract user invoke \\\n--contract-name=%s \\\n--method=%s \\\n%s \\\n--params=%s \\\n--sync-result=%v",
+				utils.GlobalContractInfo.ContractName, invokeName, sdkConf, cmcParams(seed), !concurrent)
+		}
+		if concurrent {
+			command += " &"
+		}
+		commands = append(commands, command)
+	}
+	if concurrent {
+		commands = append(commands, "wait")
+	}
+
+	// 查询重放中实际发送的交易及其所在区块
+	heights := make([]uint64, 0)
+	for _, run := range runs {
+		for _, tx := range run.Txs {
+			if tx.TxId == "" {
+				continue
+			}
+			commands = append(commands, fmt.Sprintf("./cmc query tx %s \\\n--chain-id=%s \\\n%s", tx.TxId, chainID, sdkConf))
+			if tx.BlockHeight != 0 {
+				heights = append(heights, tx.BlockHeight)
+			}
+		}
+	}
+
+	sort.Slice(heights, func(i, j int) bool { return heights[i] < heights[j] })
+	for i, height := range heights {
+		if i > 0 && heights[i-1] == height {
+			continue
+		}
+		commands = append(commands, fmt.Sprintf("./cmc query block-by-height %d \\\n--chain-id=%s \\\n%s", height, chainID, sdkConf))
+	}
+
+	return commands
+}
@@ -0,0 +1,239 @@
+/*
+	本文件主要用于：
+
+	1. 对实验中发送的每一笔交易进行结果分类（交易丢失根因分析）：
+			a. 在 RPC 阶段被拒绝（交易池满、交易重复、交易非法、超时、其他原因）
+			b. 被节点接收但最终未上链
+			c. 已上链但合约执行出错
+			d. 已上链但执行超时或 gas 耗尽
+			e. 已上链且执行成功
+
+	2. 按比例、按实验汇总各类结果数量，生成统计表
+*/
+
+package fuzz
+
+import (
+	nodecontrol "TransactionRwset/nodeControl"
+	"TransactionRwset/utils"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"chainmaker.org/chainmaker/pb-go/v2/common"
+)
+
+type TxOutcome string
+
+const (
+	OutcomeRejectedPoolFull      TxOutcome = "rejected_pool_full"
+	OutcomeRejectedDuplicate     TxOutcome = "rejected_duplicate"
+	OutcomeRejectedInvalid       TxOutcome = "rejected_invalid"
+	OutcomeRejectedTimeout       TxOutcome = "rejected_timeout"
+	OutcomeRejectedOther         TxOutcome = "rejected_other"
+	OutcomeAcceptedUnverified    TxOutcome = "accepted_unverified" // 交易已被接收，但未查询其最终状态
+	OutcomeAcceptedNotCommitted  TxOutcome = "accepted_not_committed"
+	OutcomeCommittedContractFail TxOutcome = "committed_contract_error"
+	OutcomeCommittedTimeout      TxOutcome = "committed_timeout"
+	OutcomeCommittedOutOfGas     TxOutcome = "committed_out_of_gas"
+	OutcomeCommittedSuccess      TxOutcome = "committed_success"
+)
+
+// 冲突实验目录下保存统计结果的文件名
+const OutcomesFileName = "outcomes.json"
+
+// 统计表中各结果的输出顺序
+var TxOutcomes = []TxOutcome{
+	OutcomeRejectedPoolFull,
+	OutcomeRejectedDuplicate,
+	OutcomeRejectedInvalid,
+	OutcomeRejectedTimeout,
+	OutcomeRejectedOther,
+	OutcomeAcceptedUnverified,
+	OutcomeAcceptedNotCommitted,
+	OutcomeCommittedContractFail,
+	OutcomeCommittedTimeout,
+	OutcomeCommittedOutOfGas,
+	OutcomeCommittedSuccess,
+}
+
+// 交易是否在 RPC 阶段即被拒绝
+func (o TxOutcome) IsRejected() bool {
+	return strings.HasPrefix(string(o), "rejected_")
+}
+
+// 交易是否最终丢失（未上链）
+func (o TxOutcome) IsLost() bool {
+	return o.IsRejected() || o == OutcomeAcceptedNotCommitted
+}
+
+// 根据 RPC 返回的状态码与信息，判断交易被拒绝的原因
+func classifyRejection(code common.TxStatusCode, message string) TxOutcome {
+	switch {
+	case utils.MessageContains(message, "pool is full", "txpool full", "pool full"):
+		return OutcomeRejectedPoolFull
+	case utils.MessageContains(message, "duplicate", "already exist"):
+		return OutcomeRejectedDuplicate
+	case code == common.TxStatusCode_TIMEOUT || utils.MessageContains(message, "timeout", "deadline exceeded"):
+		return OutcomeRejectedTimeout
+	case code == common.TxStatusCode_INVALID_PARAMETER || code == common.TxStatusCode_NO_PERMISSION ||
+		utils.MessageContains(message, "invalid", "verify"):
+		return OutcomeRejectedInvalid
+	default:
+		return OutcomeRejectedOther
+	}
+}
+
+// 根据上链后的执行结果，判断交易的执行情况
+// gas 耗尽与超时的判断与探测交易一致
+func classifyCommitted(code common.TxStatusCode, gasUsed uint64, message string) TxOutcome {
+	switch {
+	case code == common.TxStatusCode_SUCCESS:
+		return OutcomeCommittedSuccess
+	case nodecontrol.IsOutOfGas(code, gasUsed, message):
+		return OutcomeCommittedOutOfGas
+	case nodecontrol.IsTimeout(code, message):
+		return OutcomeCommittedTimeout
+	default:
+		return OutcomeCommittedContractFail
+	}
+}
+
+// 对单笔交易进行分类
+// queried 表示发送后是否查询过该交易的上链情况，未查询时已接收的交易记为 accepted_unverified
+func ClassifyTx(tx *Tx, queried bool) TxOutcome {
+	if tx.SendStatus != common.TxStatusCode_SUCCESS || tx.SendError != "" {
+		return classifyRejection(tx.SendStatus, tx.SendMessage+" "+tx.SendError)
+	}
+
+	if tx.OnChain {
+		return classifyCommitted(tx.ExecuteResult, tx.GasUsed, tx.ExecuteMessage)
+	}
+
+	if !queried {
+		return OutcomeAcceptedUnverified
+	}
+
+	return OutcomeAcceptedNotCommitted
+}
+
+// 对一组交易进行分类，并将结果写入 Tx.Outcome
+func ClassifyTxs(txs []*Tx, queried bool) {
+	for _, tx := range txs {
+		tx.Outcome = ClassifyTx(tx, queried)
+	}
+}
+
+// 某一组交易（某个比例）的分类统计结果
+type OutcomeTable struct {
+	Label  string            `json:"label"`
+	Total  int               `json:"total"`
+	Counts map[TxOutcome]int `json:"counts"`
+}
+
+func NewOutcomeTable(label string, txs []*Tx) *OutcomeTable {
+	table := &OutcomeTable{
+		Label:  label,
+		Total:  len(txs),
+		Counts: make(map[TxOutcome]int),
+	}
+
+	for _, tx := range txs {
+		table.Counts[tx.Outcome]++
+	}
+
+	return table
+}
+
+// 丢失交易数（被拒绝或未上链）
+func (t *OutcomeTable) Lost() int {
+	lost := 0
+	for outcome, count := range t.Counts {
+		if outcome.IsLost() {
+			lost += count
+		}
+	}
+	return lost
+}
+
+// 单个实验（一个交易对种子）下所有比例的统计结果
+type ExperimentOutcomes struct {
+	Tables []*OutcomeTable `json:"tables"`
+}
+
+func (e *ExperimentOutcomes) Add(table *OutcomeTable) {
+	e.Tables = append(e.Tables, table)
+}
+
+// 将统计结果汇总为一张总表
+func (e *ExperimentOutcomes) Total() *OutcomeTable {
+	total := &OutcomeTable{
+		Label:  "total",
+		Counts: make(map[TxOutcome]int),
+	}
+
+	for _, table := range e.Tables {
+		total.Total += table.Total
+		for outcome, count := range table.Counts {
+			total.Counts[outcome] += count
+		}
+	}
+
+	return total
+}
+
+// 以文本表格形式输出统计结果，行为比例，列为结果分类
+func (e *ExperimentOutcomes) String() string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("%-20s %8s", "ratio", "total"))
+	for _, outcome := range TxOutcomes {
+		sb.WriteString(fmt.Sprintf(" %*s", len(outcome), outcome))
+	}
+	sb.WriteString(fmt.Sprintf(" %8s\n", "lost"))
+
+	for _, table := range append(append([]*OutcomeTable{}, e.Tables...), e.Total()) {
+		sb.WriteString(fmt.Sprintf("%-20s %8d", table.Label, table.Total))
+		for _, outcome := range TxOutcomes {
+			sb.WriteString(fmt.Sprintf(" %*d", len(outcome), table.Counts[outcome]))
+		}
+		sb.WriteString(fmt.Sprintf(" %8d\n", table.Lost()))
+	}
+
+	return sb.String()
+}
+
+// 将统计结果保存为 JSON 与文本表格两份文件
+func (e *ExperimentOutcomes) SaveToFile(jsonPath, textPath string) error {
+	data, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化统计结果失败: %w", err)
+	}
+
+	if err := os.WriteFile(jsonPath, data, 0644); err != nil {
+		return fmt.Errorf("写入统计结果失败: %w", err)
+	}
+
+	if err := os.WriteFile(textPath, []byte(e.String()), 0644); err != nil {
+		return fmt.Errorf("写入统计表失败: %w", err)
+	}
+
+	return nil
+}
+
+// 从文件读取统计结果
+func LoadExperimentOutcomesFromFile(filePath string) (*ExperimentOutcomes, error) {
+	var outcomes *ExperimentOutcomes
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("读取统计结果失败: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &outcomes); err != nil {
+		return nil, fmt.Errorf("解析统计结果失败: %w", err)
+	}
+
+	return outcomes, nil
+}
@@ -0,0 +1,86 @@
+/*
+	本文件主要用于：
+
+	1. 记录每个参数路径在各个种子中出现过的取值，作为拼接变异的来源
+
+	2. 同名参数在不同函数中共享同一组取值，便于在函数之间传递可能冲突的 key 片段
+*/
+
+package fuzz
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 每个参数路径最多保留的取值数量
+var maxCorpusValuesPerPath = 64
+
+// 参数路径 - 该路径出现过的取值
+var valueCorpus = make(map[string][]interface{})
+
+// 数组下标不影响参数含义，统一去掉以便不同长度的数组共享取值
+func corpusKey(path ValuePath) string {
+	parts := make([]string, 0, len(path))
+	for _, part := range path {
+		if strings.HasPrefix(part, "[") && strings.HasSuffix(part, "]") {
+			part = "[]"
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, "/")
+}
+
+func addCorpusValue(path ValuePath, value interface{}) {
+	key := corpusKey(path)
+	values := valueCorpus[key]
+	if len(values) >= maxCorpusValuesPerPath {
+		return
+	}
+
+	// 类型与取值都相同的值只保留一份
+	for _, v := range values {
+		if fmt.Sprintf("%T:%v", v, v) == fmt.Sprintf("%T:%v", value, value) {
+			return
+		}
+	}
+	valueCorpus[key] = append(values, value)
+}
+
+// 将种子各叶子节点的取值加入语料
+func recordSeedValues(seed *FuncSeed) {
+	for _, path := range seed.ValuePaths {
+		if value, ok := lookupValue(seed.FunctionInput, path); ok {
+			addCorpusValue(path, value)
+		}
+	}
+}
+
+// 该路径可用于拼接的取值
+func corpusDonors(path ValuePath) []interface{} {
+	return valueCorpus[corpusKey(path)]
+}
+
+// 根据路径取出叶子节点的值
+func lookupValue(data interface{}, path ValuePath) (interface{}, bool) {
+	if len(path) == 0 {
+		return data, true
+	}
+
+	switch v := data.(type) {
+	case map[string]interface{}:
+		next, ok := v[path[0]]
+		if !ok {
+			return nil, false
+		}
+		return lookupValue(next, path[1:])
+	case []interface{}:
+		var index int
+		if _, err := fmt.Sscanf(path[0], "[%d]", &index); err != nil || index < 0 || index >= len(v) {
+			return nil, false
+		}
+		return lookupValue(v[index], path[1:])
+	default:
+		return nil, false
+	}
+}
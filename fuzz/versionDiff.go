@@ -0,0 +1,267 @@
+/*
+	本文件主要用于：
+
+	1. 比较合约两个版本的接口：新增与删除的函数、参数列表变化的函数、候选类型变化的参数
+
+	2. 在当前部署的合约版本上顺序执行语料中的交易对种子，记录两笔交易的执行结果与冲突签名
+	   （冲突类型 + 冲突 key 模板）；函数在当前版本中不存在时跳过
+
+	3. 逐个种子比较两个版本上的冲突签名，分为新增、消失、改变与不变的冲突，
+	   新增或改变的冲突视为回归，可用于在合约变更前进行检查
+*/
+
+package fuzz
+
+import (
+	"TransactionRwset/utils"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// 结果目录下保存版本对比结果的文件名
+const VersionDiffFileName = "version_diff.json"
+
+// 种子在两个版本上的冲突变化
+type VersionChange string
+
+const (
+	VersionConflictAppeared    VersionChange = "appeared"
+	VersionConflictDisappeared VersionChange = "disappeared"
+	VersionConflictChanged     VersionChange = "changed"
+	VersionUnchanged           VersionChange = "unchanged"
+	VersionSkipped             VersionChange = "skipped"
+)
+
+var VersionChanges = []VersionChange{VersionConflictAppeared, VersionConflictDisappeared, VersionConflictChanged, VersionUnchanged, VersionSkipped}
+
+// 交易对种子在某个版本上的执行结果
+type VersionSeedRun struct {
+	Codes        []string `json:"codes,omitempty"`         // 两笔交易的执行结果
+	Signature    []string `json:"signature"`               // 冲突类型:冲突 key 模板
+	ConflictKeys []string `json:"conflict_keys,omitempty"` // 冲突类型:冲突 key
+	Skipped      string   `json:"skipped,omitempty"`       // 未执行的原因
+}
+
+// 单个交易对种子的版本对比
+type SeedVersionDiff struct {
+	PairID    string          `json:"pair_id"`
+	Functions string          `json:"functions"`
+	Change    VersionChange   `json:"change"`
+	Old       *VersionSeedRun `json:"old"`
+	New       *VersionSeedRun `json:"new"`
+}
+
+// 参数列表变化的函数
+type MethodChange struct {
+	Method    string   `json:"method"`
+	OldParams []string `json:"old_params"`
+	NewParams []string `json:"new_params"`
+}
+
+// 候选类型变化的参数
+type TypeChange struct {
+	Param    string   `json:"param"`
+	OldTypes []string `json:"old_types"`
+	NewTypes []string `json:"new_types"`
+}
+
+type VersionDiff struct {
+	Contract       string                `json:"contract"`
+	OldPath        string                `json:"old_path"`
+	NewPath        string                `json:"new_path"`
+	OldVersion     string                `json:"old_version"`
+	NewVersion     string                `json:"new_version"`
+	UpgradeTxId    string                `json:"upgrade_tx_id,omitempty"`
+	MethodsAdded   []string              `json:"methods_added,omitempty"`
+	MethodsRemoved []string              `json:"methods_removed,omitempty"`
+	ParamsChanged  []*MethodChange       `json:"params_changed,omitempty"`
+	TypesChanged   []*TypeChange         `json:"types_changed,omitempty"`
+	Counts         map[VersionChange]int `json:"counts"`
+	Seeds          []*SeedVersionDiff    `json:"seeds"`
+}
+
+// NewVersionDiff 比较两个版本的合约信息摘要，种子对比结果由 AddSeed 加入
+func NewVersionDiff(oldInfo, newInfo *utils.ContractInfoSummary) *VersionDiff {
+	diff := &VersionDiff{
+		Contract: newInfo.ContractName,
+		OldPath:  oldInfo.ContractPath,
+		NewPath:  newInfo.ContractPath,
+		Counts:   make(map[VersionChange]int),
+	}
+
+	for method, oldFunc := range oldInfo.ContractFuncMap {
+		newFunc, ok := newInfo.ContractFuncMap[method]
+		if !ok {
+			diff.MethodsRemoved = append(diff.MethodsRemoved, method)
+			continue
+		}
+		if strings.Join(oldFunc.ParamsNameList, ",") != strings.Join(newFunc.ParamsNameList, ",") {
+			diff.ParamsChanged = append(diff.ParamsChanged, &MethodChange{Method: method, OldParams: oldFunc.ParamsNameList, NewParams: newFunc.ParamsNameList})
+		}
+	}
+	for method := range newInfo.ContractFuncMap {
+		if _, ok := oldInfo.ContractFuncMap[method]; !ok {
+			diff.MethodsAdded = append(diff.MethodsAdded, method)
+		}
+	}
+	sort.Strings(diff.MethodsAdded)
+	sort.Strings(diff.MethodsRemoved)
+	sort.Slice(diff.ParamsChanged, func(i, j int) bool { return diff.ParamsChanged[i].Method < diff.ParamsChanged[j].Method })
+
+	for param, oldTypes := range oldInfo.ParamTypes {
+		newTypes, ok := newInfo.ParamTypes[param]
+		if !ok {
+			continue
+		}
+		if strings.Join(oldTypes.CandidateTypes, ",") != strings.Join(newTypes.CandidateTypes, ",") {
+			diff.TypesChanged = append(diff.TypesChanged, &TypeChange{Param: param, OldTypes: oldTypes.CandidateTypes, NewTypes: newTypes.CandidateTypes})
+		}
+	}
+	sort.Slice(diff.TypesChanged, func(i, j int) bool { return diff.TypesChanged[i].Param < diff.TypesChanged[j].Param })
+
+	return diff
+}
+
+// RunVersionCorpus 在当前部署的合约版本上顺序执行每个交易对种子，返回交易对种子 ID - 执行结果
+func RunVersionCorpus(pairs []*FuncPairSeed) map[string]*VersionSeedRun {
+	runs := make(map[string]*VersionSeedRun, len(pairs))
+	for i, pair := range pairs {
+		run := &VersionSeedRun{Signature: []string{}}
+		runs[pair.ID()] = run
+
+		for _, seed := range []*FuncSeed{pair.SeedOne, pair.SeedTwo} {
+			if _, ok := utils.GlobalContractInfo.ContractFuncMap[seed.FunctionName]; !ok {
+				run.Skipped = "method " + seed.FunctionName + " not found"
+			}
+		}
+		if run.Skipped != "" {
+			continue
+		}
+
+		replay := replayOnce(i, []*FuncSeed{pair.SeedOne, pair.SeedTwo}, false)
+		one, two := replay.Txs[0], replay.Txs[1]
+		run.Codes = []string{one.Code, two.Code}
+		run.ConflictKeys = replay.ConflictKeys
+		run.Signature = conflictSignature(&FuncSeed{ReadSet: one.ReadSet, WriteSet: one.WriteSet}, &FuncSeed{ReadSet: two.ReadSet, WriteSet: two.WriteSet})
+	}
+	return runs
+}
+
+// 冲突签名：冲突类型 + 冲突 key 模板
+func conflictSignature(seedOne, seedTwo *FuncSeed) []string {
+	signature := make([]string, 0)
+
+	readWrite := append(intersectKeys(seedOne.ReadSet, seedTwo.WriteSet, identity), intersectKeys(seedOne.WriteSet, seedTwo.ReadSet, identity)...)
+	for _, key := range readWrite {
+		signature = addUnique(signature, conflictKindReadWrite+":"+keyTemplate(key))
+	}
+	for _, key := range intersectKeys(seedOne.WriteSet, seedTwo.WriteSet, identity) {
+		signature = addUnique(signature, conflictKindWriteWrite+":"+keyTemplate(key))
+	}
+
+	return signature
+}
+
+// AddSeed 比较交易对种子在两个版本上的冲突签名
+func (d *VersionDiff) AddSeed(pair *FuncPairSeed, oldRun, newRun *VersionSeedRun) *SeedVersionDiff {
+	seedDiff := &SeedVersionDiff{
+		PairID:    pair.ID(),
+		Functions: pair.SeedOne.FunctionName + " × " + pair.SeedTwo.FunctionName,
+		Old:       oldRun,
+		New:       newRun,
+	}
+
+	oldSignature, newSignature := strings.Join(oldRun.Signature, "\n"), strings.Join(newRun.Signature, "\n")
+	switch {
+	case oldRun.Skipped != "" || newRun.Skipped != "":
+		seedDiff.Change = VersionSkipped
+	case oldSignature == newSignature:
+		seedDiff.Change = VersionUnchanged
+	case oldSignature == "":
+		seedDiff.Change = VersionConflictAppeared
+	case newSignature == "":
+		seedDiff.Change = VersionConflictDisappeared
+	default:
+		seedDiff.Change = VersionConflictChanged
+	}
+
+	d.Counts[seedDiff.Change]++
+	d.Seeds = append(d.Seeds, seedDiff)
+	return seedDiff
+}
+
+// Regressions 新增或改变的冲突数
+func (d *VersionDiff) Regressions() int {
+	return d.Counts[VersionConflictAppeared] + d.Counts[VersionConflictChanged]
+}
+
+func (d *VersionDiff) SaveToFile(filePath string) error {
+	data, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize VersionDiff: %v", err)
+	}
+
+	err = os.WriteFile(filePath, data, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write to file: %v", err)
+	}
+
+	return nil
+}
+
+func LoadVersionDiffFromFile(filePath string) (*VersionDiff, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &VersionDiff{}
+	if err := json.Unmarshal(data, diff); err != nil {
+		return nil, fmt.Errorf("failed to parse VersionDiff: %v", err)
+	}
+	return diff, nil
+}
+
+// 以文本形式输出版本对比结果，不变的种子只计数
+func (d *VersionDiff) String() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s: %s (%s) -> %s (%s)\n", d.Contract, d.OldVersion, d.OldPath, d.NewVersion, d.NewPath))
+	if len(d.MethodsAdded) > 0 {
+		sb.WriteString(fmt.Sprintf("  methods added:   %s\n", strings.Join(d.MethodsAdded, ", ")))
+	}
+	if len(d.MethodsRemoved) > 0 {
+		sb.WriteString(fmt.Sprintf("  methods removed: %s\n", strings.Join(d.MethodsRemoved, ", ")))
+	}
+	for _, change := range d.ParamsChanged {
+		sb.WriteString(fmt.Sprintf("  params of %s: [%s] -> [%s]\n", change.Method, strings.Join(change.OldParams, ", "), strings.Join(change.NewParams, ", ")))
+	}
+	for _, change := range d.TypesChanged {
+		sb.WriteString(fmt.Sprintf("  types of %s: [%s] -> [%s]\n", change.Param, strings.Join(change.OldTypes, ", "), strings.Join(change.NewTypes, ", ")))
+	}
+
+	counts := make([]string, 0, len(VersionChanges))
+	for _, change := range VersionChanges {
+		counts = append(counts, fmt.Sprintf("%s %d", change, d.Counts[change]))
+	}
+	sb.WriteString(fmt.Sprintf("  seeds: %s\n", strings.Join(counts, ", ")))
+
+	for _, seed := range d.Seeds {
+		switch seed.Change {
+		case VersionUnchanged:
+			continue
+		case VersionSkipped:
+			reason := seed.Old.Skipped
+			if reason == "" {
+				reason = seed.New.Skipped
+			}
+			sb.WriteString(fmt.Sprintf("  %-11s %s (%s): %s\n", seed.Change, seed.PairID, seed.Functions, reason))
+		default:
+			sb.WriteString(fmt.Sprintf("  %-11s %s (%s): [%s] -> [%s]\n", seed.Change, seed.PairID, seed.Functions,
+				strings.Join(seed.Old.Signature, ", "), strings.Join(seed.New.Signature, ", ")))
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,128 @@
+package fuzz
+
+import (
+	"TransactionRwset/utils"
+	"reflect"
+	"testing"
+)
+
+func testSummary(path string, funcs map[string][]string, types map[string][]string) *utils.ContractInfoSummary {
+	summary := &utils.ContractInfoSummary{
+		ContractName:    "contract",
+		ContractPath:    path,
+		ContractFuncMap: make(map[string]*utils.FuncAndParamsNameInfo),
+		ParamTypes:      make(map[string]*utils.ParamTypesSummary),
+	}
+	for method, params := range funcs {
+		summary.ContractFuncMap[method] = &utils.FuncAndParamsNameInfo{InvokeName: method, ParamsNameList: params}
+	}
+	for param, candidates := range types {
+		summary.ParamTypes[param] = &utils.ParamTypesSummary{CandidateTypes: candidates}
+	}
+	return summary
+}
+
+func TestNewVersionDiff(t *testing.T) {
+	oldInfo := testSummary("v1/contract.go",
+		map[string][]string{"transfer": {"from", "to", "amount"}, "mint": {"to", "amount"}, "burn": {"amount"}},
+		map[string][]string{"amount": {"int"}, "to": {"string"}, "from": {"string"}})
+	newInfo := testSummary("v2/contract.go",
+		map[string][]string{"transfer": {"to", "amount"}, "mint": {"to", "amount"}, "approve": {"spender"}, "pause": nil},
+		map[string][]string{"amount": {"int", "string"}, "to": {"string"}, "spender": {"string"}})
+
+	diff := NewVersionDiff(oldInfo, newInfo)
+
+	if diff.Contract != "contract" || diff.OldPath != "v1/contract.go" || diff.NewPath != "v2/contract.go" {
+		t.Errorf("contract = %s (%s -> %s)", diff.Contract, diff.OldPath, diff.NewPath)
+	}
+	if want := []string{"approve", "pause"}; !reflect.DeepEqual(diff.MethodsAdded, want) {
+		t.Errorf("MethodsAdded = %v, want %v", diff.MethodsAdded, want)
+	}
+	if want := []string{"burn"}; !reflect.DeepEqual(diff.MethodsRemoved, want) {
+		t.Errorf("MethodsRemoved = %v, want %v", diff.MethodsRemoved, want)
+	}
+	wantParams := []*MethodChange{{Method: "transfer", OldParams: []string{"from", "to", "amount"}, NewParams: []string{"to", "amount"}}}
+	if !reflect.DeepEqual(diff.ParamsChanged, wantParams) {
+		t.Errorf("ParamsChanged = %+v, want %+v", diff.ParamsChanged, wantParams)
+	}
+	// 只在一个版本中出现的参数不算类型变化
+	wantTypes := []*TypeChange{{Param: "amount", OldTypes: []string{"int"}, NewTypes: []string{"int", "string"}}}
+	if !reflect.DeepEqual(diff.TypesChanged, wantTypes) {
+		t.Errorf("TypesChanged = %+v, want %+v", diff.TypesChanged, wantTypes)
+	}
+	if len(diff.Seeds) != 0 || diff.Regressions() != 0 {
+		t.Errorf("new diff has %d seeds and %d regressions", len(diff.Seeds), diff.Regressions())
+	}
+}
+
+func TestNewVersionDiffSameInterface(t *testing.T) {
+	info := testSummary("contract.go", map[string][]string{"transfer": {"to", "amount"}}, map[string][]string{"amount": {"int"}})
+	diff := NewVersionDiff(info, info)
+	if len(diff.MethodsAdded)+len(diff.MethodsRemoved)+len(diff.ParamsChanged)+len(diff.TypesChanged) != 0 {
+		t.Errorf("same interface reported changes: %+v", diff)
+	}
+}
+
+func testPair(one, two string, input int) *FuncPairSeed {
+	return &FuncPairSeed{
+		SeedOne: &FuncSeed{FunctionName: one, FunctionInput: map[string]interface{}{"amount": input}},
+		SeedTwo: &FuncSeed{FunctionName: two, FunctionInput: map[string]interface{}{"amount": input}},
+	}
+}
+
+func TestVersionDiffAddSeed(t *testing.T) {
+	conflict := []string{"RW:balance_{to}"}
+	other := []string{"WW:balance_{to}"}
+	run := func(signature []string) *VersionSeedRun {
+		return &VersionSeedRun{Signature: signature}
+	}
+
+	tests := []struct {
+		name     string
+		old, new *VersionSeedRun
+		want     VersionChange
+	}{
+		{"no conflict on either version", run([]string{}), run([]string{}), VersionUnchanged},
+		{"same conflict", run(conflict), run(conflict), VersionUnchanged},
+		{"conflict appeared", run([]string{}), run(conflict), VersionConflictAppeared},
+		{"conflict disappeared", run(conflict), run([]string{}), VersionConflictDisappeared},
+		{"conflict changed", run(conflict), run(other), VersionConflictChanged},
+		{"conflict gained a key", run(conflict), run(append(append([]string{}, conflict...), other...)), VersionConflictChanged},
+		{"skipped on the new version", run(conflict), &VersionSeedRun{Signature: []string{}, Skipped: "method mint not found"}, VersionSkipped},
+		{"skipped on the old version", &VersionSeedRun{Signature: []string{}, Skipped: "method approve not found"}, run(conflict), VersionSkipped},
+	}
+
+	diff := NewVersionDiff(testSummary("v1", nil, nil), testSummary("v2", nil, nil))
+	counts := make(map[VersionChange]int)
+	for i, tt := range tests {
+		pair := testPair("transfer", "mint", i)
+		seedDiff := diff.AddSeed(pair, tt.old, tt.new)
+		if seedDiff.Change != tt.want {
+			t.Errorf("%s: change = %s, want %s", tt.name, seedDiff.Change, tt.want)
+		}
+		if seedDiff.PairID != pair.ID() || seedDiff.Functions != "transfer × mint" || seedDiff.Old != tt.old || seedDiff.New != tt.new {
+			t.Errorf("%s: seed diff = %+v", tt.name, seedDiff)
+		}
+		counts[tt.want]++
+	}
+
+	if !reflect.DeepEqual(diff.Counts, counts) {
+		t.Errorf("Counts = %v, want %v", diff.Counts, counts)
+	}
+	if len(diff.Seeds) != len(tests) {
+		t.Errorf("Seeds = %d, want %d", len(diff.Seeds), len(tests))
+	}
+	// 新增与改变的冲突为回归，消失、跳过与不变的不算
+	if got, want := diff.Regressions(), counts[VersionConflictAppeared]+counts[VersionConflictChanged]; got != want || got != 3 {
+		t.Errorf("Regressions = %d, want %d", got, want)
+	}
+}
+
+func TestVersionDiffNoRegression(t *testing.T) {
+	diff := NewVersionDiff(testSummary("v1", nil, nil), testSummary("v2", nil, nil))
+	diff.AddSeed(testPair("transfer", "mint", 1), &VersionSeedRun{Signature: []string{"RW:balance_{to}"}}, &VersionSeedRun{Signature: []string{}})
+	diff.AddSeed(testPair("transfer", "burn", 2), &VersionSeedRun{Signature: []string{}}, &VersionSeedRun{Signature: []string{}, Skipped: "method burn not found"})
+	if n := diff.Regressions(); n != 0 {
+		t.Errorf("Regressions = %d, want 0 when conflicts only disappear or seeds are skipped", n)
+	}
+}
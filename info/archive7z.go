@@ -0,0 +1,124 @@
+/*
+	本文件主要用于：
+
+	1. 将编译得到的合约二进制打包为 7z 文件，替代 build.sh 中的 7z 命令
+
+	2. 只写出单个文件、使用 Copy（不压缩）编码，Docker-Go 合约部署时节点按 7z 格式解包即可读取
+*/
+
+package getContractStaticInfo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"os"
+	"unicode/utf16"
+)
+
+// 7z 文件头中用到的属性编号
+const (
+	k7zEnd              = 0x00
+	k7zHeader           = 0x01
+	k7zMainStreamsInfo  = 0x04
+	k7zFilesInfo        = 0x05
+	k7zPackInfo         = 0x06
+	k7zUnPackInfo       = 0x07
+	k7zSubStreamsInfo   = 0x08
+	k7zSize             = 0x09
+	k7zCRC              = 0x0A
+	k7zFolder           = 0x0B
+	k7zCodersUnPackSize = 0x0C
+	k7zName             = 0x11
+	k7zAttributes       = 0x15
+)
+
+// 文件属性的高 16 位为 unix 权限，与 p7zip 打包时一致，解包后合约二进制仍可执行
+const attributes7zUnixExecutable = 0x8000 | (0100755 << 16)
+
+var signature7z = []byte{'7', 'z', 0xBC, 0xAF, 0x27, 0x1C, 0, 4}
+
+// write7z 将 data 以文件名 name 写入 7z 文件 archivePath
+func write7z(archivePath, name string, data []byte) error {
+	header := &bytes.Buffer{}
+	header.WriteByte(k7zHeader)
+
+	header.WriteByte(k7zMainStreamsInfo)
+	header.WriteByte(k7zPackInfo)
+	write7zNumber(header, 0) // PackPos
+	write7zNumber(header, 1) // NumPackStreams
+	header.WriteByte(k7zSize)
+	write7zNumber(header, uint64(len(data)))
+	header.WriteByte(k7zEnd)
+
+	header.WriteByte(k7zUnPackInfo)
+	header.WriteByte(k7zFolder)
+	write7zNumber(header, 1) // NumFolders
+	header.WriteByte(0)      // External
+	write7zNumber(header, 1) // NumCoders
+	header.WriteByte(0x01)   // 简单编码器，编码器 ID 长度为 1
+	header.WriteByte(0x00)   // Copy
+	header.WriteByte(k7zCodersUnPackSize)
+	write7zNumber(header, uint64(len(data)))
+	header.WriteByte(k7zEnd)
+
+	header.WriteByte(k7zSubStreamsInfo)
+	header.WriteByte(k7zCRC)
+	header.WriteByte(1) // AllAreDefined
+	binary.Write(header, binary.LittleEndian, crc32.ChecksumIEEE(data))
+	header.WriteByte(k7zEnd)
+	header.WriteByte(k7zEnd)
+
+	header.WriteByte(k7zFilesInfo)
+	write7zNumber(header, 1) // NumFiles
+	nameChars := utf16.Encode([]rune(name))
+	header.WriteByte(k7zName)
+	write7zNumber(header, uint64(1+2*len(nameChars)+2))
+	header.WriteByte(0) // External
+	for _, c := range nameChars {
+		binary.Write(header, binary.LittleEndian, c)
+	}
+	binary.Write(header, binary.LittleEndian, uint16(0))
+	header.WriteByte(k7zAttributes)
+	write7zNumber(header, 1+1+4)
+	header.WriteByte(1) // AllAreDefined
+	header.WriteByte(0) // External
+	binary.Write(header, binary.LittleEndian, uint32(attributes7zUnixExecutable))
+	header.WriteByte(k7zEnd)
+
+	header.WriteByte(k7zEnd)
+
+	// 起始头：下一个头的偏移、长度与校验和
+	startHeader := &bytes.Buffer{}
+	binary.Write(startHeader, binary.LittleEndian, uint64(len(data)))
+	binary.Write(startHeader, binary.LittleEndian, uint64(header.Len()))
+	binary.Write(startHeader, binary.LittleEndian, crc32.ChecksumIEEE(header.Bytes()))
+
+	out := &bytes.Buffer{}
+	out.Write(signature7z)
+	binary.Write(out, binary.LittleEndian, crc32.ChecksumIEEE(startHeader.Bytes()))
+	out.Write(startHeader.Bytes())
+	out.Write(data)
+	out.Write(header.Bytes())
+	return os.WriteFile(archivePath, out.Bytes(), 0644)
+}
+
+// 7z 的变长整数编码：首字节高位的 1 的个数表示后续字节数
+func write7zNumber(buf *bytes.Buffer, value uint64) {
+	first := byte(0)
+	mask := byte(0x80)
+	i := 0
+	for ; i < 8; i++ {
+		if value < uint64(1)<<(7*(i+1)) {
+			first |= byte(value >> (8 * i))
+			break
+		}
+		first |= mask
+		mask >>= 1
+	}
+	buf.WriteByte(first)
+	for ; i > 0; i-- {
+		buf.WriteByte(byte(value))
+		value >>= 8
+	}
+}
@@ -0,0 +1,146 @@
+package getContractStaticInfo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestWrite7zNumber(t *testing.T) {
+	tests := []struct {
+		value uint64
+		want  []byte
+	}{
+		{0, []byte{0x00}},
+		{0x7f, []byte{0x7f}},
+		{0x80, []byte{0x80, 0x80}},
+		{0xff, []byte{0x80, 0xff}},
+		{0x100, []byte{0x81, 0x00}},
+		{0x3fff, []byte{0xbf, 0xff}},
+		{0x4000, []byte{0xc0, 0x00, 0x40}},
+		{0x1fffff, []byte{0xdf, 0xff, 0xff}},
+		{0x200000, []byte{0xe0, 0x00, 0x00, 0x20}},
+		{0xfffffff, []byte{0xef, 0xff, 0xff, 0xff}},
+		{0x10000000, []byte{0xf0, 0x00, 0x00, 0x00, 0x10}},
+		{1<<56 - 1, []byte{0xfe, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+		{1 << 56, []byte{0xff, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01}},
+		{math.MaxUint64, []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+	}
+
+	for _, tt := range tests {
+		buf := &bytes.Buffer{}
+		write7zNumber(buf, tt.value)
+		if !bytes.Equal(buf.Bytes(), tt.want) {
+			t.Errorf("write7zNumber(%#x) = % x, want % x", tt.value, buf.Bytes(), tt.want)
+		}
+		if got, n := read7zNumber(buf.Bytes()); got != tt.value || n != len(tt.want) {
+			t.Errorf("read7zNumber(% x) = %#x (%d bytes), want %#x (%d bytes)", tt.want, got, n, tt.value, len(tt.want))
+		}
+	}
+}
+
+// 按 7-Zip 的 ReadNumber 解码，返回取值与占用的字节数
+func read7zNumber(b []byte) (uint64, int) {
+	first := b[0]
+	mask := byte(0x80)
+	var value uint64
+	for i := 0; i < 8; i++ {
+		if first&mask == 0 {
+			high := uint64(first & (mask - 1))
+			return value | high<<(8*i), i + 1
+		}
+		value |= uint64(b[i+1]) << (8 * i)
+		mask >>= 1
+	}
+	return value, 9
+}
+
+func TestWrite7zLayout(t *testing.T) {
+	data := []byte("#!/bin/sh\necho contract\n")
+	archivePath := filepath.Join(t.TempDir(), "contract.7z")
+	if err := write7z(archivePath, "contract", data); err != nil {
+		t.Fatal(err)
+	}
+	archive, err := os.ReadFile(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.HasPrefix(archive, signature7z) {
+		t.Fatalf("signature = % x, want % x", archive[:len(signature7z)], signature7z)
+	}
+	startHeader := archive[12:32]
+	if got, want := binary.LittleEndian.Uint32(archive[8:12]), crc32.ChecksumIEEE(startHeader); got != want {
+		t.Errorf("start header CRC = %#x, want %#x", got, want)
+	}
+
+	nextHeaderOffset := binary.LittleEndian.Uint64(startHeader[0:8])
+	nextHeaderSize := binary.LittleEndian.Uint64(startHeader[8:16])
+	nextHeaderCRC := binary.LittleEndian.Uint32(startHeader[16:20])
+	if nextHeaderOffset != uint64(len(data)) {
+		t.Errorf("next header offset = %d, want %d", nextHeaderOffset, len(data))
+	}
+	if got := 32 + nextHeaderOffset + nextHeaderSize; got != uint64(len(archive)) {
+		t.Fatalf("archive size = %d, header ends at %d", len(archive), got)
+	}
+	if !bytes.Equal(archive[32:32+len(data)], data) {
+		t.Errorf("packed stream = %q, want %q", archive[32:32+len(data)], data)
+	}
+
+	header := archive[32+nextHeaderOffset:]
+	if got := crc32.ChecksumIEEE(header); got != nextHeaderCRC {
+		t.Errorf("header CRC = %#x, want %#x", got, nextHeaderCRC)
+	}
+	if header[0] != k7zHeader || header[len(header)-1] != k7zEnd {
+		t.Errorf("header = % x, want to start with %#x and end with %#x", header, k7zHeader, k7zEnd)
+	}
+
+	dataCRC := make([]byte, 4)
+	binary.LittleEndian.PutUint32(dataCRC, crc32.ChecksumIEEE(data))
+	if !bytes.Contains(header, append([]byte{k7zCRC, 1}, dataCRC...)) {
+		t.Errorf("header does not contain the CRC %x of the packed stream", dataCRC)
+	}
+}
+
+// 有 7z 或 bsdtar 时，用真实的解包工具打开生成的文件
+func TestWrite7zExtract(t *testing.T) {
+	dir := t.TempDir()
+	data := bytes.Repeat([]byte("contract binary\x00\x01\x02"), 1024)
+	archivePath := filepath.Join(dir, "contract.7z")
+	if err := write7z(archivePath, "contract", data); err != nil {
+		t.Fatal(err)
+	}
+
+	outDir := filepath.Join(dir, "out")
+	if err := os.Mkdir(outDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	var cmd *exec.Cmd
+	if path, err := exec.LookPath("7z"); err == nil {
+		cmd = exec.Command(path, "x", "-o"+outDir, archivePath)
+	} else if path, err := exec.LookPath("bsdtar"); err == nil {
+		cmd = exec.Command(path, "-xf", archivePath, "-C", outDir)
+	} else {
+		t.Skip("neither 7z nor bsdtar found")
+	}
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("%s: %v\n%s", cmd, err, output)
+	}
+
+	extracted := filepath.Join(outDir, "contract")
+	got, err := os.ReadFile(extracted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("extracted %d bytes differ from the %d packed bytes", len(got), len(data))
+	}
+	if info, err := os.Stat(extracted); err == nil && info.Mode().Perm()&0100 == 0 {
+		t.Errorf("extracted file mode = %v, want executable", info.Mode())
+	}
+}
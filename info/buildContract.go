@@ -0,0 +1,190 @@
+/*
+	本文件主要用于：
+
+	1. 在进程内编译 Docker-Go 合约：以与 build.sh 相同的参数执行 go build，并将二进制打包为 7z，
+	   不再依赖合约目录下的 build.sh 与系统中的 7z 命令
+
+	2. 按合约模块源码、go.mod、go.sum、目标架构与 Go 版本计算哈希，缓存编译产物，
+	   源码未变化时直接复用，避免每次运行都重新编译
+
+	3. 编译失败时返回包含编译器输出的 BuildError
+*/
+
+package getContractStaticInfo
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// 编译产物缓存目录，为空时不使用缓存
+var BuildCacheDir = "./build_cache"
+
+// 合约二进制的目标架构
+var ContractArch = "amd64"
+
+// 合约编译失败的错误，Output 为编译器输出
+type BuildError struct {
+	Contract string
+	Dir      string
+	Output   string
+	Err      error
+}
+
+func (e *BuildError) Error() string {
+	msg := fmt.Sprintf("failed to build contract %s in %s: %v", e.Contract, e.Dir, e.Err)
+	if output := strings.TrimSpace(e.Output); output != "" {
+		msg += "\n" + output
+	}
+	return msg
+}
+
+func (e *BuildError) Unwrap() error {
+	return e.Err
+}
+
+// BuildContract 编译 contractDir 下的合约并打包为 7z，返回 7z 文件路径；
+// 源码与依赖未变化时直接返回缓存的产物
+func BuildContract(contractDir, contractName string) (string, error) {
+	buildErr := func(output string, err error) error {
+		return &BuildError{Contract: contractName, Dir: contractDir, Output: output, Err: err}
+	}
+
+	key, err := buildCacheKey(contractDir)
+	if err != nil {
+		return "", buildErr("", err)
+	}
+
+	archivePath := filepath.Join(contractDir, contractName+".7z")
+	if BuildCacheDir != "" {
+		archivePath = filepath.Join(BuildCacheDir, key, contractName+".7z")
+		if _, err := os.Stat(archivePath); err == nil {
+			fmt.Printf("使用缓存的合约编译产物：%s\n", archivePath)
+			return archivePath, nil
+		}
+		if err := os.MkdirAll(filepath.Dir(archivePath), os.ModePerm); err != nil {
+			return "", buildErr("", err)
+		}
+	}
+
+	tmpDir, err := os.MkdirTemp("", "contract_build")
+	if err != nil {
+		return "", buildErr("", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	binPath := filepath.Join(tmpDir, contractName)
+	cmd := exec.Command("go", buildArgs(binPath)...)
+	cmd.Dir = contractDir
+	cmd.Env = append(os.Environ(), "GOOS=linux", "GOARCH="+ContractArch)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", buildErr(string(output), err)
+	}
+
+	data, err := os.ReadFile(binPath)
+	if err != nil {
+		return "", buildErr(string(output), err)
+	}
+	// 先写入临时文件再改名，避免中断或多个 worker 同时编译时留下不完整的缓存
+	tmpArchive := fmt.Sprintf("%s.%d.tmp", archivePath, os.Getpid())
+	if err := write7z(tmpArchive, contractName, data); err != nil {
+		return "", buildErr("", err)
+	}
+	if err := os.Rename(tmpArchive, archivePath); err != nil {
+		os.Remove(tmpArchive)
+		return "", buildErr("", err)
+	}
+	return archivePath, nil
+}
+
+// 与 build.sh 相同：Linux 下启用 crypto 标签，去除符号表与调试信息
+func buildArgs(output string) []string {
+	args := []string{"build"}
+	if runtime.GOOS == "linux" {
+		args = append(args, "-tags", "crypto")
+	}
+	return append(args, "-ldflags=-s -w", "-o", output)
+}
+
+// 计算编译产物的缓存键：合约所在模块的 Go 源码、go.mod、go.sum，以及目标架构、编译参数与 Go 版本
+func buildCacheKey(contractDir string) (string, error) {
+	moduleDir := findModuleDir(contractDir)
+
+	var files []string
+	err := filepath.WalkDir(moduleDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name := d.Name()
+		if d.IsDir() {
+			// 跳过隐藏目录与 testdata；子目录中的其他模块不参与本模块的编译
+			if path != moduleDir && (strings.HasPrefix(name, ".") || name == "testdata") {
+				return filepath.SkipDir
+			}
+			if path != moduleDir && fileExists(filepath.Join(path, "go.mod")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if name == "go.mod" || name == "go.sum" || (strings.HasSuffix(name, ".go") && !strings.HasSuffix(name, "_test.go")) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	sort.Strings(files)
+
+	h := sha256.New()
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return "", err
+		}
+		rel, _ := filepath.Rel(moduleDir, file)
+		fmt.Fprintf(h, "%s\x00%d\x00", filepath.ToSlash(rel), len(data))
+		h.Write(data)
+	}
+	// 合约目录在模块中的位置决定编译的包
+	rel, _ := filepath.Rel(moduleDir, contractDir)
+	fmt.Fprintf(h, "dir=%s\x00arch=%s\x00args=%s\x00go=%s\x00", filepath.ToSlash(rel), ContractArch, strings.Join(buildArgs(""), " "), goVersion())
+	return hex.EncodeToString(h.Sum(nil))[:16], nil
+}
+
+// 向上查找 go.mod 所在目录，找不到时使用合约目录本身
+func findModuleDir(dir string) string {
+	for d := dir; ; {
+		if fileExists(filepath.Join(d, "go.mod")) {
+			return d
+		}
+		parent := filepath.Dir(d)
+		if parent == d {
+			return dir
+		}
+		d = parent
+	}
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// 编译所用 go 命令的版本，不同版本的编译产物不共用缓存
+func goVersion() string {
+	output, err := exec.Command("go", "env", "GOVERSION").Output()
+	if err != nil {
+		return "unknown"
+	}
+	return strings.TrimSpace(string(output))
+}
@@ -1,13 +1,13 @@
 package getContractStaticInfo
 
 import (
+	"TransactionRwset/evm"
 	"TransactionRwset/utils"
+	"TransactionRwset/wasm"
 	"fmt"
 	"go/parser"
 	"go/token"
 	"log"
-	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 )
@@ -20,6 +20,14 @@ func MakeGlobalContractInfo(path string) *utils.ContractInfo {
 		return nil
 	}
 
+	// EVM 合约由 ABI 获取合约信息，WASM 合约由模块或接口描述获取合约信息
+	switch filepath.Ext(path) {
+	case evm.ABIExt:
+		return makeEVMContractInfo(path)
+	case wasm.WASMExt:
+		return makeWASMContractInfo(path)
+	}
+
 	node, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
 	if err != nil {
 		log.Println(err)
@@ -31,13 +39,18 @@ func MakeGlobalContractInfo(path string) *utils.ContractInfo {
 		Node: node,
 		// 1. 获取合约路径
 		ContractPath: path,
+		Runtime:      utils.RuntimeDockerGo,
 	}
 
 	// 2. 获取合约目录和合约名
 	generateContractDirAndName(info)
 
 	// 3. 获取合约二进制文件路径
-	info.ContractByteCodePath = buildContract(info.ContractDir, info.ContractName)
+	info.ContractByteCodePath, err = BuildContract(info.ContractDir, info.ContractName)
+	if err != nil {
+		fmt.Println("编译合约出错：", err)
+		return nil
+	}
 
 	// 4. 获取待测合约func和param相关信息
 	generateContractFuncMap(info)
@@ -113,28 +126,3 @@ func generateContractFuncMap(info *utils.ContractInfo) {
 func getParamCandidateTypeBySSA(info *utils.ContractInfo) {
 	info.ParamAndCandidateTypes = GetParamCandidateTypeBySSA(info.ContractPath)
 }
-
-/*
-*********************************
-
-*	编译合约
-*	获取：
-* 	1. 合约二进制文件路径
-
-*********************************
- */
-func buildContract(ContractDir string, ContractName string) string {
-	scriptDir := ContractDir
-
-	cmdStart := exec.Command("./build.sh", ContractName)
-	cmdStart.Dir = scriptDir // 设置启动脚本的工作目录
-	cmdStart.Stdout = os.Stdout
-	cmdStart.Stderr = os.Stderr
-	err := cmdStart.Run()
-	if err != nil {
-		fmt.Println("编译合约出错：", err)
-		return ""
-	}
-
-	return ContractDir + "/" + ContractName + ".7z"
-}
@@ -0,0 +1,91 @@
+/*
+	本文件主要用于：
+
+	1. 由 EVM 合约的 ABI 获取合约信息，替代 Go 合约的 AST 与 SSA 分析
+
+	2. ABI 给出了参数的确定类型，参数直接以该类型的初始值确认，不再进行类型试探
+*/
+
+package getContractStaticInfo
+
+import (
+	"TransactionRwset/evm"
+	"TransactionRwset/utils"
+	"fmt"
+	"go/types"
+	"path/filepath"
+	"strings"
+)
+
+func makeEVMContractInfo(path string) *utils.ContractInfo {
+	contract, err := evm.Load(path)
+	if err != nil {
+		fmt.Println("读取 EVM 合约出错：", err)
+		return nil
+	}
+
+	info := &utils.ContractInfo{
+		ContractPath:         path,
+		ContractDir:          filepath.Dir(path),
+		ContractName:         strings.TrimSuffix(filepath.Base(path), evm.ABIExt),
+		ContractByteCodePath: contract.ByteCodePath,
+		Runtime:              utils.RuntimeEVM,
+		EVM:                  contract,
+	}
+
+	generateEVMContractFuncMap(info)
+
+	return info
+}
+
+/*
+*********************************
+
+*	由 ABI 获取：
+* 	1. 合约方法名，调用名为方法选择器
+*	2. 各方法的参数名列表
+*	3. 各参数的类型，参数直接确认为该类型的初始值
+
+*********************************
+ */
+func generateEVMContractFuncMap(info *utils.ContractInfo) {
+	info.ContractFuncMap = make(map[string]*utils.FuncAndParamsNameInfo)
+	info.ParamAndCandidateTypes = make(map[string]*utils.CandidateTypes)
+
+	for _, method := range info.EVM.SortedMethods() {
+		info.ContractFuncMap[method.Name] = &utils.FuncAndParamsNameInfo{
+			InvokeName:     method.Selector,
+			Product:        -1,
+			ParamsNameList: method.ParamNames(),
+		}
+
+		for _, param := range method.Params {
+			goType, _ := evm.GoType(param.Type)
+			value := evm.DefaultValue(param.Type)
+
+			candidateTypes, ok := info.ParamAndCandidateTypes[param.Name]
+			if !ok {
+				info.ParamAndCandidateTypes[param.Name] = &utils.CandidateTypes{
+					Types:        map[types.Type]interface{}{goType: value},
+					Confirm:      true,
+					ConfirmValue: []interface{}{value},
+				}
+				continue
+			}
+
+			// 不同方法的同名参数类型不同时，仅记录该类型，编码时按各方法的类型转换
+			if !hasTypeName(candidateTypes, goType) {
+				candidateTypes.Types[goType] = value
+			}
+		}
+	}
+}
+
+func hasTypeName(candidateTypes *utils.CandidateTypes, t types.Type) bool {
+	for candidate := range candidateTypes.Types {
+		if candidate.String() == t.String() {
+			return true
+		}
+	}
+	return false
+}
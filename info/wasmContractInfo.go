@@ -0,0 +1,81 @@
+/*
+	本文件主要用于：
+
+	1. 由 WASM 合约模块或其接口描述获取合约信息，替代 Go 合约的 AST 与 SSA 分析
+
+	2. 合约不经编译，字节码即模块文件；调用名即导出函数名
+*/
+
+package getContractStaticInfo
+
+import (
+	"TransactionRwset/utils"
+	"TransactionRwset/wasm"
+	"fmt"
+	"go/types"
+	"path/filepath"
+	"strings"
+)
+
+func makeWASMContractInfo(path string) *utils.ContractInfo {
+	contract, err := wasm.Load(path)
+	if err != nil {
+		fmt.Println("读取 WASM 合约出错：", err)
+		return nil
+	}
+
+	info := &utils.ContractInfo{
+		ContractPath:         path,
+		ContractDir:          filepath.Dir(path),
+		ContractName:         strings.TrimSuffix(filepath.Base(path), wasm.WASMExt),
+		ContractByteCodePath: path,
+		Runtime:              contract.Runtime.String(),
+		WASM:                 contract,
+	}
+
+	generateWASMContractFuncMap(info)
+
+	return info
+}
+
+/*
+*********************************
+
+*	由模块或接口描述获取：
+* 	1. 合约方法名，调用名即方法名
+*	2. 各方法的参数名列表
+*	3. 各参数的类型，参数直接确认为该类型的初始值
+
+*********************************
+ */
+func generateWASMContractFuncMap(info *utils.ContractInfo) {
+	info.ContractFuncMap = make(map[string]*utils.FuncAndParamsNameInfo)
+	info.ParamAndCandidateTypes = make(map[string]*utils.CandidateTypes)
+
+	for _, method := range info.WASM.SortedMethods() {
+		info.ContractFuncMap[method.Name] = &utils.FuncAndParamsNameInfo{
+			InvokeName:     method.Name,
+			Product:        -1,
+			ParamsNameList: method.ParamNames(),
+		}
+
+		for _, param := range method.Params {
+			goType, value := param.GoType()
+
+			candidateTypes, ok := info.ParamAndCandidateTypes[param.Name]
+			if !ok {
+				info.ParamAndCandidateTypes[param.Name] = &utils.CandidateTypes{
+					Types:        map[types.Type]interface{}{goType: value},
+					Confirm:      true,
+					ConfirmValue: []interface{}{value},
+				}
+				continue
+			}
+
+			// 不同方法的同名参数类型不同时，仅记录该类型
+			if !hasTypeName(candidateTypes, goType) {
+				candidateTypes.Types[goType] = value
+			}
+		}
+	}
+}
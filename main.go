@@ -3,7 +3,10 @@ package main
 import (
 	"TransactionRwset/engine"
 	"TransactionRwset/fuzz"
+	getfuncInfo "TransactionRwset/info"
 	nodecontrol "TransactionRwset/nodeControl"
+	"TransactionRwset/report"
+	"TransactionRwset/utils"
 	"flag"
 	"fmt"
 	"os"
@@ -26,6 +29,9 @@ var contractPath []string = []string{
 	"/home/ChainMaker/transaction_rwset/contract/contracts-go/vote/vote.go",   // 输入参数为结构体，无法自动构造
 }
 
+// 默认待测合约
+const defaultContractPath = "/data/wxj/transaction_rwset_fuzz/contract/contracts-go/raffle/raffle.go"
+
 func main() {
 	// 创建一个通道来接收信号
 	signalChan := make(chan os.Signal, 1)
@@ -45,12 +51,111 @@ func main() {
 		os.Exit(0)
 	}()
 
+	// 后台 goroutine panic 时同样停止集群
+	utils.OnPanic = engine.Stop
+
+	// panic 时同样停止集群，再继续抛出
+	defer func() {
+		if r := recover(); r != nil {
+			engine.Stop()
+			panic(r)
+		}
+	}()
+
+	// replay 子命令：重放种子池中的单个种子
+	if len(os.Args) > 1 && os.Args[1] == "replay" {
+		os.Exit(replayCommand(os.Args[2:]))
+	}
+
+	// matrix 子命令：在多组节点配置上进行相同的冲突实验
+	if len(os.Args) > 1 && os.Args[1] == "matrix" {
+		os.Exit(matrixCommand(os.Args[2:]))
+	}
+
+	// worker 子命令：作为分布式 worker 通过共享目录与其他 worker 同步种子
+	if len(os.Args) > 1 && os.Args[1] == "worker" {
+		os.Exit(workerCommand(os.Args[2:]))
+	}
+
+	// distribute 子命令：在本机启动多个 worker
+	if len(os.Args) > 1 && os.Args[1] == "distribute" {
+		os.Exit(distributeCommand(os.Args[2:]))
+	}
+
+	// envelope 子命令：修改交易信封，观察节点各环节的校验结果
+	if len(os.Args) > 1 && os.Args[1] == "envelope" {
+		os.Exit(envelopeCommand(os.Args[2:]))
+	}
+
+	// upgrade 子命令：将合约升级为新版本，比较两个版本上语料的冲突
+	if len(os.Args) > 1 && os.Args[1] == "upgrade" {
+		os.Exit(upgradeCommand(os.Args[2:]))
+	}
+
+	contract := flag.String("contract", defaultContractPath, "Path to the contract source (.go), the EVM contract ABI (.abi, with .bin and optional _storage.json next to it) or the WASM module (.wasm, with optional _interface.json next to it)")
 	pairSeedsfilePath := flag.String("load", "", "Path to JSON file to load the pair seeds pool")
+	sampleInterval := flag.Duration("sample-interval", fuzz.SampleInterval, "Interval of tx pool and node state sampling during experiments (0 disables sampling)")
+	reportDir := flag.String("report", "", "Generate an HTML report from an existing result directory and exit")
+	seed := flag.Int64("seed", 0, "Master random seed of the campaign (0 picks one from the current time)")
+	replayDir := flag.String("replay", "", "Replay the campaign recorded in a result directory with its seed and check the random decisions")
+	mutatorWeights := flag.String("mutator-weights", "", "Comma separated mutator weights, e.g. str_hex=5,bytes_havoc=0 (0 disables a mutator)")
+	flag.DurationVar(&fuzz.Schedule.TimeBudget, "time-budget", fuzz.Schedule.TimeBudget, "Global time budget of the mutation phase (0 means unlimited)")
+	flag.IntVar(&fuzz.Schedule.PlateauRounds, "plateau-rounds", fuzz.Schedule.PlateauRounds, "Retire a mutable pair after this many scheduled rounds without similarity improvement (0 disables)")
+	flag.IntVar(&fuzz.Schedule.MaxExecsPerPair, "max-execs-per-pair", fuzz.Schedule.MaxExecsPerPair, "Maximum mutation executions per pair (0 means unlimited)")
+	flag.IntVar(&fuzz.Schedule.BaseEnergy, "base-energy", fuzz.Schedule.BaseEnergy, "Base number of mutations a pair gets per round before energy scaling")
+	registerClusterFlags(flag.CommandLine)
+	registerBuildFlags(flag.CommandLine)
+	flag.BoolVar(&engine.SnapshotLedger, "snapshot-ledger", engine.SnapshotLedger, "Snapshot every node's ledger after deployment and restore it before each conflict experiment")
+	flag.IntVar(&fuzz.MaxMinimizeExecs, "minimize-execs", fuzz.MaxMinimizeExecs, "Maximum transactions executed when minimizing a conflict seed (0 disables minimization)")
+	flag.Uint64Var(&nodecontrol.InvokeGasLimit, "invoke-gas-limit", nodecontrol.InvokeGasLimit, "Gas limit attached to every contract invocation")
+	flag.Uint64Var(&nodecontrol.DeployGasLimit, "deploy-gas-limit", nodecontrol.DeployGasLimit, "Gas limit attached to the contract deployment")
+	flag.IntVar(&fuzz.GasSearchExecs, "gas-execs", fuzz.GasSearchExecs, "Maximum transactions per function when searching for gas-heavy inputs (0 disables the search)")
+	flag.Float64Var(&fuzz.TimeoutProneRate, "timeout-prone-rate", fuzz.TimeoutProneRate, "Exclude a function from probing, mutation and conflict experiments once this share of its probes time out (0 disables)")
+	flag.IntVar(&fuzz.TimeoutProneMinSamples, "timeout-prone-min-samples", fuzz.TimeoutProneMinSamples, "Minimum executions of a function before its timeout share can mark it timeout-prone")
+	logLevel := flag.String("log-level", utils.DefaultLogLevel.String(), "Minimum level of events written to the event log (debug, info, warn, error)")
 	flag.Parse()
 
+	fuzz.SampleInterval = *sampleInterval
+
+	level, err := utils.ParseLogLevel(*logLevel)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	utils.DefaultLogLevel = level
+
+	if err := utils.SetMutatorWeights(*mutatorWeights); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	if *replayDir != "" {
+		if err := utils.PrepareReplay(*replayDir); err != nil {
+			fmt.Printf("Error preparing replay: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Replaying %s with seed %d\n", *replayDir, utils.MasterSeed)
+	} else if *seed != 0 {
+		utils.SetSeed(*seed)
+	}
+
+	if *reportDir != "" {
+		// 仅根据已有结果目录生成报告，不启动节点
+		reportPath, err := report.Generate(*reportDir)
+		if err != nil {
+			fmt.Printf("Error generating report: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Report generated: %s\n", reportPath)
+		return
+	}
+
 	var pool *fuzz.FuncPairSeedsPool
-	var err error
-	engine.Start("/data/wxj/transaction_rwset_fuzz/contract/contracts-go/raffle/raffle.go")
+	if err := engine.Start(*contract); err != nil {
+		fmt.Println(err)
+		engine.Stop()
+		os.Exit(1)
+	}
 
 	if *pairSeedsfilePath != "" {
 		// 从 JSON 文件加载种子池
@@ -58,13 +163,44 @@ func main() {
 		pool, err = fuzz.LoadPairSeedPoolFromFile(*pairSeedsfilePath)
 		if err != nil {
 			fmt.Printf("Error loading seed pool: %v\n", err)
+			engine.Stop()
 			os.Exit(1)
 		}
+
+		// 在本次结果目录中保留一份种子池，以便生成报告
+		if err := pool.SaveToFile(utils.Log.BaseDir); err != nil {
+			fmt.Println(err)
+		}
 	} else {
 		pool = engine.GenerateSeeds()
 	}
 
 	engine.HandleFuncPairSeedsPool(pool)
-	nodecontrol.ChainmakerController.StopChainmaker()
+	engine.Stop()
+
+	reportPath, err := report.Generate(utils.Log.BaseDir)
+	if err != nil {
+		fmt.Printf("Error generating report: %v\n", err)
+	} else {
+		fmt.Printf("Report generated: %s\n", reportPath)
+	}
+
+}
+
+// 本地集群与认证模式相关参数，主命令与各子命令共用
+func registerClusterFlags(flags *flag.FlagSet) {
+	options := nodecontrol.ClusterOptions
+	flags.StringVar(&options.ReleaseDir, "release-dir", options.ReleaseDir, "Directory of chainmaker release packages, one node per subdirectory")
+	flags.StringVar(&options.TemplateDir, "cluster-template", options.TemplateDir, "Config template directory with node1..nodeN subdirectories copied over each node's config (empty keeps the packaged config)")
+	flags.IntVar(&options.Nodes, "nodes", options.Nodes, "Number of nodes to start (0 starts every node in the release dir)")
+	flags.DurationVar(&options.ReadyTimeout, "ready-timeout", options.ReadyTimeout, "Maximum time to wait for the cluster to become ready and for blocks to be committed")
+	flags.BoolVar(&options.KeepData, "keep-data", options.KeepData, "Keep node data and logs in the release dir after stopping the cluster")
+	flags.Func("node-weights", "Spread experiment txs over the nodes by weight, e.g. node1=3,node2=1 (all gives every node the same weight; empty sends through the SDK config only)", nodecontrol.SetNodeWeights)
+	flags.Func("auth-type", "Auth mode of the chain: cert (default), pwk (permissionedWithKey) or public; key modes convert the prepare.sh node configs with the keys in config-pk", nodecontrol.SetAuthType)
+}
 
+// Docker-Go 合约编译相关参数，主命令与各子命令共用
+func registerBuildFlags(flags *flag.FlagSet) {
+	flags.StringVar(&getfuncInfo.BuildCacheDir, "build-cache", getfuncInfo.BuildCacheDir, "Directory caching compiled contracts by a hash of the module sources and go.sum (empty rebuilds every run)")
+	flags.StringVar(&getfuncInfo.ContractArch, "contract-arch", getfuncInfo.ContractArch, "GOARCH of the compiled Docker-Go contract")
 }
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"TransactionRwset/engine"
+	"TransactionRwset/fuzz"
+	nodecontrol "TransactionRwset/nodeControl"
+	"TransactionRwset/report"
+	"TransactionRwset/utils"
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// matrix 子命令，返回进程退出码
+// 用法: matrix -dims "consensus=TBFT,RAFT;block_tx_capacity=10,100" [-load <func_pair_seeds_pool.json>] [-max-seeds N]
+func matrixCommand(args []string) int {
+	flags := flag.NewFlagSet("matrix", flag.ExitOnError)
+	opts := &engine.MatrixOptions{}
+
+	settings := make([]string, 0, len(nodecontrol.ConfigSettings))
+	for _, setting := range nodecontrol.ConfigSettings {
+		settings = append(settings, setting.Name)
+	}
+	dims := flags.String("dims", "", "Config matrix, e.g. consensus=TBFT,RAFT;block_tx_capacity=10,100 (settings: "+strings.Join(settings, ", ")+")")
+	flags.StringVar(&opts.BaseConfigDir, "base-config", utils.ChainMakerConfigDir, "Node configs generated by prepare.sh, one node1..nodeN subdirectory per node, rewritten for every combination")
+	flags.IntVar(&opts.MaxSeeds, "max-seeds", 0, "Number of conflict seeds run on every combination (0 runs all)")
+	poolFile := flags.String("load", "", "Path to JSON file to load the pair seeds pool (empty generates it on the first combination)")
+	contractPath := flags.String("contract", defaultContractPath, "Path to the contract source (.go), EVM ABI (.abi) or WASM module (.wasm)")
+	flags.DurationVar(&fuzz.SampleInterval, "sample-interval", fuzz.SampleInterval, "Interval of tx pool and node state sampling during experiments (0 disables sampling)")
+	registerClusterFlags(flags)
+	registerBuildFlags(flags)
+	logLevel := flags.String("log-level", utils.DefaultLogLevel.String(), "Minimum level of events written to the event log (debug, info, warn, error)")
+	flags.Parse(args)
+
+	var err error
+	opts.Dimensions, err = engine.ParseMatrixSpec(*dims)
+	if err != nil {
+		fmt.Println("matrix:", err)
+		flags.Usage()
+		return 2
+	}
+
+	level, err := utils.ParseLogLevel(*logLevel)
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	utils.DefaultLogLevel = level
+
+	var pool *fuzz.FuncPairSeedsPool
+	if *poolFile != "" {
+		pool, err = fuzz.LoadPairSeedPoolFromFile(*poolFile)
+		if err != nil {
+			fmt.Printf("Error loading seed pool: %v\n", err)
+			return 1
+		}
+	}
+
+	result, err := engine.RunMatrix(*contractPath, pool, opts)
+	if err != nil {
+		fmt.Printf("Error running matrix: %v\n", err)
+	}
+	if result != nil {
+		fmt.Print(result.String())
+	}
+	engine.Stop()
+
+	if utils.Log != nil {
+		reportPath, err := report.Generate(utils.Log.BaseDir)
+		if err != nil {
+			fmt.Printf("Error generating report: %v\n", err)
+		} else {
+			fmt.Printf("Report generated: %s\n", reportPath)
+		}
+	}
+
+	if err != nil {
+		return 1
+	}
+	return 0
+}
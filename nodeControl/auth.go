@@ -0,0 +1,424 @@
+/*
+	本文件主要用于：
+
+	1. 选择链的认证模式：PermissionedWithCert（cert）、PermissionedWithKey（pwk）与 Public（public），
+	   每种模式使用各自的 SDK 配置，公钥模式的用户与节点密钥来自 config-pk 目录
+
+	2. 以 prepare.sh 生成的证书模式节点配置为基础，按认证模式改写 chainmaker.yml 与 chainconfig/bc1.yml：
+	   节点私钥与节点 ID、共识节点列表、信任根改为 config-pk 中的密钥与管理员公钥，并关闭 RPC TLS，
+	   生成的配置作为集群的配置模板目录使用
+
+	3. 按链配置中的信任根选择部署合约的背书管理员
+*/
+
+package nodecontrol
+
+import (
+	"TransactionRwset/utils"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	sdk "chainmaker.org/chainmaker/sdk-go/v2"
+	"gopkg.in/yaml.v2"
+)
+
+// 当前认证模式，可通过命令行设置
+var AuthType = sdk.PermissionedWithCert
+
+// 命令行中认证模式的名称
+var authTypeNames = map[string]sdk.AuthType{
+	"cert":                 sdk.PermissionedWithCert,
+	"permissionedWithCert": sdk.PermissionedWithCert,
+	"pwk":                  sdk.PermissionedWithKey,
+	"permissionedWithKey":  sdk.PermissionedWithKey,
+	"public":               sdk.Public,
+	"pk":                   sdk.Public,
+}
+
+// 节点配置中 auth_type 的取值
+var authTypeConfigNames = map[sdk.AuthType]string{
+	sdk.PermissionedWithCert: "permissionedWithCert",
+	sdk.PermissionedWithKey:  "permissionedWithKey",
+	sdk.Public:               "public",
+}
+
+// 各认证模式下客户端使用的 SDK 配置
+var authSDKConfPaths = map[sdk.AuthType]string{
+	sdk.PermissionedWithCert: "./sdk_config.yml",
+	sdk.PermissionedWithKey:  "./sdk_config_pwk.yml",
+	sdk.Public:               "./sdk_config_pk.yml",
+}
+
+// 部署合约时默认的背书用户，无法查询链配置时使用
+var defaultEndorsers = []string{UserNameOrg1Admin1, UserNameOrg2Admin1, UserNameOrg3Admin1, UserNameOrg4Admin1}
+
+// 当前认证模式在节点配置中的名称
+func AuthTypeName() string {
+	return authTypeConfigNames[AuthType]
+}
+
+// SetAuthType 切换认证模式，检查所需密钥并以对应的 SDK 配置重新创建客户端
+func SetAuthType(name string) error {
+	authType, ok := authTypeNames[name]
+	if !ok {
+		return fmt.Errorf("unknown auth type %s (available: cert, pwk, public)", name)
+	}
+	if err := checkAuthKeys(authType); err != nil {
+		return err
+	}
+
+	AuthType = authType
+	sdkConfPath = authSDKConfPaths[authType]
+
+	if ChainmakerController == nil {
+		ChainmakerController = &NodeController{}
+	}
+	return ChainmakerController.reconnect()
+}
+
+// 检查 SDK 配置与背书用户的密钥是否存在，证书模式的证书由 prepare.sh 生成，不在此检查
+func checkAuthKeys(authType sdk.AuthType) error {
+	paths := []string{authSDKConfPaths[authType]}
+	switch authType {
+	case sdk.PermissionedWithKey:
+		for _, u := range permissionedPkUsers {
+			paths = append(paths, u.SignKeyPath)
+		}
+	case sdk.Public:
+		for _, u := range pkUsers {
+			paths = append(paths, u.SignKeyPath)
+		}
+	}
+
+	missing := make([]string, 0)
+	for _, path := range paths {
+		if _, err := os.Stat(path); err != nil {
+			missing = append(missing, path)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("missing keys of %s auth mode: %s", authTypeConfigNames[authType], strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// AuthTemplateDir 返回按当前认证模式启动集群时使用的配置模板目录
+// 公钥模式下将 config.TemplateDir（为空时为 prepare.sh 生成的配置）改写后保存至 targetDir；
+// 证书模式下若发布包配置已被公钥模式改写，则使用 prepare.sh 生成的配置恢复
+func AuthTemplateDir(config *ClusterConfig, targetDir string) (string, error) {
+	baseDir := config.TemplateDir
+	if baseDir == "" {
+		baseDir = utils.ChainMakerConfigDir
+	}
+
+	if AuthType != sdk.PermissionedWithCert {
+		if err := GenerateAuthConfigs(baseDir, targetDir); err != nil {
+			return "", err
+		}
+		return targetDir, nil
+	}
+
+	if config.TemplateDir != "" {
+		return config.TemplateDir, nil
+	}
+	nodes, err := discoverNodes(config)
+	if err != nil {
+		return "", err
+	}
+	for _, node := range nodes {
+		authType, err := readAuthType(filepath.Join(node.configDir(), nodeConfigFile))
+		if err != nil {
+			return "", err
+		}
+		if authType != authTypeConfigNames[sdk.PermissionedWithCert] {
+			return baseDir, nil
+		}
+	}
+	return "", nil
+}
+
+func readAuthType(filePath string) (string, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", err
+	}
+
+	var config struct {
+		AuthType string `yaml:"auth_type"`
+	}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return "", err
+	}
+	return config.AuthType, nil
+}
+
+type consensusNode struct {
+	OrgID   string   `yaml:"org_id"`
+	NodeIDs []string `yaml:"node_id"`
+}
+
+type chainConfig struct {
+	Consensus struct {
+		Type  int              `yaml:"type"`
+		Nodes []*consensusNode `yaml:"nodes"`
+	} `yaml:"consensus"`
+	TrustRoots []struct {
+		OrgID string `yaml:"org_id"`
+	} `yaml:"trust_roots"`
+}
+
+// 组织的共识节点在公钥模式下使用的密钥
+type authNodeKey struct {
+	oldID   string // 证书模式下的节点 ID
+	nodeID  string
+	keyDir  string // 复制到节点配置 keys 目录的源目录
+	keyFile string // 节点私钥相对 keys 目录的路径
+}
+
+// GenerateAuthConfigs 将 baseDir 中证书模式的节点配置按当前认证模式改写后保存至 targetDir
+func GenerateAuthConfigs(baseDir, targetDir string) error {
+	names, err := nodeConfigNames(baseDir)
+	if err != nil {
+		return err
+	}
+
+	// 各节点的链配置一致，以第一个节点为准
+	chain, err := readChainConfig(filepath.Join(baseDir, names[0], chainConfigFile))
+	if err != nil {
+		return err
+	}
+	if AuthType == sdk.Public && chain.Consensus.Type != ConsensusTypes["TBFT"] {
+		return fmt.Errorf("consensus type %d is not supported in public auth mode, only TBFT", chain.Consensus.Type)
+	}
+	keys, err := locateNodeKeys(chain)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		dir := filepath.Join(targetDir, name)
+		if err := os.RemoveAll(dir); err != nil {
+			return err
+		}
+		if _, err := copyDir(filepath.Join(baseDir, name), dir); err != nil {
+			return fmt.Errorf("failed to copy config of %s: %v", name, err)
+		}
+		if err := convertNodeAuth(dir, chain, keys); err != nil {
+			return fmt.Errorf("failed to convert config of %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+func readChainConfig(filePath string) (*chainConfig, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	chain := &chainConfig{}
+	if err := yaml.Unmarshal(data, chain); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", filePath, err)
+	}
+	if len(chain.Consensus.Nodes) == 0 {
+		return nil, fmt.Errorf("no consensus node in %s", filePath)
+	}
+	return chain, nil
+}
+
+// 在 config-pk 目录中查找各共识组织的节点密钥
+// PermissionedWithKey 模式按组织名（wx-org1）查找，Public 模式按共识节点顺序使用 node1..nodeN
+func locateNodeKeys(chain *chainConfig) (map[string]*authNodeKey, error) {
+	keys := make(map[string]*authNodeKey)
+
+	for i, node := range chain.Consensus.Nodes {
+		if len(node.NodeIDs) != 1 {
+			return nil, fmt.Errorf("org %s has %d consensus nodes, only one node per org is supported", node.OrgID, len(node.NodeIDs))
+		}
+
+		key := &authNodeKey{oldID: node.NodeIDs[0]}
+		var idFile string
+		switch AuthType {
+		case sdk.PermissionedWithKey:
+			org := strings.TrimSuffix(node.OrgID, ".chainmaker.org")
+			key.keyDir = filepath.Join(utils.ChainMakerConfigPkDir, "permissioned-with-key", org, "public-key")
+			key.keyFile = "node/consensus1/consensus1.key"
+			idFile = filepath.Join(key.keyDir, "node", "consensus1", "consensus1.node.id")
+		case sdk.Public:
+			name := fmt.Sprintf("node%d", i+1)
+			key.keyDir = filepath.Join(utils.ChainMakerConfigPkDir, "public", "node", name)
+			key.keyFile = name + ".key"
+			idFile = filepath.Join(key.keyDir, name+".node.id")
+		default:
+			return nil, fmt.Errorf("%s auth mode uses the certificates of prepare.sh", AuthTypeName())
+		}
+
+		id, err := os.ReadFile(idFile)
+		if err != nil {
+			return nil, fmt.Errorf("no key of consensus node of %s: %v", node.OrgID, err)
+		}
+		key.nodeID = strings.TrimSpace(string(id))
+		keys[node.OrgID] = key
+	}
+	return keys, nil
+}
+
+// 改写单个节点的配置，配置中的路径以发布包 bin 目录为基准
+func convertNodeAuth(dir string, chain *chainConfig, keys map[string]*authNodeKey) error {
+	nodeFile := filepath.Join(dir, nodeConfigFile)
+	chainFile := filepath.Join(dir, chainConfigFile)
+
+	orgID, orgPath, err := readNodeOrg(nodeFile)
+	if err != nil {
+		return err
+	}
+	key, ok := keys[orgID]
+	if !ok {
+		return fmt.Errorf("org %s has no consensus node", orgID)
+	}
+	configPath := "../config/" + orgPath + "/"
+
+	// 节点密钥，PermissionedWithKey 模式下还包含各组织的管理员公钥
+	if AuthType == sdk.PermissionedWithKey {
+		if _, err := copyDir(key.keyDir, filepath.Join(dir, "keys")); err != nil {
+			return err
+		}
+	} else {
+		if err := os.MkdirAll(filepath.Join(dir, "keys"), 0755); err != nil {
+			return err
+		}
+		if _, err := copyFile(filepath.Join(key.keyDir, key.keyFile), filepath.Join(dir, "keys", key.keyFile), 0600); err != nil {
+			return err
+		}
+		if _, err := copyDir(filepath.Join(utils.ChainMakerConfigPkDir, "public", "admin"), filepath.Join(dir, "keys", "admin")); err != nil {
+			return err
+		}
+	}
+
+	authType := fmt.Sprintf("%q", AuthTypeName())
+	nodeValues := []struct {
+		path  []string
+		value interface{}
+	}{
+		{[]string{"auth_type"}, authType},
+		{[]string{"node", "priv_key_file"}, configPath + "keys/" + key.keyFile},
+		{[]string{"net", "tls", "priv_key_file"}, configPath + "keys/" + key.keyFile},
+		{[]string{"rpc", "tls", "mode"}, "disable"},
+	}
+	for _, v := range nodeValues {
+		if err := setYAMLValue(nodeFile, v.path, v.value); err != nil {
+			return err
+		}
+	}
+	if err := setYAMLValue(chainFile, []string{"auth_type"}, authType); err != nil {
+		return err
+	}
+
+	// 共识节点与信任根
+	roots := make([]string, 0)
+	if AuthType == sdk.Public {
+		nodes := []string{`- org_id: "public"`, "  node_id:"}
+		roots = append(roots, `- org_id: "public"`, "  root:")
+		for i, node := range chain.Consensus.Nodes {
+			nodes = append(nodes, fmt.Sprintf("    - %q", keys[node.OrgID].nodeID))
+			roots = append(roots, fmt.Sprintf(`    - "%skeys/admin/admin%d/admin%d.pem"`, configPath, i+1, i+1))
+		}
+		if err := replaceYAMLSection(chainFile, []string{"consensus", "nodes"}, nodes); err != nil {
+			return err
+		}
+	} else {
+		for _, root := range chain.TrustRoots {
+			roots = append(roots, fmt.Sprintf("- org_id: %q", root.OrgID), "  root:",
+				fmt.Sprintf(`    - "%skeys/admin/%s/admin.pem"`, configPath, root.OrgID))
+		}
+	}
+	if err := replaceYAMLSection(chainFile, []string{"trust_roots"}, roots); err != nil {
+		return err
+	}
+
+	// 种子节点地址与共识节点列表中的节点 ID
+	for _, file := range []string{nodeFile, chainFile} {
+		if err := replaceNodeIDs(file, keys); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 节点所属组织与发布包中的配置目录名，由创世配置路径 ../config/<org_path>/chainconfig/bc1.yml 得到
+func readNodeOrg(filePath string) (string, string, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", "", err
+	}
+
+	var config struct {
+		Node struct {
+			OrgID string `yaml:"org_id"`
+		} `yaml:"node"`
+		Blockchain []struct {
+			Genesis string `yaml:"genesis"`
+		} `yaml:"blockchain"`
+	}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return "", "", fmt.Errorf("failed to parse %s: %v", filePath, err)
+	}
+	if len(config.Blockchain) == 0 {
+		return "", "", fmt.Errorf("no blockchain in %s", filePath)
+	}
+
+	orgPath := strings.TrimPrefix(config.Blockchain[0].Genesis, "../config/")
+	if orgPath == config.Blockchain[0].Genesis || !strings.Contains(orgPath, "/") {
+		return "", "", fmt.Errorf("unexpected genesis path %s in %s", config.Blockchain[0].Genesis, filePath)
+	}
+	return config.Node.OrgID, orgPath[:strings.Index(orgPath, "/")], nil
+}
+
+func replaceNodeIDs(filePath string, keys map[string]*authNodeKey) error {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+
+	content := string(data)
+	for _, key := range keys {
+		content = strings.ReplaceAll(content, key.oldID, key.nodeID)
+	}
+	return os.WriteFile(filePath, []byte(content), 0644)
+}
+
+// 部署合约的背书用户：证书与 PermissionedWithKey 模式下为每个信任根组织的管理员，
+// Public 模式下为全部管理员
+func (n *NodeController) deployEndorsers(client *sdk.ChainClient) []string {
+	chainConfig, err := client.GetChainConfig()
+	if err != nil {
+		fmt.Println("failed to get chain config, use default endorsers:", err)
+		return defaultEndorsers
+	}
+
+	orgs := []string{OrgId1, OrgId2, OrgId3, OrgId4, OrgId5}
+	names := make([]string, 0)
+	for _, root := range chainConfig.TrustRoots {
+		if AuthType == sdk.Public {
+			for i := range root.Root {
+				names = append(names, fmt.Sprintf("org%dadmin1", i+1))
+			}
+			continue
+		}
+		for i, org := range orgs {
+			if org == root.OrgId {
+				names = append(names, fmt.Sprintf("org%dadmin1", i+1))
+			}
+		}
+	}
+
+	if len(names) == 0 {
+		return defaultEndorsers
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,782 @@
+/*
+	本文件主要用于：
+
+	1. 管理本地 chainmaker 集群的生命周期，替代 sudo 启停脚本：
+			a. 从发布包目录启动 N 个节点，可选使用配置模板覆盖各节点配置
+			b. 按节点配置启动合约所需的 Docker-Go 虚拟机容器
+			c. 通过 RPC 等待集群就绪：链信息可查询，且节点已连接其余全部节点；
+			   首次启动时另外发送探测交易，确认区块高度能够增长
+			d. 部署合约等交易后，等待区块高度增长，替代固定时长的等待
+
+	2. 运行期间：
+			a. 将节点标准输出与 system.log 持续写入结果目录
+			b. 检测退出的节点进程与停止的虚拟机容器
+
+	3. 停止时结束所有节点进程与容器、还原被配置模板覆盖的发布包配置并清理数据；节点进程设置了父进程退出信号，
+	   本程序异常退出时节点也会被结束
+
+	4. 节点可以只停止不清理数据，再次启动后继续使用原有账本，用于账本快照与恢复
+*/
+
+package nodecontrol
+
+import (
+	"TransactionRwset/utils"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Docker-Go 虚拟机镜像，与发布包 start.sh 中一致
+const VMGoImage = "chainmakerofficial/chainmaker-vm-engine:v2.3.2"
+
+type ClusterConfig struct {
+	ReleaseDir   string        // 节点发布包目录，每个子目录为一个节点
+	TemplateDir  string        // 配置模板目录，子目录 node1..nodeN 覆盖对应节点的配置，为空时使用发布包自带配置
+	Nodes        int           // 启动的节点数，0 表示发布包中的全部节点
+	LogDir       string        // 节点日志输出目录，为空时不保存
+	ReadyTimeout time.Duration // 等待集群就绪的最长时间
+	KeepData     bool          // 停止后保留节点数据
+}
+
+// 全局集群配置，参数可通过命令行设置
+var ClusterOptions = &ClusterConfig{
+	ReleaseDir:   utils.ChainMakerReleaseDir,
+	ReadyTimeout: 2 * time.Minute,
+}
+
+// 单个节点的运行状态
+type NodeStatus struct {
+	Name      string    `json:"name"`
+	Dir       string    `json:"dir"`
+	OrgID     string    `json:"org_id"`
+	Pid       int       `json:"pid"`
+	Container string    `json:"container,omitempty"` // Docker-Go 虚拟机容器名
+	StartedAt time.Time `json:"started_at"`
+	Crashed   bool      `json:"crashed"`
+	ExitError string    `json:"exit_error,omitempty"`
+}
+
+type clusterNode struct {
+	NodeStatus
+
+	cmd    *exec.Cmd
+	output *os.File
+	done   chan struct{}
+	tails  []*logTail
+}
+
+type cluster struct {
+	config   ClusterConfig
+	nodes    []*clusterNode
+	mu       sync.Mutex
+	stopping bool
+}
+
+// 节点配置中与 Docker-Go 虚拟机相关的部分
+type vmGoConfig struct {
+	VM struct {
+		Go struct {
+			Enable         bool   `yaml:"enable"`
+			DataMountPath  string `yaml:"data_mount_path"`
+			LogMountPath   string `yaml:"log_mount_path"`
+			Protocol       string `yaml:"protocol"`
+			LogLevel       string `yaml:"log_level"`
+			LogInConsole   bool   `yaml:"log_in_console"`
+			MaxSendMsgSize int    `yaml:"max_send_msg_size"`
+			MaxRecvMsgSize int    `yaml:"max_recv_msg_size"`
+			DialTimeout    int    `yaml:"dial_timeout"`
+			MaxConcurrency int    `yaml:"max_concurrency"`
+			SlowDisable    bool   `yaml:"slow_disable"`
+			SlowStepTime   int    `yaml:"slow_step_time"`
+			SlowTxTime     int    `yaml:"slow_tx_time"`
+			ProcessTimeout int    `yaml:"process_timeout"`
+			RuntimeServer  struct {
+				Host string `yaml:"host"`
+				Port int    `yaml:"port"`
+			} `yaml:"runtime_server"`
+			ContractEngine struct {
+				Host string `yaml:"host"`
+				Port int    `yaml:"port"`
+			} `yaml:"contract_engine"`
+		} `yaml:"go"`
+	} `yaml:"vm"`
+}
+
+func emitClusterEvent(level utils.LogLevel, eventType, message string, fields map[string]interface{}) {
+	if utils.Log == nil {
+		fmt.Println(message)
+		return
+	}
+	utils.Log.Emit(&utils.Event{
+		Level:   level,
+		Phase:   utils.ExecutionLog,
+		Type:    eventType,
+		Message: message,
+		Fields:  fields,
+	})
+}
+
+// 列出发布包目录中的节点，按目录名排序
+func discoverNodes(config *ClusterConfig) ([]*clusterNode, error) {
+	entries, err := os.ReadDir(config.ReleaseDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read release dir: %v", err)
+	}
+
+	nodes := make([]*clusterNode, 0)
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		dir, err := filepath.Abs(filepath.Join(config.ReleaseDir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if _, err := os.Stat(filepath.Join(dir, "bin", "chainmaker")); err != nil {
+			continue
+		}
+
+		// 发布包的 config 目录下只有一个以组织名命名的目录
+		configs, err := os.ReadDir(filepath.Join(dir, "config"))
+		if err != nil || len(configs) == 0 {
+			return nil, fmt.Errorf("node %s has no config dir", entry.Name())
+		}
+
+		nodes = append(nodes, &clusterNode{NodeStatus: NodeStatus{
+			Dir:   dir,
+			OrgID: configs[0].Name(),
+		}})
+	}
+	// 节点按目录名中的编号排序，与配置模板中的 node1..nodeN 对应
+	sort.Slice(nodes, func(i, j int) bool {
+		a, b := dirNumber(nodes[i].Dir), dirNumber(nodes[j].Dir)
+		if a != b {
+			return a < b
+		}
+		return nodes[i].Dir < nodes[j].Dir
+	})
+	for i, node := range nodes {
+		node.Name = fmt.Sprintf("node%d", i+1)
+	}
+
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("no chainmaker node found in %s", config.ReleaseDir)
+	}
+	if config.Nodes > len(nodes) {
+		return nil, fmt.Errorf("%d nodes requested but only %d found in %s", config.Nodes, len(nodes), config.ReleaseDir)
+	}
+	if config.Nodes > 0 {
+		nodes = nodes[:config.Nodes]
+	}
+	return nodes, nil
+}
+
+var dirNumberPattern = regexp.MustCompile(`\d+`)
+
+// 目录名中最后一段数字，如 node10 为 10、wx-org2.chainmaker.org 为 2，没有数字时为 -1
+func dirNumber(dir string) int {
+	matches := dirNumberPattern.FindAllString(filepath.Base(dir), -1)
+	if len(matches) == 0 {
+		return -1
+	}
+	number, err := strconv.Atoi(matches[len(matches)-1])
+	if err != nil {
+		return -1
+	}
+	return number
+}
+
+func (node *clusterNode) configDir() string {
+	return filepath.Join(node.Dir, "config", node.OrgID)
+}
+
+// 应用配置模板前发布包配置的备份目录，不放在 config 下，以免被当作组织配置目录
+func (node *clusterNode) configBackupDir() string {
+	return filepath.Join(node.Dir, "config.orig")
+}
+
+// 使用模板目录中的同名节点配置覆盖发布包配置，覆盖前备份发布包配置，停止集群时还原
+func (node *clusterNode) applyTemplate(templateDir string) error {
+	src := filepath.Join(templateDir, node.Name)
+	if _, err := os.Stat(src); err != nil {
+		return fmt.Errorf("template %s has no config for %s", templateDir, node.Name)
+	}
+
+	if _, err := os.Stat(node.configBackupDir()); os.IsNotExist(err) {
+		if _, err := copyDir(node.configDir(), node.configBackupDir()); err != nil {
+			os.RemoveAll(node.configBackupDir())
+			return fmt.Errorf("failed to back up config of %s: %v", node.Name, err)
+		}
+	}
+
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(node.configDir(), rel)
+		if info.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(target, data, info.Mode())
+	})
+}
+
+// 还原应用配置模板前的发布包配置，没有备份时不做处理
+func (node *clusterNode) restoreConfig() error {
+	backup := node.configBackupDir()
+	if _, err := os.Stat(backup); os.IsNotExist(err) {
+		return nil
+	}
+	if err := os.RemoveAll(node.configDir()); err != nil {
+		return err
+	}
+	return os.Rename(backup, node.configDir())
+}
+
+func (node *clusterNode) clean() error {
+	if err := os.RemoveAll(filepath.Join(node.Dir, "data")); err != nil {
+		return err
+	}
+	logs, _ := filepath.Glob(filepath.Join(node.Dir, "log", "*"))
+	for _, log := range logs {
+		if err := os.RemoveAll(log); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (node *clusterNode) readVMConfig() (*vmGoConfig, error) {
+	data, err := os.ReadFile(filepath.Join(node.configDir(), "chainmaker.yml"))
+	if err != nil {
+		return nil, err
+	}
+
+	config := &vmGoConfig{}
+	if err := yaml.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("failed to parse chainmaker.yml of %s: %v", node.Name, err)
+	}
+	return config, nil
+}
+
+// 相对路径以节点 bin 目录为基准，与 start.sh 一致
+func (node *clusterNode) binPath(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(node.Dir, "bin", path)
+}
+
+func runDocker(args ...string) (string, error) {
+	output, err := exec.Command("docker", args...).CombinedOutput()
+	if err != nil {
+		return string(output), fmt.Errorf("docker %s: %v: %s", args[0], err, strings.TrimSpace(string(output)))
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// 启动节点所需的 Docker-Go 虚拟机容器，与 start.sh 中的参数一致
+func (node *clusterNode) startVMContainer() error {
+	config, err := node.readVMConfig()
+	if err != nil {
+		return err
+	}
+	vm := config.VM.Go
+	if !vm.Enable {
+		return nil
+	}
+
+	node.Container = fmt.Sprintf("VM-GO-%s-%s", node.OrgID, node.Name)
+	runDocker("rm", "-f", node.Container)
+
+	mountPath, logPath := node.binPath(vm.DataMountPath), node.binPath(vm.LogMountPath)
+	for _, dir := range []string{mountPath, logPath} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+
+	args := []string{"run", "-d", "-v", mountPath + ":/mount", "-v", logPath + ":/log"}
+	env := map[string]string{
+		"MAX_SEND_MSG_SIZE":                  strconv.Itoa(vm.MaxSendMsgSize),
+		"MAX_RECV_MSG_SIZE":                  strconv.Itoa(vm.MaxRecvMsgSize),
+		"MAX_CONN_TIMEOUT":                   strconv.Itoa(vm.DialTimeout),
+		"MAX_ORIGINAL_PROCESS_NUM":           strconv.Itoa(vm.MaxConcurrency),
+		"DOCKERVM_CONTRACT_ENGINE_LOG_LEVEL": vm.LogLevel,
+		"DOCKERVM_SANDBOX_LOG_LEVEL":         vm.LogLevel,
+		"DOCKERVM_LOG_IN_CONSOLE":            strconv.FormatBool(vm.LogInConsole),
+		"SLOW_DISABLE":                       strconv.FormatBool(vm.SlowDisable),
+		"SLOW_STEP_TIME":                     strconv.Itoa(vm.SlowStepTime),
+		"SLOW_TX_TIME":                       strconv.Itoa(vm.SlowTxTime),
+		"PROCESS_TIMEOUT":                    strconv.Itoa(vm.ProcessTimeout),
+	}
+	if vm.Protocol == "uds" {
+		env["CHAIN_RPC_PROTOCOL"] = "0"
+	} else {
+		args = append(args, "--net=host")
+		env["CHAIN_RPC_PROTOCOL"] = "1"
+		env["CHAIN_HOST"] = vm.RuntimeServer.Host
+		env["CHAIN_RPC_PORT"] = strconv.Itoa(vm.ContractEngine.Port)
+		env["SANDBOX_RPC_PORT"] = strconv.Itoa(vm.RuntimeServer.Port)
+	}
+
+	keys := make([]string, 0, len(env))
+	for key := range env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		args = append(args, "-e", key+"="+env[key])
+	}
+	args = append(args, "--name", node.Container, "--privileged", VMGoImage)
+
+	if _, err := runDocker(args...); err != nil {
+		return fmt.Errorf("failed to start vm container of %s: %v", node.Name, err)
+	}
+	return nil
+}
+
+// 启动节点进程，标准输出写入 panic.log，与 start.sh 一致
+func (node *clusterNode) start(logDir string) error {
+	binDir := filepath.Join(node.Dir, "bin")
+	libDir := filepath.Join(node.Dir, "lib")
+
+	outputPath := filepath.Join(binDir, "panic.log")
+	if logDir != "" {
+		if err := os.MkdirAll(filepath.Join(logDir, node.Name), 0755); err != nil {
+			return err
+		}
+		outputPath = filepath.Join(logDir, node.Name, "panic.log")
+	}
+	// 集群重启后追加写入，保留重启前的输出
+	output, err := os.OpenFile(outputPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command("./chainmaker", "start", "-c", filepath.Join("..", "config", node.OrgID, "chainmaker.yml"))
+	cmd.Dir = binDir
+	cmd.Stdout = output
+	cmd.Stderr = output
+	cmd.Env = append(os.Environ(),
+		"LD_LIBRARY_PATH="+libDir+":"+os.Getenv("LD_LIBRARY_PATH"),
+		"PATH="+libDir+":"+os.Getenv("PATH"),
+		"WASMER_BACKTRACE=1",
+	)
+	// 节点进程独立成组，本程序退出时节点随之结束
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true, Pdeathsig: syscall.SIGKILL}
+
+	// 在节点启动前开始跟踪日志，重启时不会遗漏节点刚启动时的输出
+	var tail *logTail
+	if logDir != "" {
+		tail = followLog(filepath.Join(node.Dir, "log", "system.log"), filepath.Join(logDir, node.Name, "system.log"))
+	}
+
+	if err := cmd.Start(); err != nil {
+		output.Close()
+		if tail != nil {
+			tail.Stop()
+		}
+		return fmt.Errorf("failed to start %s: %v", node.Name, err)
+	}
+
+	node.cmd = cmd
+	node.output = output
+	node.done = make(chan struct{})
+	node.Pid = cmd.Process.Pid
+	node.StartedAt = time.Now()
+	node.Crashed = false
+	node.ExitError = ""
+
+	if tail != nil {
+		node.tails = append(node.tails, tail)
+	}
+	return nil
+}
+
+// 节点进程退出后记录状态，非停止过程中的退出视为崩溃
+func (c *cluster) watch(node *clusterNode) {
+	defer utils.RecoverPanic()
+
+	err := node.cmd.Wait()
+
+	c.mu.Lock()
+	stopping := c.stopping
+	if !stopping {
+		node.Crashed = true
+		if err != nil {
+			node.ExitError = err.Error()
+		} else {
+			node.ExitError = "exited"
+		}
+	}
+	c.mu.Unlock()
+	close(node.done)
+
+	if !stopping {
+		emitClusterEvent(utils.LevelError, utils.EventNodeCrashed, fmt.Sprintf("%s (pid %d) exited unexpectedly: %s", node.Name, node.Pid, node.ExitError),
+			map[string]interface{}{"node": node.Name, "pid": node.Pid, "error": node.ExitError})
+	}
+}
+
+// StartCluster 按配置启动集群并等待就绪，失败时清理已启动的部分
+func (n *NodeController) StartCluster(config *ClusterConfig) error {
+	if n.cluster != nil {
+		return fmt.Errorf("cluster already started")
+	}
+
+	nodes, err := discoverNodes(config)
+	if err != nil {
+		return err
+	}
+
+	c := &cluster{config: *config, nodes: nodes}
+	n.cluster = c
+
+	start := time.Now()
+	for _, node := range nodes {
+		// 上次运行异常退出时未还原的配置，先还原再应用本次的模板
+		if err := node.restoreConfig(); err != nil {
+			n.StopCluster()
+			return fmt.Errorf("failed to restore config of %s: %v", node.Name, err)
+		}
+		if config.TemplateDir != "" {
+			if err := node.applyTemplate(config.TemplateDir); err != nil {
+				n.StopCluster()
+				return err
+			}
+		}
+		if err := node.clean(); err != nil {
+			n.StopCluster()
+			return fmt.Errorf("failed to clean %s: %v", node.Name, err)
+		}
+		if err := c.launch(node); err != nil {
+			n.StopCluster()
+			return err
+		}
+	}
+
+	if err := n.WaitReady(config.ReadyTimeout); err != nil {
+		n.StopCluster()
+		return err
+	}
+	if err := n.waitBlockProduction(config.ReadyTimeout); err != nil {
+		n.StopCluster()
+		return err
+	}
+
+	// 按节点分发实验交易时为每个节点创建客户端，节点 SDK 配置与节点日志保存在一起
+	if err := n.ConnectNodes(config.LogDir); err != nil {
+		n.StopCluster()
+		return err
+	}
+
+	emitClusterEvent(utils.LevelInfo, utils.EventClusterReady, fmt.Sprintf("cluster of %d nodes ready in %v", len(nodes), time.Since(start).Round(time.Millisecond)),
+		map[string]interface{}{"nodes": len(nodes), "auth_type": AuthTypeName(), "duration_ms": time.Since(start).Milliseconds()})
+	return nil
+}
+
+// 启动节点的虚拟机容器与节点进程，并监控进程退出
+func (c *cluster) launch(node *clusterNode) error {
+	if err := node.startVMContainer(); err != nil {
+		return err
+	}
+	if err := node.start(c.config.LogDir); err != nil {
+		return err
+	}
+	go c.watch(node)
+
+	emitClusterEvent(utils.LevelInfo, utils.EventNodeStarted, fmt.Sprintf("%s started (pid %d)", node.Name, node.Pid),
+		map[string]interface{}{"node": node.Name, "pid": node.Pid, "dir": node.Dir, "org_id": node.OrgID, "container": node.Container})
+	return nil
+}
+
+func (c *cluster) setStopping(stopping bool) {
+	c.mu.Lock()
+	c.stopping = stopping
+	c.mu.Unlock()
+}
+
+// WaitReady 等待链信息可查询、节点已连接其余全部节点且区块高度可读取；不提交任何交易，
+// 重启后账本高度保持不变
+func (n *NodeController) WaitReady(timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	lastErr := fmt.Errorf("not checked")
+
+	for time.Now().Before(deadline) {
+		if err := n.CheckCluster(); err != nil {
+			return err
+		}
+
+		info, err := n.Client.GetChainInfo()
+		if err != nil {
+			lastErr = err
+		} else if n.cluster != nil && len(info.NodeList) < len(n.cluster.nodes)-1 {
+			// 节点列表是否包含自身与版本有关，这里只要求看到其余全部节点
+			lastErr = fmt.Errorf("%d of %d nodes connected", len(info.NodeList), len(n.cluster.nodes))
+		} else if _, err := n.Client.GetCurrentBlockHeight(); err != nil {
+			lastErr = err
+		} else {
+			return nil
+		}
+
+		time.Sleep(time.Second)
+	}
+
+	return fmt.Errorf("cluster not ready after %v: %v", timeout, lastErr)
+}
+
+// 链上没有交易时不会出块，首次启动时通过探测交易确认集群能够出块
+const (
+	readinessProbeContract = "readiness_probe"
+	readinessProbeInterval = 5 * time.Second
+)
+
+// 等待区块高度增长，高度不变时定期发送探测交易；会增加区块，只用于首次启动的新账本
+func (n *NodeController) waitBlockProduction(timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	lastErr := fmt.Errorf("not checked")
+	var baseHeight uint64
+	heightKnown := false
+	var lastProbe time.Time
+
+	for time.Now().Before(deadline) {
+		if err := n.CheckCluster(); err != nil {
+			return err
+		}
+
+		height, err := n.Client.GetCurrentBlockHeight()
+		if err != nil {
+			lastErr = err
+		} else if !heightKnown {
+			baseHeight, heightKnown = height, true
+			lastErr = fmt.Errorf("block height stays at %d", height)
+		} else if height > baseHeight {
+			return nil
+		}
+		if heightKnown && time.Since(lastProbe) >= readinessProbeInterval {
+			// 调用不存在的合约同样会被打包上链，只用于推动出块，不关心执行结果
+			lastProbe = time.Now()
+			if _, err := n.Client.InvokeContract(readinessProbeContract, "ping", "", nil, -1, false); err != nil {
+				lastErr = fmt.Errorf("block height stays at %d, probe tx failed: %v", baseHeight, err)
+			}
+		}
+
+		time.Sleep(time.Second)
+	}
+
+	return fmt.Errorf("no block produced after %v: %v", timeout, lastErr)
+}
+
+// WaitForBlock 等待区块高度达到 height
+func (n *NodeController) WaitForBlock(height uint64, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	var current uint64
+
+	for time.Now().Before(deadline) {
+		if err := n.CheckCluster(); err != nil {
+			return err
+		}
+
+		h, err := n.Client.GetCurrentBlockHeight()
+		if err == nil {
+			current = h
+			if current >= height {
+				return nil
+			}
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+
+	return fmt.Errorf("block height %d not reached after %v (current %d)", height, timeout, current)
+}
+
+// CheckCluster 检查节点进程与虚拟机容器是否仍在运行，未启动集群时不做检查
+func (n *NodeController) CheckCluster() error {
+	c := n.cluster
+	if c == nil {
+		return nil
+	}
+
+	problems := make([]string, 0)
+	for _, status := range n.NodeStatuses() {
+		if status.Crashed {
+			problems = append(problems, fmt.Sprintf("%s crashed: %s", status.Name, status.ExitError))
+		}
+		if status.Container != "" {
+			running, err := runDocker("inspect", "-f", "{{.State.Running}}", status.Container)
+			if err != nil || running != "true" {
+				problems = append(problems, fmt.Sprintf("vm container %s of %s is not running", status.Container, status.Name))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("cluster unhealthy: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// NodeStatuses 当前各节点的状态
+func (n *NodeController) NodeStatuses() []NodeStatus {
+	c := n.cluster
+	if c == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	statuses := make([]NodeStatus, 0, len(c.nodes))
+	for _, node := range c.nodes {
+		statuses = append(statuses, node.NodeStatus)
+	}
+	return statuses
+}
+
+// StopCluster 结束所有节点与容器，可重复调用
+func (n *NodeController) StopCluster() error {
+	c := n.cluster
+	if c == nil {
+		return nil
+	}
+	n.cluster = nil
+	n.closeNodeClients()
+	c.setStopping(true)
+
+	errs := make([]string, 0)
+	for _, node := range c.nodes {
+		if err := node.halt(); err != nil {
+			errs = append(errs, err.Error())
+		}
+		if err := node.restoreConfig(); err != nil {
+			errs = append(errs, fmt.Sprintf("failed to restore config of %s: %v", node.Name, err))
+		}
+
+		if !c.config.KeepData {
+			if err := node.clean(); err != nil {
+				errs = append(errs, fmt.Sprintf("failed to clean %s: %v", node.Name, err))
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// 结束节点进程、日志跟踪与虚拟机容器，保留节点数据
+func (node *clusterNode) halt() error {
+	if node.cmd != nil {
+		// 先发送 SIGTERM，超时后强制结束整个进程组
+		syscall.Kill(-node.Pid, syscall.SIGTERM)
+		select {
+		case <-node.done:
+		case <-time.After(15 * time.Second):
+			syscall.Kill(-node.Pid, syscall.SIGKILL)
+			<-node.done
+		}
+		node.output.Close()
+		node.cmd = nil
+	}
+
+	for _, tail := range node.tails {
+		tail.Stop()
+	}
+	node.tails = nil
+
+	if node.Container != "" {
+		if _, err := runDocker("rm", "-f", node.Container); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 持续将节点日志文件的新增内容追加到结果目录
+type logTail struct {
+	src, dst string
+	offset   int64
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+func followLog(src, dst string) *logTail {
+	t := &logTail{src: src, dst: dst, stop: make(chan struct{}), done: make(chan struct{})}
+
+	// 集群重启后源日志保留，只跟踪新增的部分
+	if info, err := os.Stat(src); err == nil {
+		t.offset = info.Size()
+	}
+	go t.run()
+	return t
+}
+
+func (t *logTail) run() {
+	defer utils.RecoverPanic()
+	defer close(t.done)
+
+	out, err := os.OpenFile(t.dst, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		fmt.Println("创建节点日志失败!", err)
+		return
+	}
+	defer out.Close()
+
+	offset := t.offset
+	copyNew := func() {
+		in, err := os.Open(t.src)
+		if err != nil {
+			return
+		}
+		defer in.Close()
+
+		// 日志被截断或轮转时从头开始
+		if info, err := in.Stat(); err == nil && info.Size() < offset {
+			offset = 0
+		}
+		if _, err := in.Seek(offset, io.SeekStart); err != nil {
+			return
+		}
+		written, _ := io.Copy(out, in)
+		offset += written
+	}
+
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			copyNew()
+		case <-t.stop:
+			copyNew()
+			return
+		}
+	}
+}
+
+func (t *logTail) Stop() {
+	close(t.stop)
+	<-t.done
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"strings"
 
 	"chainmaker.org/chainmaker/pb-go/v2/common"
 	sdk "chainmaker.org/chainmaker/sdk-go/v2"
@@ -12,14 +13,48 @@ import (
 
 var ChainmakerController *NodeController = NewNodeController()
 
-// 路径以main执行目录路径为基准
-const (
-	sdkConfPath  = "./sdk_config.yml"
-	claimVersion = "1.0.0"
+// 首次部署合约时使用的版本，升级时需指定不同的版本
+const ClaimVersion = "1.0.0"
+
+// 部署与调用合约时附带的 gas 上限
+var (
+	DeployGasLimit uint64 = 60000000
+	InvokeGasLimit uint64 = 200000
 )
 
+// 合约执行耗尽 gas 时虚拟机返回的信息片段
+var outOfGasMessages = []string{"gas limit is not enough", "out of gas"}
+
+// 合约执行超时或同步等待交易结果超时时返回的信息片段
+var timeoutMessages = []string{"timeout", "time out", "timed out", "deadline exceeded", "time limit"}
+
+// 客户端使用的 SDK 配置文件路径，随认证模式切换
+var sdkConfPath = authSDKConfPaths[sdk.PermissionedWithCert]
+
+// 客户端使用的 SDK 配置文件路径，同样可用于 cmc 命令
+func SDKConfPath() string {
+	return sdkConfPath
+}
+
+// SetSDKConfPath 使用指定的 SDK 配置重新创建客户端，用于连接端口不同的另一套集群
+func SetSDKConfPath(path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("sdk config %s not found: %v", path, err)
+	}
+	sdkConfPath = path
+
+	if ChainmakerController == nil {
+		ChainmakerController = &NodeController{}
+	}
+	return ChainmakerController.reconnect()
+}
+
 type NodeController struct {
 	Client *sdk.ChainClient
+
+	cluster  *cluster        // 由本程序启动的本地集群
+	snapshot *ledgerSnapshot // 部署合约后保存的账本快照
+	nodes    *nodeRotation   // 各节点的客户端，按权重分发实验交易
 }
 
 func NewNodeController() *NodeController {
@@ -37,34 +72,14 @@ func NewNodeController() *NodeController {
 	return controller
 }
 
-func (n *NodeController) StartChainmaker() {
-	scriptDir := utils.ChainMakerScriptsDir
-
-	// 执行启动脚本
-	cmdStart := exec.Command("sudo", "./cluster_quick_start.sh", "normal")
-	cmdStart.Dir = scriptDir // 设置启动脚本的工作目录
-	cmdStart.Stdout = os.Stdout
-	cmdStart.Stderr = os.Stderr
-	err := cmdStart.Run()
-	if err != nil {
-		fmt.Println("启动集群时出错：", err)
-		return
-	}
+// 启动本地集群并等待就绪
+func (n *NodeController) StartChainmaker() error {
+	return n.StartCluster(ClusterOptions)
 }
 
-func (n *NodeController) StopChainmaker() {
-	scriptDir := utils.ChainMakerScriptsDir
-
-	// 执行停止脚本
-	cmdStop := exec.Command("sudo", "./cluster_quick_stop.sh", "clean")
-	cmdStop.Dir = scriptDir // 设置停止脚本的工作目录
-	cmdStop.Stdout = os.Stdout
-	cmdStop.Stderr = os.Stderr
-	err := cmdStop.Run()
-	if err != nil {
-		fmt.Println("停止集群时出错：", err)
-		return
-	}
+// 停止本地集群并清理数据
+func (n *NodeController) StopChainmaker() error {
+	return n.StopCluster()
 }
 
 func (n *NodeController) GetChainClient() (*sdk.ChainClient, error) {
@@ -84,11 +99,15 @@ func (n *NodeController) GetChainClient() (*sdk.ChainClient, error) {
 func (n *NodeController) UserContractClaimCreate(claimContractName string, claimByteCodePath string,
 	withSyncResult bool, isIgnoreSameContract bool) (string, error) {
 	client := ChainmakerController.Client
-	usernames := []string{"org1admin1", "org2admin1",
-		"org3admin1", "org4admin1"}
+	usernames := n.deployEndorsers(client)
+
+	byteCode, runtime, kvs, err := deployArgs(claimByteCodePath)
+	if err != nil {
+		return "", err
+	}
 
-	resp, err := n.createUserContract(client, claimContractName, claimVersion, claimByteCodePath,
-		common.RuntimeType_DOCKER_GO, []*common.KeyValuePair{}, withSyncResult, usernames...)
+	resp, err := n.createUserContract(client, claimContractName, ClaimVersion, byteCode,
+		runtime, kvs, withSyncResult, usernames...)
 	if err != nil {
 		if !isIgnoreSameContract {
 			return "", err
@@ -104,6 +123,48 @@ func (n *NodeController) UserContractClaimCreate(claimContractName string, claim
 	return "", nil
 }
 
+// 升级已部署的合约，合约状态保持不变，version 需与当前版本不同
+// 升级时合约的 UpgradeContract 方法以 kvs 为参数执行
+func (n *NodeController) UserContractUpgrade(contractName, byteCodePath, version string, withSyncResult bool) (string, error) {
+	client := ChainmakerController.Client
+	usernames := n.deployEndorsers(client)
+
+	byteCode, runtime, kvs, err := deployArgs(byteCodePath)
+	if err != nil {
+		return "", err
+	}
+
+	payload, err := client.CreateContractUpgradePayload(contractName, version, byteCode, runtime, kvs)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := n.sendContractManage(client, payload, withSyncResult, usernames...)
+	if err != nil {
+		return "", fmt.Errorf("UPGRADE contract %s to %s failed, err: %s, resp: %+v", contractName, version, err, resp)
+	}
+	return resp.TxId, nil
+}
+
+// 部署与升级合约时的字节码、运行时与初始化参数
+func deployArgs(byteCodePath string) (string, common.RuntimeType, []*common.KeyValuePair, error) {
+	runtime := common.RuntimeType_DOCKER_GO
+	kvs := []*common.KeyValuePair{}
+	if contract := utils.GlobalContractInfo.EVM; contract != nil {
+		// EVM 合约与 cmc 一致，传入十六进制字节码内容及构造函数参数
+		byteCode, constructorKvs, err := contract.DeployPayload()
+		if err != nil {
+			return "", runtime, nil, err
+		}
+		byteCodePath, kvs, runtime = byteCode, constructorKvs, common.RuntimeType_EVM
+	}
+	if contract := utils.GlobalContractInfo.WASM; contract != nil {
+		// WASM 合约直接使用模块文件，按运行时部署并传入初始化参数
+		runtime, kvs = contract.DeployPayload()
+	}
+	return byteCodePath, runtime, kvs, nil
+}
+
 func (n *NodeController) createUserContract(client *sdk.ChainClient, contractName, version, byteCodePath string, runtime common.RuntimeType,
 	kvs []*common.KeyValuePair, withSyncResult bool, usernames ...string) (*common.TxResponse, error) {
 
@@ -112,8 +173,13 @@ func (n *NodeController) createUserContract(client *sdk.ChainClient, contractNam
 		return nil, err
 	}
 
+	return n.sendContractManage(client, payload, withSyncResult, usernames...)
+}
+
+// 附加 gas 上限与背书后发送合约管理交易
+func (n *NodeController) sendContractManage(client *sdk.ChainClient, payload *common.Payload, withSyncResult bool, usernames ...string) (*common.TxResponse, error) {
 	payload = client.AttachGasLimit(payload, &common.Limit{
-		GasLimit: 60000000,
+		GasLimit: DeployGasLimit,
 	})
 
 	//endorsers, err := examples.GetEndorsers(payload, usernames...)
@@ -139,34 +205,76 @@ func (n *NodeController) createUserContract(client *sdk.ChainClient, contractNam
 }
 
 // 调用合约
-func (n *NodeController) UserContractInvoke(contractName, method string, kvs []*common.KeyValuePair, withSyncResult bool) (string, string, common.TxStatusCode, bool, error) {
-	// 将FuncName转化为InvokeName
-	client := ChainmakerController.Client
-	method = utils.GlobalContractInfo.ContractFuncMap[method].InvokeName
+// 返回交易 ID、执行信息、状态码、消耗的 gas 以及是否执行成功
+func (n *NodeController) UserContractInvoke(contractName, method string, kvs []*common.KeyValuePair, withSyncResult bool) (string, string, common.TxStatusCode, uint64, bool, error) {
+	return n.UserContractInvokeVia(ChainmakerController.Client, contractName, method, kvs, withSyncResult)
+}
 
-	txId, message, code, success, err := n.invokeUserContract(client, contractName, method, "", kvs, withSyncResult, &common.Limit{GasLimit: 200000})
+// 经由指定客户端（如某个节点的客户端）调用合约
+func (n *NodeController) UserContractInvokeVia(client *sdk.ChainClient, contractName, method string, kvs []*common.KeyValuePair, withSyncResult bool) (string, string, common.TxStatusCode, uint64, bool, error) {
+	method, kvs, err := invokeArgs(method, kvs)
 	if err != nil {
-		return txId, message, code, success, err
+		return "", err.Error(), common.TxStatusCode_INVALID_PARAMETER, 0, false, err
 	}
 
-	return txId, message, code, success, nil
+	txId, message, code, gasUsed, success, err := n.invokeUserContract(client, contractName, method, "", kvs, withSyncResult, &common.Limit{GasLimit: InvokeGasLimit})
+	if err != nil {
+		return txId, message, code, gasUsed, success, err
+	}
+
+	return txId, message, code, gasUsed, success, nil
+}
+
+// 将FuncName转化为InvokeName
+// EVM 合约按 ABI 编码参数，调用名为方法选择器
+func invokeArgs(method string, kvs []*common.KeyValuePair) (string, []*common.KeyValuePair, error) {
+	if contract := utils.GlobalContractInfo.EVM; contract != nil {
+		return contract.EncodeInvoke(method, kvs)
+	}
+	return utils.GlobalContractInfo.ContractFuncMap[method].InvokeName, kvs, nil
 }
 
 func (n *NodeController) invokeUserContract(client *sdk.ChainClient, contractName, method, txId string, kvs []*common.KeyValuePair,
-	withSyncResult bool, limit *common.Limit) (string, string, common.TxStatusCode, bool, error) {
+	withSyncResult bool, limit *common.Limit) (string, string, common.TxStatusCode, uint64, bool, error) {
 
 	resp, err := client.InvokeContractWithLimit(contractName, method, txId, kvs, -1, withSyncResult, limit)
 
 	if err != nil {
-		return resp.GetTxId(), resp.Message, resp.Code, false, err
+		return resp.GetTxId(), resp.GetMessage(), resp.GetCode(), 0, false, err
+	}
+
+	// 同步调用时合约执行结果中带有消耗的 gas 与合约返回的错误信息
+	gasUsed := resp.GetContractResult().GetGasUsed()
+	message := resp.Message
+	if contractMessage := resp.GetContractResult().GetMessage(); contractMessage != "" && contractMessage != message {
+		message = strings.TrimSpace(message + " " + contractMessage)
 	}
 
 	if resp.Code != common.TxStatusCode_SUCCESS {
 		// fmt.Println(resp.TxId)
-		return resp.GetTxId(), resp.Message, resp.Code, false, fmt.Errorf("invoke contract failed, %s[code:%d]/[method:%s]/[height:%d]/[message:%s]\n", resp.GetTxId(), resp.Code, method, resp.TxBlockHeight, resp.GetMessage())
+		return resp.GetTxId(), message, resp.Code, gasUsed, false, fmt.Errorf("invoke contract failed, %s[code:%d]/[method:%s]/[height:%d]/[message:%s]\n", resp.GetTxId(), resp.Code, method, resp.TxBlockHeight, message)
 	}
 
-	return resp.GetTxId(), resp.Message, resp.Code, true, nil
+	return resp.GetTxId(), message, resp.Code, gasUsed, true, nil
+}
+
+// IsOutOfGas 判断一次调用是否因 gas 耗尽而失败，而非合约逻辑出错
+func IsOutOfGas(code common.TxStatusCode, gasUsed uint64, message string) bool {
+	if code == common.TxStatusCode_SUCCESS {
+		return false
+	}
+	if code == common.TxStatusCode_GAS_BALANCE_NOT_ENOUGH_FAILED || (InvokeGasLimit > 0 && gasUsed >= InvokeGasLimit) {
+		return true
+	}
+	return utils.MessageContains(message, outOfGasMessages...)
+}
+
+// IsTimeout 判断一次调用是否因执行超时而失败
+func IsTimeout(code common.TxStatusCode, message string) bool {
+	if code == common.TxStatusCode_SUCCESS {
+		return false
+	}
+	return code == common.TxStatusCode_TIMEOUT || utils.MessageContains(message, timeoutMessages...)
 }
 
 // used to debug:
@@ -0,0 +1,36 @@
+/*
+	本文件主要用于：
+
+	1. 构造合约调用的交易信封（payload），调用名与参数按合约类型转换，
+	   交易 ID、时间戳与 gas 上限使用与 UserContractInvoke 相同的默认值
+
+	2. 对任意修改后的 payload 签名并发送，不经过 SDK 对交易 ID、时间戳、过期时间、
+	   限额等字段的默认处理，用于交易信封模糊测试
+*/
+
+package nodecontrol
+
+import (
+	"fmt"
+
+	"chainmaker.org/chainmaker/pb-go/v2/common"
+)
+
+// NewInvokePayload 构造调用合约的 payload，交易 ID 为空时由 SDK 生成
+func (n *NodeController) NewInvokePayload(contractName, method string, kvs []*common.KeyValuePair) (*common.Payload, error) {
+	method, kvs, err := invokeArgs(method, kvs)
+	if err != nil {
+		return nil, err
+	}
+	return n.Client.CreatePayload("", common.TxType_INVOKE_CONTRACT, contractName, method, kvs, 0, &common.Limit{GasLimit: InvokeGasLimit}), nil
+}
+
+// SendPayload 以客户端用户身份对 payload 签名并发送
+// 签名失败时返回的响应为空；发送失败时返回节点的响应（若有）与错误
+func (n *NodeController) SendPayload(payload *common.Payload, withSyncResult bool) (*common.TxResponse, error) {
+	req, err := n.Client.GenerateTxRequest(payload, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate tx request: %v", err)
+	}
+	return n.Client.SendTxRequest(req, -1, withSyncResult)
+}
@@ -0,0 +1,266 @@
+/*
+	本文件主要用于：
+
+	1. 为本地集群的每个共识节点创建独立的 SDK 客户端：以当前 SDK 配置为基础，
+	   将节点列表改写为该节点的 RPC 地址与 TLS 根证书，保存为单独的配置文件
+
+	2. 按配置的权重在各节点客户端之间轮流分发实验交易，使交易经由不同节点进入交易池，
+	   覆盖交易转发路径；未配置权重时所有交易仍经由默认客户端发送
+*/
+
+package nodecontrol
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	sdk "chainmaker.org/chainmaker/sdk-go/v2"
+	"gopkg.in/yaml.v2"
+)
+
+// 单个节点的客户端
+type NodeClient struct {
+	Name       string
+	Addr       string
+	Weight     int
+	ConfigPath string
+	Client     *sdk.ChainClient
+}
+
+// 各节点的发送权重，为空时不创建节点客户端
+var nodeWeights map[string]int
+
+// 是否所有节点使用相同权重
+var allNodesWeight bool
+
+// SetNodeWeights 解析节点权重，格式为 node1=3,node2=1（未列出的节点不接收实验交易），all 表示所有节点权重相同
+func SetNodeWeights(spec string) error {
+	spec = strings.TrimSpace(spec)
+	nodeWeights, allNodesWeight = nil, false
+	if spec == "" {
+		return nil
+	}
+	if spec == "all" {
+		allNodesWeight = true
+		return nil
+	}
+
+	weights := make(map[string]int)
+	for _, item := range strings.Split(spec, ",") {
+		parts := strings.SplitN(strings.TrimSpace(item), "=", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("invalid node weight %q, expected node=weight", item)
+		}
+		weight, err := strconv.Atoi(parts[1])
+		if err != nil || weight < 0 {
+			return fmt.Errorf("invalid weight of %s: %s", parts[0], parts[1])
+		}
+		weights[parts[0]] = weight
+	}
+	nodeWeights = weights
+	return nil
+}
+
+// 是否按节点分发交易
+func nodeSpreadEnabled() bool {
+	return allNodesWeight || len(nodeWeights) > 0
+}
+
+// 各节点的发送顺序，按权重展开后轮流使用
+type nodeRotation struct {
+	clients  []*NodeClient
+	schedule []int
+	next     uint64
+}
+
+// ConnectNodes 为集群中的每个节点创建客户端，节点 SDK 配置保存在 confDir 下
+func (n *NodeController) ConnectNodes(confDir string) error {
+	c := n.cluster
+	if c == nil || !nodeSpreadEnabled() {
+		return nil
+	}
+	// 节点名写错时所有节点权重都为 0，在创建客户端前指出
+	names := make(map[string]bool, len(c.nodes))
+	for _, node := range c.nodes {
+		names[node.Name] = true
+	}
+	for name := range nodeWeights {
+		if !names[name] {
+			return fmt.Errorf("unknown node %q in node weights, the cluster has node1..node%d", name, len(c.nodes))
+		}
+	}
+
+	if confDir == "" {
+		confDir = filepath.Join(os.TempDir(), "chainmaker_node_sdk_config")
+	}
+	if err := os.MkdirAll(confDir, os.ModePerm); err != nil {
+		return err
+	}
+	n.closeNodeClients()
+
+	clients := make([]*NodeClient, 0, len(c.nodes))
+	// 创建失败时关闭已创建的客户端
+	n.nodes = &nodeRotation{clients: clients}
+	defer func() {
+		if n.nodes != nil && len(n.nodes.schedule) == 0 {
+			n.closeNodeClients()
+		}
+	}()
+	for _, node := range c.nodes {
+		weight := 1
+		if !allNodesWeight {
+			weight = nodeWeights[node.Name]
+		}
+
+		port, ok, err := getYAMLInt(filepath.Join(node.configDir(), nodeConfigFile), []string{"rpc", "port"})
+		if err != nil || !ok {
+			return fmt.Errorf("failed to read rpc port of %s: %v", node.Name, err)
+		}
+		nodeClient := &NodeClient{
+			Name:       node.Name,
+			Addr:       fmt.Sprintf("127.0.0.1:%d", port),
+			Weight:     weight,
+			ConfigPath: filepath.Join(confDir, fmt.Sprintf("sdk_config_%s.yml", node.Name)),
+		}
+
+		caDir := filepath.Join(node.configDir(), "certs", "ca", node.OrgID)
+		if err := writeNodeSDKConfig(sdkConfPath, nodeClient.ConfigPath, nodeClient.Addr, caDir); err != nil {
+			return fmt.Errorf("failed to write sdk config of %s: %v", node.Name, err)
+		}
+		if nodeClient.Client, err = sdk.NewChainClient(sdk.WithConfPath(nodeClient.ConfigPath)); err != nil {
+			return fmt.Errorf("failed to create chain client of %s: %v", node.Name, err)
+		}
+		clients = append(clients, nodeClient)
+		n.nodes.clients = clients
+	}
+
+	// 按轮次交错排列，避免同一节点的交易连续发送
+	rotation := &nodeRotation{clients: clients}
+	for round := 0; ; round++ {
+		added := false
+		for i, client := range clients {
+			if client.Weight > round {
+				rotation.schedule = append(rotation.schedule, i)
+				added = true
+			}
+		}
+		if !added {
+			break
+		}
+	}
+	n.nodes = rotation
+	if len(rotation.schedule) == 0 {
+		return fmt.Errorf("every node has weight 0")
+	}
+	return nil
+}
+
+// 节点重启后重新创建各节点客户端
+func (n *NodeController) reconnectNodes() error {
+	if n.nodes == nil {
+		return nil
+	}
+	for _, nodeClient := range n.nodes.clients {
+		client, err := sdk.NewChainClient(sdk.WithConfPath(nodeClient.ConfigPath))
+		if err != nil {
+			return fmt.Errorf("failed to create chain client of %s: %v", nodeClient.Name, err)
+		}
+		if nodeClient.Client != nil {
+			nodeClient.Client.Stop()
+		}
+		nodeClient.Client = client
+	}
+	return nil
+}
+
+func (n *NodeController) closeNodeClients() {
+	if n.nodes == nil {
+		return
+	}
+	for _, nodeClient := range n.nodes.clients {
+		if nodeClient.Client != nil {
+			nodeClient.Client.Stop()
+		}
+	}
+	n.nodes = nil
+}
+
+// NodeClients 各节点的客户端，未按节点分发时为空
+func (n *NodeController) NodeClients() []*NodeClient {
+	if n.nodes == nil {
+		return nil
+	}
+	return n.nodes.clients
+}
+
+// NextNodeClient 按权重轮流返回下一笔交易使用的节点客户端，可并发调用；未按节点分发时返回 nil
+func (n *NodeController) NextNodeClient() *NodeClient {
+	r := n.nodes
+	if r == nil || len(r.schedule) == 0 {
+		return nil
+	}
+	i := atomic.AddUint64(&r.next, 1) - 1
+	return r.clients[r.schedule[i%uint64(len(r.schedule))]]
+}
+
+// 以 base 为基础生成只连接一个节点的 SDK 配置
+func writeNodeSDKConfig(base, target, addr, caDir string) error {
+	data, err := os.ReadFile(base)
+	if err != nil {
+		return err
+	}
+	var config yaml.MapSlice
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return err
+	}
+
+	chainClient, ok := mapSliceValue(config, "chain_client").(yaml.MapSlice)
+	if !ok {
+		return fmt.Errorf("chain_client not found in %s", base)
+	}
+	nodes, ok := mapSliceValue(chainClient, "nodes").([]interface{})
+	if !ok || len(nodes) == 0 {
+		return fmt.Errorf("chain_client.nodes not found in %s", base)
+	}
+	node, ok := nodes[0].(yaml.MapSlice)
+	if !ok {
+		return fmt.Errorf("invalid chain_client.nodes in %s", base)
+	}
+
+	node = setMapSliceValue(append(yaml.MapSlice{}, node...), "node_addr", addr)
+	// 各节点的 TLS 证书由所属组织的 CA 签发
+	if _, err := os.Stat(caDir); err == nil {
+		node = setMapSliceValue(node, "trust_root_paths", []string{caDir})
+	}
+	chainClient = setMapSliceValue(chainClient, "nodes", []interface{}{node})
+	config = setMapSliceValue(config, "chain_client", chainClient)
+
+	out, err := yaml.Marshal(config)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(target, out, 0644)
+}
+
+func mapSliceValue(m yaml.MapSlice, key string) interface{} {
+	for _, item := range m {
+		if item.Key == key {
+			return item.Value
+		}
+	}
+	return nil
+}
+
+func setMapSliceValue(m yaml.MapSlice, key string, value interface{}) yaml.MapSlice {
+	for i, item := range m {
+		if item.Key == key {
+			m[i].Value = value
+			return m
+		}
+	}
+	return append(m, yaml.MapItem{Key: key, Value: value})
+}
@@ -0,0 +1,365 @@
+/*
+	本文件主要用于：
+
+	1. 定义实验矩阵中可调整的节点配置项：共识类型、区块容量、出块间隔、区块大小、
+	   调度超时（DAG 模拟超时）、ConflictsBitWindow、发送者分组调度、RWSetLog
+
+	2. 以 prepare.sh 根据 config/config_tpl 模板与本地证书生成的节点配置为基础，
+	   按配置项取值逐行改写 chainmaker.yml 与 chainconfig/bc1.yml 中对应的值，
+	   生成一组节点配置，作为集群的配置模板目录（node1..nodeN）使用；
+	   SOLO 共识只启动 node1，共识节点列表同时裁剪为 node1
+*/
+
+package nodecontrol
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	sdk "chainmaker.org/chainmaker/sdk-go/v2"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	nodeConfigFile  = "chainmaker.yml"
+	chainConfigFile = "chainconfig/bc1.yml"
+)
+
+// 共识类型名称与 bc1.yml 中 consensus.type 的对应关系
+var ConsensusTypes = map[string]int{
+	"SOLO":   0,
+	"TBFT":   1,
+	"MAXBFT": 3,
+	"RAFT":   4,
+}
+
+// 可调整的节点配置项
+type ConfigSetting struct {
+	Name  string
+	File  string   // 相对节点配置目录的文件
+	Path  []string // YAML 中的键路径
+	Parse func(value string) (interface{}, error)
+}
+
+func parseInt(value string) (interface{}, error) {
+	return strconv.Atoi(value)
+}
+
+func parseBool(value string) (interface{}, error) {
+	return strconv.ParseBool(value)
+}
+
+func parseConsensus(value string) (interface{}, error) {
+	if t, ok := ConsensusTypes[strings.ToUpper(value)]; ok {
+		return t, nil
+	}
+	return nil, fmt.Errorf("unknown consensus type %s", value)
+}
+
+var ConfigSettings = []*ConfigSetting{
+	{Name: "consensus", File: chainConfigFile, Path: []string{"consensus", "type"}, Parse: parseConsensus},
+	{Name: "block_tx_capacity", File: chainConfigFile, Path: []string{"block", "block_tx_capacity"}, Parse: parseInt},
+	{Name: "block_interval", File: chainConfigFile, Path: []string{"block", "block_interval"}, Parse: parseInt},
+	{Name: "block_size", File: chainConfigFile, Path: []string{"block", "block_size"}, Parse: parseInt},
+	{Name: "scheduler_timeout", File: chainConfigFile, Path: []string{"core", "tx_scheduler_timeout"}, Parse: parseInt},
+	{Name: "conflicts_bit_window", File: chainConfigFile, Path: []string{"core", "enable_conflicts_bit_window"}, Parse: parseBool},
+	{Name: "sender_group", File: chainConfigFile, Path: []string{"core", "enable_sender_group"}, Parse: parseBool},
+	{Name: "rwset_log", File: nodeConfigFile, Path: []string{"scheduler", "rwset_log"}, Parse: parseBool},
+}
+
+func LookupConfigSetting(name string) (*ConfigSetting, error) {
+	for _, setting := range ConfigSettings {
+		if setting.Name == name {
+			return setting, nil
+		}
+	}
+
+	names := make([]string, 0, len(ConfigSettings))
+	for _, setting := range ConfigSettings {
+		names = append(names, setting.Name)
+	}
+	return nil, fmt.Errorf("unknown config setting %s (available: %s)", name, strings.Join(names, ", "))
+}
+
+// 配置项取值所需的节点数，SOLO 只启动一个节点，MaxBFT 至少需要四个节点
+// 返回 0 表示不限制；Public 认证模式下只支持 TBFT
+func RequiredNodes(values map[string]string, available int) (int, error) {
+	consensus, ok := values["consensus"]
+	if !ok {
+		return 0, nil
+	}
+
+	if AuthType == sdk.Public && strings.ToUpper(consensus) != "TBFT" {
+		return 0, fmt.Errorf("%s is not supported in public auth mode, only TBFT", consensus)
+	}
+
+	switch strings.ToUpper(consensus) {
+	case "SOLO":
+		return 1, nil
+	case "MAXBFT":
+		if available > 0 && available < 4 {
+			return 0, fmt.Errorf("MAXBFT requires at least 4 nodes")
+		}
+	}
+	return 0, nil
+}
+
+// GenerateNodeConfigs 将 baseDir 中的 node1..nodeN 配置复制到 targetDir，并按 values 改写配置项
+func GenerateNodeConfigs(baseDir, targetDir string, values map[string]string) error {
+	names, err := nodeConfigNames(baseDir)
+	if err != nil {
+		return err
+	}
+
+	// 按名称排序保证各节点的改写顺序一致
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, name := range names {
+		dir := filepath.Join(targetDir, name)
+		if err := os.RemoveAll(dir); err != nil {
+			return err
+		}
+		if _, err := copyDir(filepath.Join(baseDir, name), dir); err != nil {
+			return fmt.Errorf("failed to copy config of %s: %v", name, err)
+		}
+
+		for _, key := range keys {
+			setting, err := LookupConfigSetting(key)
+			if err != nil {
+				return err
+			}
+			value, err := setting.Parse(values[key])
+			if err != nil {
+				return fmt.Errorf("invalid value %q of %s: %v", values[key], key, err)
+			}
+			if err := setYAMLValue(filepath.Join(dir, setting.File), setting.Path, value); err != nil {
+				return fmt.Errorf("failed to set %s of %s: %v", key, name, err)
+			}
+		}
+
+		if err := alignTxBatchSize(dir); err != nil {
+			return fmt.Errorf("failed to align batch size of %s: %v", name, err)
+		}
+	}
+
+	// SOLO 只启动 node1，共识节点列表中只保留 node1，否则链配置中的其余共识节点永远不会上线
+	if consensus, ok := values["consensus"]; ok && strings.ToUpper(consensus) == "SOLO" {
+		if err := keepFirstConsensusNode(targetDir, names); err != nil {
+			return fmt.Errorf("failed to trim consensus nodes: %v", err)
+		}
+	}
+	return nil
+}
+
+// 各节点链配置中的共识节点列表只保留第一个节点（node1）所在组织的第一个节点
+func keepFirstConsensusNode(dir string, names []string) error {
+	first := names[0]
+	for _, name := range names {
+		if dirNumber(name) < dirNumber(first) {
+			first = name
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, first, nodeConfigFile))
+	if err != nil {
+		return err
+	}
+	var config struct {
+		Node struct {
+			OrgID string `yaml:"org_id"`
+		} `yaml:"node"`
+	}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return fmt.Errorf("failed to parse %s of %s: %v", nodeConfigFile, first, err)
+	}
+
+	chain, err := readChainConfig(filepath.Join(dir, first, chainConfigFile))
+	if err != nil {
+		return err
+	}
+	var node *consensusNode
+	for _, n := range chain.Consensus.Nodes {
+		if n.OrgID == config.Node.OrgID && len(n.NodeIDs) > 0 {
+			node = n
+			break
+		}
+	}
+	if node == nil {
+		return fmt.Errorf("org %s of %s is not a consensus org", config.Node.OrgID, first)
+	}
+
+	body := []string{fmt.Sprintf("- org_id: %q", node.OrgID), "  node_id:", fmt.Sprintf("    - %q", node.NodeIDs[0])}
+	for _, name := range names {
+		if err := replaceYAMLSection(filepath.Join(dir, name, chainConfigFile), []string{"consensus", "nodes"}, body); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 配置目录中的节点配置 node1..nodeN
+func nodeConfigNames(baseDir string) ([]string, error) {
+	entries, err := os.ReadDir(baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read node config dir: %v", err)
+	}
+
+	names := make([]string, 0)
+	for _, entry := range entries {
+		if entry.IsDir() && strings.HasPrefix(entry.Name(), "node") {
+			names = append(names, entry.Name())
+		}
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no node config found in %s", baseDir)
+	}
+	return names, nil
+}
+
+// 区块容量须为交易池批大小的整数倍，否则将批大小设为区块容量
+func alignTxBatchSize(dir string) error {
+	capacity, ok, err := getYAMLInt(filepath.Join(dir, chainConfigFile), []string{"block", "block_tx_capacity"})
+	if err != nil || !ok {
+		return err
+	}
+	batch, ok, err := getYAMLInt(filepath.Join(dir, nodeConfigFile), []string{"txpool", "batch_max_size"})
+	if err != nil || !ok || batch <= 0 || capacity%batch == 0 {
+		return err
+	}
+	return setYAMLValue(filepath.Join(dir, nodeConfigFile), []string{"txpool", "batch_max_size"}, capacity)
+}
+
+// 按缩进定位键路径所在的行并替换其值，保留文件中的注释与格式
+func setYAMLValue(filePath string, path []string, value interface{}) error {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+
+	lines := strings.Split(string(data), "\n")
+	i, indent, err := findYAMLKey(lines, path)
+	if err != nil {
+		return fmt.Errorf("%v in %s", err, filePath)
+	}
+
+	trimmed := strings.TrimSpace(lines[i])
+	colon := strings.Index(trimmed, ":")
+	comment := ""
+	if index := strings.Index(trimmed[colon+1:], " #"); index >= 0 {
+		comment = " " + strings.TrimSpace(trimmed[colon+1+index:])
+	}
+	lines[i] = fmt.Sprintf("%s%s: %v%s", lines[i][:indent], trimmed[:colon], value, comment)
+	return os.WriteFile(filePath, []byte(strings.Join(lines, "\n")), 0644)
+}
+
+// 替换键路径下的整段内容（如列表），body 中的行相对该键缩进两格
+func replaceYAMLSection(filePath string, path []string, body []string) error {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+
+	lines := strings.Split(string(data), "\n")
+	i, indent, err := findYAMLKey(lines, path)
+	if err != nil {
+		return fmt.Errorf("%v in %s", err, filePath)
+	}
+
+	// 段落到下一个缩进不大于该键的行为止，同级的列表项仍属于该段
+	end := i
+	for j := i + 1; j < len(lines); j++ {
+		trimmed := strings.TrimSpace(lines[j])
+		if trimmed == "" {
+			continue
+		}
+		lineIndent := len(lines[j]) - len(strings.TrimLeft(lines[j], " "))
+		if lineIndent < indent || (lineIndent == indent && !strings.HasPrefix(trimmed, "-")) {
+			break
+		}
+		end = j
+	}
+
+	prefix := strings.Repeat(" ", indent+2)
+	section := make([]string, 0, len(body))
+	for _, line := range body {
+		section = append(section, prefix+line)
+	}
+
+	result := append(append(append([]string{}, lines[:i+1]...), section...), lines[end+1:]...)
+	return os.WriteFile(filePath, []byte(strings.Join(result, "\n")), 0644)
+}
+
+// 返回键路径所在的行号及其缩进，忽略注释与列表项
+func findYAMLKey(lines []string, path []string) (int, int, error) {
+	type level struct {
+		indent int
+		key    string
+	}
+	stack := make([]level, 0)
+
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "-") {
+			continue
+		}
+		colon := strings.Index(trimmed, ":")
+		if colon < 0 {
+			continue
+		}
+
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		key := strings.Trim(trimmed[:colon], `"'`)
+		for len(stack) > 0 && stack[len(stack)-1].indent >= indent {
+			stack = stack[:len(stack)-1]
+		}
+		stack = append(stack, level{indent: indent, key: key})
+
+		if len(stack) != len(path) {
+			continue
+		}
+		matched := true
+		for j := range path {
+			if stack[j].key != path[j] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return i, indent, nil
+		}
+	}
+
+	return 0, 0, fmt.Errorf("%s not found", strings.Join(path, "."))
+}
+
+func getYAMLInt(filePath string, path []string) (int, bool, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return 0, false, err
+	}
+
+	var current interface{}
+	if err := yaml.Unmarshal(data, &current); err != nil {
+		return 0, false, err
+	}
+	for _, key := range path {
+		m, ok := current.(map[interface{}]interface{})
+		if !ok {
+			return 0, false, nil
+		}
+		if current, ok = m[key]; !ok {
+			return 0, false, nil
+		}
+	}
+
+	value, ok := current.(int)
+	return value, ok, nil
+}
@@ -0,0 +1,248 @@
+/*
+	本文件主要用于：
+
+	1. 保存本地集群的账本快照：停止全部节点，将各节点的 data 目录（账本、状态库、
+	   交易过滤器、虚拟机挂载目录等）复制到快照目录，再重新启动集群
+
+	2. 将集群恢复至快照：停止全部节点，使用快照替换各节点的 data 目录后重新启动，
+	   并确认区块高度与保存快照时一致，使每次实验都从相同的链状态开始
+
+	3. 节点重启后原有连接与交易结果订阅不再可用，重启后重新创建 SDK 客户端
+*/
+
+package nodecontrol
+
+import (
+	"TransactionRwset/utils"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	sdk "chainmaker.org/chainmaker/sdk-go/v2"
+)
+
+// 账本快照：各节点 data 目录的副本及保存时的区块高度
+type ledgerSnapshot struct {
+	dir    string
+	height uint64
+	size   int64
+}
+
+// HasLedgerSnapshot 是否已保存账本快照
+func (n *NodeController) HasLedgerSnapshot() bool {
+	return n.snapshot != nil
+}
+
+// LedgerSnapshotDir 账本快照目录，未保存快照时为空
+func (n *NodeController) LedgerSnapshotDir() string {
+	if n.snapshot == nil {
+		return ""
+	}
+	return n.snapshot.dir
+}
+
+// SnapshotLedger 停止集群，将各节点账本数据保存至 dir 后重新启动集群
+func (n *NodeController) SnapshotLedger(dir string) error {
+	if n.cluster == nil {
+		return fmt.Errorf("no local cluster started")
+	}
+
+	height, err := n.Client.GetCurrentBlockHeight()
+	if err != nil {
+		return fmt.Errorf("failed to query block height: %v", err)
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	var size int64
+	err = n.restartCluster(height, func(node *clusterNode) error {
+		written, err := copyDir(filepath.Join(node.Dir, "data"), filepath.Join(dir, node.Name))
+		if err != nil {
+			return fmt.Errorf("failed to snapshot %s: %v", node.Name, err)
+		}
+		size += written
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	n.snapshot = &ledgerSnapshot{dir: dir, height: height, size: size}
+
+	emitClusterEvent(utils.LevelInfo, utils.EventLedgerSnapshot, fmt.Sprintf("ledger snapshot at height %d saved to %s (%d bytes) in %v", height, dir, size, time.Since(start).Round(time.Millisecond)),
+		map[string]interface{}{"dir": dir, "height": height, "bytes": size, "duration_ms": time.Since(start).Milliseconds()})
+	return nil
+}
+
+// RestoreLedger 停止集群，使用账本快照替换各节点数据后重新启动集群
+func (n *NodeController) RestoreLedger() error {
+	if n.snapshot == nil {
+		return fmt.Errorf("no ledger snapshot saved")
+	}
+	if n.cluster == nil {
+		return fmt.Errorf("no local cluster started")
+	}
+
+	start := time.Now()
+	err := n.restartCluster(n.snapshot.height, func(node *clusterNode) error {
+		data := filepath.Join(node.Dir, "data")
+		if err := os.RemoveAll(data); err != nil {
+			return fmt.Errorf("failed to remove data of %s: %v", node.Name, err)
+		}
+		if _, err := copyDir(filepath.Join(n.snapshot.dir, node.Name), data); err != nil {
+			return fmt.Errorf("failed to restore %s: %v", node.Name, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	emitClusterEvent(utils.LevelInfo, utils.EventLedgerRestore, fmt.Sprintf("ledger restored to height %d in %v", n.snapshot.height, time.Since(start).Round(time.Millisecond)),
+		map[string]interface{}{"dir": n.snapshot.dir, "height": n.snapshot.height, "duration_ms": time.Since(start).Milliseconds()})
+	return nil
+}
+
+// RemoveLedgerSnapshot 删除账本快照目录
+func (n *NodeController) RemoveLedgerSnapshot() error {
+	if n.snapshot == nil {
+		return nil
+	}
+	dir := n.snapshot.dir
+	n.snapshot = nil
+	return os.RemoveAll(dir)
+}
+
+// 停止全部节点，对每个节点执行 fn 后重新启动并等待就绪，确认区块高度为 expected
+// 就绪检查不提交交易，在此之前账本不会增加区块；失败时未能启动的节点标记为崩溃，由 CheckCluster 报告
+func (n *NodeController) restartCluster(expected uint64, fn func(node *clusterNode) error) error {
+	c := n.cluster
+	c.setStopping(true)
+
+	errs := make([]string, 0)
+	for _, node := range c.nodes {
+		if err := node.halt(); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	c.setStopping(false)
+
+	for _, node := range c.nodes {
+		if len(errs) == 0 {
+			if err := fn(node); err != nil {
+				errs = append(errs, err.Error())
+			}
+		}
+		if len(errs) == 0 {
+			if err := c.launch(node); err != nil {
+				errs = append(errs, err.Error())
+			}
+		}
+
+		if len(errs) > 0 && node.cmd == nil {
+			c.mu.Lock()
+			node.Crashed = true
+			node.ExitError = "not restarted"
+			c.mu.Unlock()
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to restart cluster: %s", strings.Join(errs, "; "))
+	}
+
+	if err := n.reconnect(); err != nil {
+		return err
+	}
+	if err := n.reconnectNodes(); err != nil {
+		return err
+	}
+	if err := n.WaitReady(c.config.ReadyTimeout); err != nil {
+		return err
+	}
+	return n.checkHeight(expected)
+}
+
+// 重新创建 SDK 客户端，替换重启前的客户端
+func (n *NodeController) reconnect() error {
+	client, err := sdk.NewChainClient(
+		sdk.WithConfPath(sdkConfPath),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create chain client: %v", err)
+	}
+
+	if n.Client != nil {
+		n.Client.Stop()
+	}
+	n.Client = client
+	return nil
+}
+
+// 确认重启后的区块高度与预期一致
+func (n *NodeController) checkHeight(expected uint64) error {
+	height, err := n.Client.GetCurrentBlockHeight()
+	if err != nil {
+		return fmt.Errorf("failed to query block height: %v", err)
+	}
+	if height != expected {
+		return fmt.Errorf("block height is %d after restart, expected %d", height, expected)
+	}
+	return nil
+}
+
+// 复制目录，保留文件权限与符号链接，返回复制的字节数
+func copyDir(src, dst string) (int64, error) {
+	var size int64
+	err := filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		switch {
+		case info.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm())
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case !info.Mode().IsRegular():
+			// 虚拟机挂载目录中的 socket 等文件由容器重新创建
+			return nil
+		}
+
+		written, err := copyFile(path, target, info.Mode().Perm())
+		size += written
+		return err
+	})
+	return size, err
+}
+
+func copyFile(src, dst string, perm os.FileMode) (int64, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return 0, err
+	}
+	written, err := io.Copy(out, in)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	return written, err
+}
@@ -89,49 +89,56 @@ var users = map[string]*User{
 }
 var permissionedPkUsers = map[string]*PermissionedPkUsers{
 	"org1client1": {
-		"../../testdata/crypto-config-pk/permissioned-with-key/wx-org1/user/client1/client1.key",
+		"./nodeControl/chainmaker/chainmaker-go/config-pk/permissioned-with-key/wx-org1/public-key/user/client1/client1.key",
 		OrgId1,
 	},
 	"org2client1": {
-		"../../testdata/crypto-config-pk/permissioned-with-key/wx-org2/user/client1/client1.key",
+		"./nodeControl/chainmaker/chainmaker-go/config-pk/permissioned-with-key/wx-org2/public-key/user/client1/client1.key",
 		OrgId2,
 	},
 	"org1admin1": {
-		"../../testdata/crypto-config-pk/permissioned-with-key/wx-org1/user/admin1/admin1.key",
+		"./nodeControl/chainmaker/chainmaker-go/config-pk/permissioned-with-key/wx-org1/public-key/user/admin1/admin1.key",
 		OrgId1,
 	},
 	"org2admin1": {
-		"../../testdata/crypto-config-pk/permissioned-with-key/wx-org2/user/admin1/admin1.key",
+		"./nodeControl/chainmaker/chainmaker-go/config-pk/permissioned-with-key/wx-org2/public-key/user/admin1/admin1.key",
 		OrgId2,
 	},
 	"org3admin1": {
-		"../../testdata/crypto-config-pk/permissioned-with-key/wx-org3/user/admin1/admin1.key",
+		"./nodeControl/chainmaker/chainmaker-go/config-pk/permissioned-with-key/wx-org3/public-key/user/admin1/admin1.key",
 		OrgId3,
 	},
 	"org4admin1": {
-		"../../testdata/crypto-config-pk/permissioned-with-key/wx-org4/user/admin1/admin1.key",
+		"./nodeControl/chainmaker/chainmaker-go/config-pk/permissioned-with-key/wx-org4/public-key/user/admin1/admin1.key",
 		OrgId4,
 	},
+	"org5admin1": {
+		"./nodeControl/chainmaker/chainmaker-go/config-pk/permissioned-with-key/wx-org5/public-key/user/admin1/admin1.key",
+		OrgId5,
+	},
 }
 
 var pkUsers = map[string]*PkUsers{
 	"org1client1": {
-		"../../testdata/crypto-config-pk/public/user/user1/user1.key",
+		"./nodeControl/chainmaker/chainmaker-go/config-pk/public/user/user1/user1.key",
 	},
 	"org2client1": {
-		"../../testdata/crypto-config-pk/public/user/user2/user2.key",
+		"./nodeControl/chainmaker/chainmaker-go/config-pk/public/user/user2/user2.key",
 	},
 	"org1admin1": {
-		"../../testdata/crypto-config-pk/public/admin/admin1/admin1.key",
+		"./nodeControl/chainmaker/chainmaker-go/config-pk/public/admin/admin1/admin1.key",
 	},
 	"org2admin1": {
-		"../../testdata/crypto-config-pk/public/admin/admin2/admin2.key",
+		"./nodeControl/chainmaker/chainmaker-go/config-pk/public/admin/admin2/admin2.key",
 	},
 	"org3admin1": {
-		"../../testdata/crypto-config-pk/public/admin/admin3/admin3.key",
+		"./nodeControl/chainmaker/chainmaker-go/config-pk/public/admin/admin3/admin3.key",
 	},
 	"org4admin1": {
-		"../../testdata/crypto-config-pk/public/admin/admin4/admin4.key",
+		"./nodeControl/chainmaker/chainmaker-go/config-pk/public/admin/admin4/admin4.key",
+	},
+	"org5admin1": {
+		"./nodeControl/chainmaker/chainmaker-go/config-pk/public/admin/admin5/admin5.key",
 	},
 }
 
@@ -0,0 +1,98 @@
+package picture
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/fogleman/gg"
+)
+
+const (
+	fontSize = 20                  // 字体大小
+	fontPath = "picture/Arial.ttf" // 字体路径
+	margin   = 70                  // 图片四周的边缘空间
+
+	heatCellSize   = 110  // 热力图每个方格的边长
+	heatLabelSpace = 220  // 左侧与上侧函数名所占空间
+	heatMinWidth   = 1100 // 保证图例能够完整展示的最小宽度
+)
+
+// 热力图中一个函数对的信息
+type HeatCell struct {
+	Similarity float64 // 最大读写集相似度
+	Kind       string  // 冲突类型缩写，如 RW、WW
+	Confirmed  bool    // 冲突是否已被实际执行确认
+	Predicted  bool    // 是否为可变异、可能冲突的函数对
+}
+
+// DrawConflictHeatmap 生成函数 × 函数的冲突热力图
+func DrawConflictHeatmap(funcNames []string, cells [][]HeatCell, outputPath string) error {
+	n := len(funcNames)
+	imageWidth := n*heatCellSize + heatLabelSpace + margin
+	if imageWidth < heatMinWidth {
+		imageWidth = heatMinWidth
+	}
+	imageHeight := n*heatCellSize + heatLabelSpace + margin*2
+
+	dc := gg.NewContext(imageWidth, imageHeight)
+	dc.SetColor(color.White)
+	dc.Clear()
+
+	if err := dc.LoadFontFace(fontPath, fontSize); err != nil {
+		return err
+	}
+
+	// 绘制函数名
+	dc.SetColor(color.Black)
+	for i, name := range funcNames {
+		center := float64(heatLabelSpace + i*heatCellSize + heatCellSize/2)
+		dc.DrawStringAnchored(name, float64(heatLabelSpace-10), center, 1, 0.5)
+
+		dc.Push()
+		dc.RotateAbout(gg.Radians(-45), center, float64(heatLabelSpace-10))
+		dc.DrawStringAnchored(name, center, float64(heatLabelSpace-10), 0, 0.5)
+		dc.Pop()
+	}
+
+	for i := range cells {
+		for j, cell := range cells[i] {
+			x := float64(heatLabelSpace + j*heatCellSize)
+			y := float64(heatLabelSpace + i*heatCellSize)
+
+			// 相似度越高颜色越深，已确认的冲突使用红色
+			switch {
+			case cell.Confirmed:
+				dc.SetColor(color.RGBA{231, 76, 60, 255})
+			case cell.Predicted || cell.Similarity > 0:
+				alpha := uint8(40 + cell.Similarity*215)
+				dc.SetColor(color.NRGBA{230, 126, 34, alpha})
+			default:
+				dc.SetColor(color.RGBA{244, 244, 244, 255})
+			}
+			dc.DrawRectangle(x, y, heatCellSize, heatCellSize)
+			dc.Fill()
+
+			dc.SetColor(color.Black)
+			dc.SetLineWidth(1)
+			dc.DrawRectangle(x, y, heatCellSize, heatCellSize)
+			dc.Stroke()
+
+			if cell.Similarity > 0 || cell.Confirmed || cell.Predicted {
+				status := "P"
+				if cell.Confirmed {
+					status = "C"
+				} else if !cell.Predicted {
+					status = "-"
+				}
+				dc.DrawStringAnchored(fmt.Sprintf("%.2f", cell.Similarity), x+heatCellSize/2, y+heatCellSize/3, 0.5, 0.5)
+				dc.DrawStringAnchored(status+" "+cell.Kind, x+heatCellSize/2, y+heatCellSize*2/3, 0.5, 0.5)
+			}
+		}
+	}
+
+	// 图例
+	legend := "C: confirmed conflict   P: predicted (mutable pair)   RW: read-write   WW: write-write"
+	dc.DrawStringAnchored(legend, float64(heatLabelSpace), float64(heatLabelSpace+n*heatCellSize+margin), 0, 0.5)
+
+	return dc.SavePNG(outputPath)
+}
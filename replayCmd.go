@@ -0,0 +1,56 @@
+package main
+
+import (
+	"TransactionRwset/engine"
+	"TransactionRwset/fuzz"
+	"TransactionRwset/utils"
+	"flag"
+	"fmt"
+)
+
+// replay 子命令，返回进程退出码
+// 用法: replay -pool <func_pair_seeds_pool.json> -seed <index|id> [-n N] [-concurrent] [-cmc]
+func replayCommand(args []string) int {
+	flags := flag.NewFlagSet("replay", flag.ExitOnError)
+	opts := &fuzz.ReplayOptions{}
+	flags.StringVar(&opts.PoolFile, "pool", "", "Path to a saved func_pair_seeds_pool_*.json")
+	flags.StringVar(&opts.Selector, "seed", "0", "Index of the pair in the pool file (conflict, mutate, retired order), a pair ID or a single seed ID")
+	flags.IntVar(&opts.Times, "n", 1, "Number of times to replay the seed")
+	flags.BoolVar(&opts.Concurrent, "concurrent", false, "Send the two transactions of a pair concurrently instead of one after another")
+	flags.BoolVar(&opts.Minimized, "minimized", false, "Replay the minimized form of the pair if it has one")
+	flags.BoolVar(&opts.Cmc, "cmc", false, "Print the equivalent cmc command lines")
+	flags.StringVar(&opts.ChainID, "chain-id", "chain1", "Chain ID used in the printed cmc commands")
+	contractPath := flags.String("contract", defaultContractPath, "Path to the contract source (.go), EVM ABI (.abi) or WASM module (.wasm) the pool was generated from")
+	startChain := flags.Bool("start", true, "Start the local cluster and deploy the contract before replaying (false uses a running chain)")
+	registerClusterFlags(flags)
+	registerBuildFlags(flags)
+	logLevel := flags.String("log-level", utils.DefaultLogLevel.String(), "Minimum level of events written to the event log (debug, info, warn, error)")
+	flags.Parse(args)
+
+	if opts.PoolFile == "" {
+		fmt.Println("replay: -pool is required")
+		flags.Usage()
+		return 2
+	}
+
+	level, err := utils.ParseLogLevel(*logLevel)
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	utils.DefaultLogLevel = level
+
+	result, err := engine.Replay(*contractPath, *startChain, opts)
+	if err != nil {
+		fmt.Printf("Error replaying seed: %v\n", err)
+	} else {
+		fmt.Print(result.String())
+		fmt.Printf("Replay result saved to: %s\n", utils.Log.BaseDir)
+	}
+	engine.Stop()
+
+	if err != nil {
+		return 1
+	}
+	return 0
+}
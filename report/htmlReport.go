@@ -0,0 +1,765 @@
+/*
+	本文件主要用于：
+
+	根据一次测试（result/<contract>_<timestamp> 目录）的全部产物，生成单个离线可用的 HTML 报告，包括：
+			a. 合约元信息
+			b. 参数推断类型
+			c. 函数对冲突矩阵
+			d. 每个冲突种子的输入及发生冲突的 key
+			e. 各冲突实验的图表与交易结果统计表
+			f. 原始文件链接
+
+	报告中的图片均以 base64 形式内嵌，不依赖任何外部资源，可随时由已有结果目录重新生成
+*/
+
+package report
+
+import (
+	"TransactionRwset/fuzz"
+	"TransactionRwset/utils"
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// 报告文件名，生成于结果目录下
+const ReportFileName = "report.html"
+
+// 实验目录下各比例交易结果文件，与 fuzz.ConflictPairSeedExperiment 中的命名保持一致
+var ratioFiles = []string{"1_99", "30_70", "50_50", "70_30", "99_1"}
+
+type keyValue struct {
+	Key   string
+	Value string
+}
+
+type paramTypes struct {
+	Name           string
+	CandidateTypes []string
+	Confirm        bool
+	ConfirmValue   string
+}
+
+type matrixCell struct {
+	Similarity float64
+	Conflict   bool
+	Known      bool
+	Kind       string
+}
+
+type conflictMatrix struct {
+	FuncNames []string
+	Rows      [][]matrixCell
+}
+
+type conflictSeed struct {
+	Source       string
+	FuncOne      string
+	FuncTwo      string
+	InputOne     string
+	InputTwo     string
+	MinInputOne  string // 最小化后的输入，未最小化时为空
+	MinInputTwo  string
+	Similarity   float64
+	ConflictKeys []string
+}
+
+type outcomeRow struct {
+	Label  string
+	Total  int
+	Counts []int
+	Lost   int
+}
+
+type embeddedImage struct {
+	Name    string
+	DataURI template.URL
+}
+
+type experiment struct {
+	Name         string
+	Seed         *conflictSeed
+	OutcomeRows  []outcomeRow
+	Images       []embeddedImage
+	Files        []string
+	OutcomeError string
+	Nodes        *fuzz.NodeComparisons
+}
+
+type campaign struct {
+	Title         string
+	ResultDir     string
+	Contract      []keyValue
+	Params        []paramTypes
+	Matrix        *conflictMatrix
+	Overview      []embeddedImage
+	ConflictSeeds []*conflictSeed
+	Experiments   []*experiment
+	Mutators      []*utils.MutatorStat
+	Corpus        []*fuzz.CorpusStats
+	Gas           *fuzz.GasProfile
+	Latency       *fuzz.LatencyProfile
+	Events        []*fuzz.EventMismatch
+	Outcomes      []fuzz.TxOutcome
+	ConfigMatrix  *fuzz.MatrixResult
+	Envelope      *fuzz.EnvelopeReport
+	Sync          *fuzz.SyncStats
+	VersionDiff   *fuzz.VersionDiff
+	MatrixColumns []string
+	RawFiles      []string
+}
+
+// 根据结果目录生成 HTML 报告，返回报告路径
+func Generate(resultDir string) (string, error) {
+	info, err := os.Stat(resultDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to read result dir: %v", err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("%s is not a directory", resultDir)
+	}
+
+	c := &campaign{
+		Title:     filepath.Base(filepath.Clean(resultDir)),
+		ResultDir: resultDir,
+		Outcomes:  fuzz.TxOutcomes,
+	}
+
+	// 中断的运行没有渲染文本日志，根据事件流补齐
+	eventsPath := filepath.Join(resultDir, utils.EventLogFileName)
+	if _, err := os.Stat(filepath.Join(resultDir, string(utils.ExecutionLog)+".txt")); os.IsNotExist(err) {
+		if _, err := os.Stat(eventsPath); err == nil {
+			if err := utils.RenderEventLog(eventsPath, resultDir); err != nil {
+				fmt.Println(err)
+			}
+		}
+	}
+
+	summary, err := utils.LoadContractInfoSummary(filepath.Join(resultDir, utils.ContractInfoFileName))
+	if err != nil {
+		// 旧版本结果目录中没有合约信息文件，从执行日志中解析
+		c.Contract = parseExecutionInfo(filepath.Join(resultDir, string(utils.ExecutionLog)+".txt"))
+	} else {
+		c.Contract, c.Params = describeContract(summary)
+	}
+
+	// 随机种子，可通过 -seed 或 -replay 复现本次运行
+	if runSeed, err := utils.LoadRunSeed(resultDir); err == nil {
+		c.Contract = append(c.Contract, keyValue{"Seed", fmt.Sprint(runSeed.Seed)})
+		if runSeed.ReplayOf != "" {
+			c.Contract = append(c.Contract, keyValue{"ReplayOf", runSeed.ReplayOf})
+		}
+	}
+
+	pool := loadSeedsPool(resultDir)
+
+	c.Experiments, err = loadExperiments(resultDir)
+	if err != nil {
+		return "", err
+	}
+
+	c.ConflictSeeds = collectConflictSeeds(pool, c.Experiments)
+	c.Matrix = buildConflictMatrix(summary, pool, c.ConflictSeeds)
+	c.Overview = loadOverviewImages(resultDir)
+	c.Corpus = loadCorpusStats(resultDir, pool)
+	c.Mutators, _ = utils.LoadMutatorStats(filepath.Join(resultDir, utils.MutatorStatsFileName))
+	c.Gas, _ = fuzz.LoadGasProfile(filepath.Join(resultDir, fuzz.GasProfileFileName))
+	c.Latency, _ = fuzz.LoadLatencyProfile(filepath.Join(resultDir, fuzz.LatencyProfileFileName))
+	c.Events, _ = fuzz.LoadEventMismatches(filepath.Join(resultDir, fuzz.EventOracleFileName))
+	c.ConfigMatrix, _ = fuzz.LoadMatrixResultFromFile(filepath.Join(resultDir, utils.MatrixDirName, fuzz.MatrixResultFileName))
+	c.MatrixColumns = fuzz.MatrixColumns()
+	c.Envelope, _ = fuzz.LoadEnvelopeReportFromFile(filepath.Join(resultDir, fuzz.EnvelopeResultFileName))
+	c.Sync, _ = fuzz.LoadSyncStats(filepath.Join(resultDir, fuzz.SyncResultFileName))
+	c.VersionDiff, _ = fuzz.LoadVersionDiffFromFile(filepath.Join(resultDir, fuzz.VersionDiffFileName))
+
+	c.RawFiles, err = listRawFiles(resultDir)
+	if err != nil {
+		return "", err
+	}
+
+	reportPath := filepath.Join(resultDir, ReportFileName)
+	file, err := os.Create(reportPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to create report: %v", err)
+	}
+	defer file.Close()
+
+	if err := reportTemplate.Execute(file, c); err != nil {
+		return "", fmt.Errorf("failed to render report: %v", err)
+	}
+
+	return reportPath, nil
+}
+
+func toJSON(v interface{}) string {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+func describeContract(summary *utils.ContractInfoSummary) ([]keyValue, []paramTypes) {
+	funcNames := make([]string, 0, len(summary.ContractFuncMap))
+	for funcName := range summary.ContractFuncMap {
+		funcNames = append(funcNames, funcName)
+	}
+	sort.Strings(funcNames)
+
+	contract := []keyValue{
+		{"ContractName", summary.ContractName},
+		{"ContractPath", summary.ContractPath},
+		{"ContractByteCodePath", summary.ContractByteCodePath},
+	}
+	if summary.Runtime != "" {
+		contract = append(contract, keyValue{"Runtime", summary.Runtime})
+	}
+	if summary.StorageLayoutPath != "" {
+		contract = append(contract, keyValue{"StorageLayoutPath", summary.StorageLayoutPath})
+	}
+	if summary.InterfacePath != "" {
+		contract = append(contract, keyValue{"InterfacePath", summary.InterfacePath})
+	}
+	for _, funcName := range funcNames {
+		funcInfo := summary.ContractFuncMap[funcName]
+		contract = append(contract, keyValue{
+			Key:   "func " + funcName,
+			Value: fmt.Sprintf("invoke: %s, params: [%s]", funcInfo.InvokeName, strings.Join(funcInfo.ParamsNameList, ", ")),
+		})
+	}
+
+	paramNames := make([]string, 0, len(summary.ParamTypes))
+	for paramName := range summary.ParamTypes {
+		paramNames = append(paramNames, paramName)
+	}
+	sort.Strings(paramNames)
+
+	params := make([]paramTypes, 0, len(paramNames))
+	for _, paramName := range paramNames {
+		p := summary.ParamTypes[paramName]
+		params = append(params, paramTypes{
+			Name:           paramName,
+			CandidateTypes: p.CandidateTypes,
+			Confirm:        p.Confirm,
+			ConfirmValue:   toJSON(p.ConfirmValue),
+		})
+	}
+
+	return contract, params
+}
+
+// 从执行日志的"获取合约信息"部分解析出合约元信息
+func parseExecutionInfo(filePath string) []keyValue {
+	contract := make([]keyValue, 0)
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return contract
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 1024*1024), 64*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		// 去掉 "[2006-01-02 15:04:05] " 时间戳前缀
+		if idx := strings.Index(line, "] "); strings.HasPrefix(line, "[") && idx > 0 {
+			line = line[idx+2:]
+		}
+
+		for _, key := range []string{"ContractName", "ContractByteCodePath", "ContractFuncList"} {
+			if strings.HasPrefix(strings.TrimSpace(line), key) {
+				parts := strings.SplitN(line, ":", 2)
+				if len(parts) == 2 {
+					contract = append(contract, keyValue{key, strings.TrimSpace(parts[1])})
+				}
+			}
+		}
+	}
+
+	return contract
+}
+
+// 读取语料统计，优先使用运行结束时保存的统计，其次使用种子池中的语料
+func loadCorpusStats(resultDir string, pool *fuzz.FuncPairSeedsPool) []*fuzz.CorpusStats {
+	var stats []*fuzz.CorpusStats
+
+	data, err := os.ReadFile(filepath.Join(resultDir, fuzz.CorpusStatsFileName))
+	if err == nil && json.Unmarshal(data, &stats) == nil {
+		return stats
+	}
+
+	if pool != nil && pool.Corpus != nil {
+		return pool.Corpus.Stats()
+	}
+	return nil
+}
+
+// 读取结果目录下的交易对种子池文件
+func loadSeedsPool(resultDir string) *fuzz.FuncPairSeedsPool {
+	matches, _ := filepath.Glob(filepath.Join(resultDir, "func_pair_seeds_pool_*.json"))
+	if len(matches) == 0 {
+		return nil
+	}
+	sort.Strings(matches)
+
+	pool, err := fuzz.LoadPairSeedPoolFromFile(matches[len(matches)-1])
+	if err != nil {
+		fmt.Println(err)
+		return nil
+	}
+	return pool
+}
+
+func newConflictSeed(source string, seed *fuzz.FuncPairSeed) *conflictSeed {
+	c := &conflictSeed{
+		Source:       source,
+		FuncOne:      seed.SeedOne.FunctionName,
+		FuncTwo:      seed.SeedTwo.FunctionName,
+		InputOne:     toJSON(seed.SeedOne.FunctionInput),
+		InputTwo:     toJSON(seed.SeedTwo.FunctionInput),
+		Similarity:   seed.MaxSimilarity,
+		ConflictKeys: fuzz.ConflictKeys(seed.SeedOne, seed.SeedTwo),
+	}
+
+	if seed.Minimized != nil {
+		c.MinInputOne = toJSON(seed.Minimized.SeedOne.FunctionInput)
+		c.MinInputTwo = toJSON(seed.Minimized.SeedTwo.FunctionInput)
+	}
+	return c
+}
+
+// 实验目录中保存有交易对种子、执行结果或各比例的交易文件（旧版本结果目录只有交易文件），
+// 结果目录下的其他子目录（节点日志、快照等）不是实验
+func isExperimentDir(dir string) bool {
+	names := []string{fuzz.PairSeedFileName, fuzz.OutcomesFileName}
+	for _, ratio := range ratioFiles {
+		names = append(names, ratio+".json")
+	}
+	for _, name := range names {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func loadExperiments(resultDir string) ([]*experiment, error) {
+	entries, err := os.ReadDir(resultDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read result dir: %v", err)
+	}
+
+	experiments := make([]*experiment, 0)
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		dir := filepath.Join(resultDir, entry.Name())
+		if !isExperimentDir(dir) {
+			continue
+		}
+
+		exp := &experiment{Name: entry.Name()}
+
+		if seed, err := fuzz.LoadPairSeedFromFile(filepath.Join(dir, fuzz.PairSeedFileName)); err == nil {
+			exp.Seed = newConflictSeed("experiment", seed)
+		}
+
+		outcomes, err := loadOutcomes(dir)
+		if err != nil {
+			exp.OutcomeError = err.Error()
+		} else {
+			exp.OutcomeRows = outcomeRows(outcomes)
+		}
+		exp.Nodes, _ = fuzz.LoadNodeComparisonsFromFile(filepath.Join(dir, fuzz.NodeComparisonFileName))
+
+		files, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read experiment dir: %v", err)
+		}
+		for _, file := range files {
+			if file.IsDir() {
+				continue
+			}
+			exp.Files = append(exp.Files, filepath.ToSlash(filepath.Join(entry.Name(), file.Name())))
+
+			if image, ok := embedPNG(dir, file.Name()); ok {
+				exp.Images = append(exp.Images, image)
+			}
+		}
+
+		experiments = append(experiments, exp)
+	}
+
+	return experiments, nil
+}
+
+// 将 png 图片以 data URI 形式内嵌
+func embedPNG(dir, name string) (embeddedImage, bool) {
+	if filepath.Ext(name) != ".png" {
+		return embeddedImage{}, false
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return embeddedImage{}, false
+	}
+
+	return embeddedImage{
+		Name:    name,
+		DataURI: template.URL("data:image/png;base64," + base64.StdEncoding.EncodeToString(data)),
+	}, true
+}
+
+// 结果目录顶层的概览图，如冲突热力图与热点 key 排名图
+func loadOverviewImages(resultDir string) []embeddedImage {
+	images := make([]embeddedImage, 0)
+
+	entries, err := os.ReadDir(resultDir)
+	if err != nil {
+		return images
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if image, ok := embedPNG(resultDir, entry.Name()); ok {
+			images = append(images, image)
+		}
+	}
+
+	return images
+}
+
+// 读取实验统计结果，旧版本结果目录中没有统计文件时，根据各比例的交易文件重新分类统计
+func loadOutcomes(dir string) (*fuzz.ExperimentOutcomes, error) {
+	outcomes, err := fuzz.LoadExperimentOutcomesFromFile(filepath.Join(dir, fuzz.OutcomesFileName))
+	if err == nil {
+		return outcomes, nil
+	}
+
+	outcomes = &fuzz.ExperimentOutcomes{}
+	for _, ratio := range ratioFiles {
+		data, err := os.ReadFile(filepath.Join(dir, ratio+".json"))
+		if err != nil {
+			continue
+		}
+
+		var txs []*fuzz.Tx
+		if err := json.Unmarshal(data, &txs); err != nil {
+			return nil, fmt.Errorf("failed to parse %s.json: %v", ratio, err)
+		}
+
+		fuzz.ClassifyTxs(txs, true)
+		outcomes.Add(fuzz.NewOutcomeTable(strings.Replace(ratio, "_", ":", 1), txs))
+	}
+
+	if len(outcomes.Tables) == 0 {
+		return nil, fmt.Errorf("no transaction results found")
+	}
+
+	return outcomes, nil
+}
+
+func outcomeRows(outcomes *fuzz.ExperimentOutcomes) []outcomeRow {
+	rows := make([]outcomeRow, 0, len(outcomes.Tables)+1)
+	for _, table := range append(append([]*fuzz.OutcomeTable{}, outcomes.Tables...), outcomes.Total()) {
+		row := outcomeRow{
+			Label: table.Label,
+			Total: table.Total,
+			Lost:  table.Lost(),
+		}
+		for _, outcome := range fuzz.TxOutcomes {
+			row.Counts = append(row.Counts, table.Counts[outcome])
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func collectConflictSeeds(pool *fuzz.FuncPairSeedsPool, experiments []*experiment) []*conflictSeed {
+	seeds := make([]*conflictSeed, 0)
+
+	if pool != nil {
+		for _, seed := range pool.ConflictList {
+			seeds = append(seeds, newConflictSeed("initial pool", seed))
+		}
+	}
+
+	for _, exp := range experiments {
+		if exp.Seed != nil {
+			seeds = append(seeds, exp.Seed)
+		}
+	}
+
+	return seeds
+}
+
+func buildConflictMatrix(summary *utils.ContractInfoSummary, pool *fuzz.FuncPairSeedsPool, conflictSeeds []*conflictSeed) *conflictMatrix {
+	nameSet := make(map[string]bool)
+	if summary != nil {
+		for funcName := range summary.ContractFuncMap {
+			nameSet[funcName] = true
+		}
+	}
+
+	type pairKey struct{ a, b string }
+	cells := make(map[pairKey]*matrixCell)
+	update := func(a, b string, similarity float64, conflict bool, kind string) {
+		nameSet[a], nameSet[b] = true, true
+		for _, key := range []pairKey{{a, b}, {b, a}} {
+			cell, ok := cells[key]
+			if !ok {
+				cell = &matrixCell{Known: true}
+				cells[key] = cell
+			}
+			cell.Similarity = utils.Max(cell.Similarity, similarity)
+			cell.Conflict = cell.Conflict || conflict
+			if kind != "" {
+				cell.Kind = kind
+			}
+		}
+	}
+
+	if pool != nil && pool.ConflictMap != nil {
+		for _, pair := range pool.ConflictMap.Pairs {
+			if pair.Status != fuzz.ConflictNone {
+				update(pair.FuncOne, pair.FuncTwo, pair.BestSimilarity, pair.Status == fuzz.ConflictConfirmed, pair.Kind())
+			}
+		}
+	} else if pool != nil {
+		for _, seed := range pool.MutateList {
+			update(seed.SeedOne.FunctionName, seed.SeedTwo.FunctionName, seed.MaxSimilarity, false, "")
+		}
+	}
+	for _, seed := range conflictSeeds {
+		update(seed.FuncOne, seed.FuncTwo, 1.0, true, "")
+	}
+
+	funcNames := make([]string, 0, len(nameSet))
+	for funcName := range nameSet {
+		funcNames = append(funcNames, funcName)
+	}
+	sort.Strings(funcNames)
+
+	matrix := &conflictMatrix{FuncNames: funcNames}
+	for _, a := range funcNames {
+		row := make([]matrixCell, 0, len(funcNames))
+		for _, b := range funcNames {
+			if cell, ok := cells[pairKey{a, b}]; ok {
+				row = append(row, *cell)
+			} else {
+				row = append(row, matrixCell{})
+			}
+		}
+		matrix.Rows = append(matrix.Rows, row)
+	}
+
+	return matrix
+}
+
+func listRawFiles(resultDir string) ([]string, error) {
+	files := make([]string, 0)
+	err := filepath.WalkDir(resultDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || d.Name() == ReportFileName {
+			return nil
+		}
+		rel, err := filepath.Rel(resultDir, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list result files: %v", err)
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"percent": func(v float64) string { return fmt.Sprintf("%.0f%%", v*100) },
+	"seconds": func(ms int64) string { return fmt.Sprintf("%.1fs", float64(ms)/1000) },
+	"heat": func(cell matrixCell) template.CSS {
+		switch {
+		case !cell.Known:
+			return "#f4f4f4"
+		case cell.Conflict:
+			return "#e74c3c"
+		default:
+			// 相似度越高颜色越深
+			return template.CSS(fmt.Sprintf("rgba(230, 126, 34, %.2f)", 0.15+cell.Similarity*0.85))
+		}
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+h1, h2, h3 { border-bottom: 1px solid #ddd; padding-bottom: 4px; }
+table { border-collapse: collapse; margin: 1em 0; font-size: 13px; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; }
+th { background: #f0f0f0; }
+td.num { text-align: right; }
+td.cell { text-align: center; min-width: 48px; }
+pre { margin: 0; white-space: pre-wrap; word-break: break-all; font-size: 12px; }
+img { max-width: 100%; border: 1px solid #ddd; margin: 4px 0; }
+.muted { color: #888; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p class="muted">result dir: {{.ResultDir}}</p>
+
+<h2>合约信息</h2>
+<table>
+{{range .Contract}}<tr><th>{{.Key}}</th><td>{{.Value}}</td></tr>
+{{else}}<tr><td class="muted">no contract information</td></tr>
+{{end}}</table>
+
+<h2>参数类型</h2>
+{{if .Params}}<table>
+<tr><th>param</th><th>candidate types</th><th>confirm</th><th>confirm value</th></tr>
+{{range .Params}}<tr><td>{{.Name}}</td><td>{{range .CandidateTypes}}{{.}}<br>{{end}}</td><td>{{.Confirm}}</td><td><pre>{{.ConfirmValue}}</pre></td></tr>
+{{end}}</table>
+{{else}}<p class="muted">no parameter type information</p>{{end}}
+
+<h2>函数对冲突矩阵</h2>
+{{if .Matrix.FuncNames}}<table>
+<tr><th></th>{{range .Matrix.FuncNames}}<th>{{.}}</th>{{end}}</tr>
+{{range $i, $row := .Matrix.Rows}}<tr><th>{{index $.Matrix.FuncNames $i}}</th>{{range $row}}<td class="cell" style="background: {{heat .}}">{{if .Known}}{{percent .Similarity}}{{if .Kind}}<br>{{.Kind}}{{end}}{{end}}</td>{{end}}</tr>
+{{end}}</table>
+<p class="muted">red: conflict confirmed; orange: best read/write key similarity of mutable pairs; grey: no candidate pair</p>
+{{else}}<p class="muted">no function pair information</p>{{end}}
+{{range .Overview}}<p>{{.Name}}<br><img src="{{.DataURI}}" alt="{{.Name}}"></p>
+{{end}}
+
+<h2>冲突种子</h2>
+{{if .ConflictSeeds}}<table>
+<tr><th>source</th><th>function one</th><th>input one</th><th>function two</th><th>input two</th><th>conflict keys</th></tr>
+{{range .ConflictSeeds}}<tr><td>{{.Source}}</td><td>{{.FuncOne}}</td><td><pre>{{.InputOne}}</pre>{{if .MinInputOne}}minimized:<pre>{{.MinInputOne}}</pre>{{end}}</td><td>{{.FuncTwo}}</td><td><pre>{{.InputTwo}}</pre>{{if .MinInputTwo}}minimized:<pre>{{.MinInputTwo}}</pre>{{end}}</td><td>{{range .ConflictKeys}}<code>{{.}}</code><br>{{end}}</td></tr>
+{{end}}</table>
+{{else}}<p class="muted">no conflict seeds</p>{{end}}
+
+<h2>种子语料</h2>
+{{if .Corpus}}<table>
+<tr><th>kind</th><th>shapes</th><th>seeds</th><th>duplicates</th><th>shapes by function</th></tr>
+{{range .Corpus}}<tr><td>{{.Kind}}</td><td class="num">{{.Shapes}}</td><td class="num">{{.Seeds}}</td><td class="num">{{.Duplicates}}</td><td>{{range $name, $shapes := .ByFunction}}{{$name}}: {{$shapes}}<br>{{end}}</td></tr>
+{{end}}</table>
+{{else}}<p class="muted">no corpus statistics</p>{{end}}
+
+<h2>变异算子</h2>
+{{if .Mutators}}<table>
+<tr><th>mutator</th><th>weight</th><th>applied</th><th>improved</th><th>conflicts</th></tr>
+{{range .Mutators}}<tr><td>{{.Name}}</td><td class="num">{{.Weight}}</td><td class="num">{{.Applied}}</td><td class="num">{{.Improved}}</td><td class="num">{{.Conflicts}}</td></tr>
+{{end}}</table>
+{{else}}<p class="muted">no mutation statistics</p>{{end}}
+
+<h2>Gas</h2>
+{{if .Gas}}<p class="muted">invoke gas limit: {{.Gas.Limit}}</p>
+<table>
+<tr><th>function</th><th>samples</th><th>min</th><th>mean</th><th>max</th><th>out of gas</th><th>input correlation</th><th>state correlation</th><th>max gas input</th></tr>
+{{range .Gas.Functions}}<tr><td>{{.Function}}</td><td class="num">{{.Samples}}</td><td class="num">{{.Min}}</td><td class="num">{{.Mean}}</td><td class="num">{{.Max}}</td><td class="num">{{.OutOfGas}}</td><td class="num">{{printf "%.2f" .InputCorrelation}}</td><td class="num">{{printf "%.2f" .StateCorrelation}}</td><td><pre>{{.MaxInput}}</pre></td></tr>
+{{end}}</table>
+{{if .Gas.Anomalies}}<table>
+<tr><th>function</th><th>anomaly</th><th>gas used</th><th>input</th><th>message</th></tr>
+{{range .Gas.Anomalies}}<tr><td>{{.Function}}</td><td>{{.Kind}}</td><td class="num">{{.GasUsed}}</td><td><pre>{{.Input}}</pre></td><td>{{.Message}}</td></tr>
+{{end}}</table>
+{{else}}<p class="muted">no gas anomalies</p>{{end}}
+{{else}}<p class="muted">no gas statistics</p>{{end}}
+
+<h2>执行耗时</h2>
+{{if .Latency}}<table>
+<tr><th>function</th><th>probes</th><th>mean</th><th>p50</th><th>p95</th><th>max</th><th>timeouts</th><th>slowest input</th><th>timeout inputs</th></tr>
+{{range .Latency.Functions}}<tr><td>{{.Function}}{{if .TimeoutProne}}<br><b>timeout-prone</b>{{end}}</td><td class="num">{{.Samples}}</td><td class="num">{{.MeanMs}}ms</td><td class="num">{{.P50Ms}}ms</td><td class="num">{{.P95Ms}}ms</td><td class="num">{{.MaxMs}}ms</td><td class="num">{{.Timeouts}}</td><td><pre>{{.SlowestInput}}</pre></td><td>{{range .TimeoutInputs}}<pre>{{.Input}}</pre><span class="muted">{{.DurationMs}}ms {{.Message}}</span><br>{{end}}</td></tr>
+{{end}}</table>
+<p class="muted">timeout-prone functions are excluded from related path probing, gas search, mutation and conflict experiments</p>
+{{else}}<p class="muted">no latency statistics</p>{{end}}
+
+<h2>事件一致性</h2>
+{{if .Events}}<table>
+<tr><th>function</th><th>check</th><th>event</th><th>count</th><th>input</th><th>write set</th><th>message</th></tr>
+{{range .Events}}<tr><td>{{.Function}}</td><td>{{.Kind}}</td><td><code>{{.Event}}</code></td><td class="num">{{.Count}}</td><td><pre>{{.Input}}</pre></td><td>{{range .WriteSet}}<code>{{.}}</code><br>{{end}}</td><td>{{.Message}}</td></tr>
+{{end}}</table>
+{{else}}<p class="muted">no event mismatches</p>{{end}}
+
+{{if .ConfigMatrix}}<h2>配置矩阵</h2>
+<table>
+<tr>{{range .ConfigMatrix.Settings}}<th>{{.}}</th>{{end}}<th>seed</th>{{range $.MatrixColumns}}<th>{{.}}</th>{{end}}<th>duration</th><th>error</th></tr>
+{{range .ConfigMatrix.OrderedRows}}{{$row := .}}<tr>{{range $.ConfigMatrix.Settings}}<td>{{index $row.Values .}}</td>{{end}}<td>{{if eq .Seed "total"}}<b>total</b>{{else}}{{.Seed}}<br><span class="muted">{{.Functions}}</span>{{end}}</td>{{range .Counts}}<td class="num">{{.}}</td>{{end}}<td class="num">{{seconds .DurationMs}}</td><td>{{.Error}}</td></tr>
+{{end}}</table>
+{{end}}
+
+{{if .Sync}}<h2>分布式同步</h2>
+<table>
+<tr><th>worker</th><th>assigned pairs</th><th>published</th><th>imported</th><th>duplicates</th><th>experiments</th><th>claimed elsewhere</th></tr>
+<tr><td>{{.Sync.Worker}}</td><td class="num">{{.Sync.Assigned}}</td><td class="num">{{.Sync.Published}}</td><td class="num">{{.Sync.Imported}}</td><td class="num">{{.Sync.Duplicates}}</td><td class="num">{{.Sync.ClaimsWon}}</td><td class="num">{{.Sync.ClaimsLost}}</td></tr>
+</table>
+{{end}}
+
+{{if .Envelope}}<h2>交易信封</h2>
+<table>
+<tr><th>function</th><th>variant</th><th>stage</th><th>txs</th></tr>
+{{range .Envelope.Results}}<tr><td>{{.Function}}</td><td>{{.Variant}}<br><span class="muted">{{.Description}}</span></td><td>{{.Stage}}</td><td>{{range .Txs}}<code>{{.Stage}}</code> {{.Code}}{{if .ContractCode}} (contract {{.ContractCode}}){{end}} <span class="muted">{{.DurationMs}}ms {{.Message}}</span><br>{{end}}</td></tr>
+{{end}}</table>
+{{end}}
+
+{{if .VersionDiff}}<h2>版本对比</h2>
+<p>{{.VersionDiff.Contract}}: {{.VersionDiff.OldVersion}} <span class="muted">{{.VersionDiff.OldPath}}</span> → {{.VersionDiff.NewVersion}} <span class="muted">{{.VersionDiff.NewPath}}</span></p>
+<table>
+<tr><th>methods added</th><td>{{range .VersionDiff.MethodsAdded}}<code>{{.}}</code> {{end}}</td></tr>
+<tr><th>methods removed</th><td>{{range .VersionDiff.MethodsRemoved}}<code>{{.}}</code> {{end}}</td></tr>
+<tr><th>params changed</th><td>{{range .VersionDiff.ParamsChanged}}<code>{{.Method}}</code> {{.OldParams}} → {{.NewParams}}<br>{{end}}</td></tr>
+<tr><th>types changed</th><td>{{range .VersionDiff.TypesChanged}}<code>{{.Param}}</code> {{.OldTypes}} → {{.NewTypes}}<br>{{end}}</td></tr>
+<tr><th>seeds</th><td>{{range $change, $count := .VersionDiff.Counts}}{{$change}} {{$count}} {{end}}</td></tr>
+</table>
+<table>
+<tr><th>change</th><th>seed</th><th>old conflicts</th><th>new conflicts</th></tr>
+{{range .VersionDiff.Seeds}}{{if ne .Change "unchanged"}}<tr><td>{{.Change}}</td><td>{{.PairID}}<br><span class="muted">{{.Functions}}</span></td><td>{{range .Old.Signature}}<code>{{.}}</code><br>{{end}}<span class="muted">{{.Old.Skipped}} {{.Old.Codes}}</span></td><td>{{range .New.Signature}}<code>{{.}}</code><br>{{end}}<span class="muted">{{.New.Skipped}} {{.New.Codes}}</span></td></tr>
+{{end}}{{end}}</table>
+{{end}}
+
+<h2>冲突实验</h2>
+{{range .Experiments}}
+<h3>{{.Name}}</h3>
+{{if .Seed}}<p>{{.Seed.FuncOne}} × {{.Seed.FuncTwo}}</p>{{end}}
+{{if .OutcomeRows}}<table>
+<tr><th>ratio</th><th>total</th>{{range $.Outcomes}}<th>{{.}}</th>{{end}}<th>lost</th></tr>
+{{range .OutcomeRows}}<tr><td>{{.Label}}</td><td class="num">{{.Total}}</td>{{range .Counts}}<td class="num">{{.}}</td>{{end}}<td class="num">{{.Lost}}</td></tr>
+{{end}}</table>
+{{else}}<p class="muted">{{.OutcomeError}}</p>{{end}}
+{{if .Nodes}}<table>
+<tr><th>ratio</th><th>node</th><th>weight</th><th>sent</th><th>rejected</th><th>lost</th><th>rejection rate</th><th>divergent txs</th><th>divergent blocks</th></tr>
+{{range .Nodes.Comparisons}}{{$c := .}}{{range .Nodes}}<tr><td>{{$c.Label}}</td><td>{{.Node}}</td><td class="num">{{.Weight}}</td><td class="num">{{.Sent}}</td><td class="num">{{.Rejected}}</td><td class="num">{{.Lost}}</td><td class="num">{{percent .RejectionRate}}</td><td class="num">{{$c.DivergentTxs}}</td><td class="num">{{len $c.BlockDivergent}}</td></tr>
+{{end}}{{end}}</table>
+{{range .Nodes.Comparisons}}{{range .TxDivergences}}<p class="muted">{{.TxId}} sent to {{.SentTo}}: {{range .Views}}{{.Node}} on_chain={{.OnChain}} code={{.Code}} height={{.BlockHeight}}; {{end}}</p>
+{{end}}{{range .BlockDivergent}}<p class="muted">height {{.Height}}: {{range $node, $hash := .Hashes}}{{$node}}={{$hash}} {{end}}</p>
+{{end}}{{end}}{{end}}
+{{range .Images}}<p>{{.Name}}<br><img src="{{.DataURI}}" alt="{{.Name}}"></p>
+{{end}}
+<p>{{range .Files}}<a href="{{.}}">{{.}}</a> {{end}}</p>
+{{else}}<p class="muted">no conflict experiments</p>
+{{end}}
+
+<h2>原始文件</h2>
+<ul>
+{{range .RawFiles}}<li><a href="{{.}}">{{.}}</a></li>
+{{end}}</ul>
+</body>
+</html>
+`))
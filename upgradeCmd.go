@@ -0,0 +1,78 @@
+package main
+
+import (
+	"TransactionRwset/engine"
+	"TransactionRwset/fuzz"
+	"TransactionRwset/report"
+	"TransactionRwset/utils"
+	"flag"
+	"fmt"
+)
+
+// upgrade 子命令以该退出码表示两个版本之间存在新增或改变的冲突
+const exitConflictRegression = 3
+
+// upgrade 子命令，返回进程退出码
+// 用法: upgrade -contract <old.go> -new-contract <new.go> [-load <func_pair_seeds_pool.json>] [-version 2.0.0] [-max-seeds N]
+func upgradeCommand(args []string) int {
+	flags := flag.NewFlagSet("upgrade", flag.ExitOnError)
+	opts := &engine.UpgradeOptions{}
+	contractPath := flags.String("contract", defaultContractPath, "Path to the currently deployed version of the contract")
+	flags.StringVar(&opts.NewContractPath, "new-contract", "", "Path to the new version of the contract, deployed through an upgrade that keeps the contract state")
+	flags.StringVar(&opts.Version, "version", "2.0.0", "Contract version after the upgrade")
+	poolFile := flags.String("load", "", "Path to JSON file to load the saved pair seeds pool (empty generates it on the old version)")
+	flags.IntVar(&opts.MaxSeeds, "max-seeds", 0, "Number of pair seeds run on both versions (0 runs all)")
+	failOnRegression := flags.Bool("fail-on-regression", true, "Exit with code 3 when a conflict appeared or changed keys in the new version")
+	registerClusterFlags(flags)
+	registerBuildFlags(flags)
+	logLevel := flags.String("log-level", utils.DefaultLogLevel.String(), "Minimum level of events written to the event log (debug, info, warn, error)")
+	flags.Parse(args)
+
+	if opts.NewContractPath == "" {
+		fmt.Println("upgrade: -new-contract is required")
+		flags.Usage()
+		return 2
+	}
+
+	level, err := utils.ParseLogLevel(*logLevel)
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	utils.DefaultLogLevel = level
+
+	var pool *fuzz.FuncPairSeedsPool
+	if *poolFile != "" {
+		pool, err = fuzz.LoadPairSeedPoolFromFile(*poolFile)
+		if err != nil {
+			fmt.Printf("Error loading seed pool: %v\n", err)
+			return 1
+		}
+	}
+
+	diff, err := engine.RunUpgradeDiff(*contractPath, pool, opts)
+	if err != nil {
+		fmt.Printf("Error comparing contract versions: %v\n", err)
+	} else {
+		fmt.Print(diff.String())
+	}
+	engine.Stop()
+
+	if utils.Log != nil {
+		reportPath, err := report.Generate(utils.Log.BaseDir)
+		if err != nil {
+			fmt.Printf("Error generating report: %v\n", err)
+		} else {
+			fmt.Printf("Report generated: %s\n", reportPath)
+		}
+	}
+
+	if err != nil {
+		return 1
+	}
+	if *failOnRegression && diff.Regressions() > 0 {
+		fmt.Printf("%d conflict regressions between %s and %s\n", diff.Regressions(), diff.OldVersion, diff.NewVersion)
+		return exitConflictRegression
+	}
+	return 0
+}
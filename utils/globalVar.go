@@ -1,14 +1,45 @@
 package utils
 
 import (
+	"TransactionRwset/evm"
+	"TransactionRwset/wasm"
+	"encoding/json"
 	"fmt"
 	"go/ast"
 	"go/types"
+	"os"
+	"sort"
+)
+
+// 结果目录下保存合约信息摘要的文件名
+const ContractInfoFileName = "contract_info.json"
+
+// 结果目录下保存节点日志、账本快照、配置矩阵与认证模式节点配置的目录，不属于实验目录
+const (
+	NodeLogDirName        = "nodes"
+	LedgerSnapshotDirName = "ledger_snapshot"
+	MatrixDirName         = "matrix"
+	AuthConfigDirName     = "auth_config"
+)
+
+// 合约运行时
+const (
+	RuntimeDockerGo = "DOCKER_GO"
+	RuntimeEVM      = "EVM"
+	RuntimeWASMER   = "WASMER"
+	RuntimeGASM     = "GASM"
 )
 
 var Log *Logger
 
-var ChainMakerScriptsDir string = "./nodeControl/chainmaker/chainmaker-go/scripts"
+// 节点发布包目录，每个子目录为一个节点
+var ChainMakerReleaseDir string = "./nodeControl/chainmaker/chainmaker-go/build/release"
+
+// prepare.sh 生成的节点配置目录，子目录 node1..nodeN 为各节点配置
+var ChainMakerConfigDir string = "./nodeControl/chainmaker/chainmaker-go/build/config"
+
+// 预先生成的 PermissionedWithKey 与 Public 模式密钥目录
+var ChainMakerConfigPkDir string = "./nodeControl/chainmaker/chainmaker-go/config-pk"
 
 var GlobalContractInfo *ContractInfo
 
@@ -32,6 +63,33 @@ func (f *CandidateTypes) String() string {
 	)
 }
 
+// 按类型名排序后的候选值，保证每次运行遍历顺序一致
+func (f *CandidateTypes) SortedTypeValues() []interface{} {
+	typeList := make([]types.Type, 0, len(f.Types))
+	for t := range f.Types {
+		typeList = append(typeList, t)
+	}
+	sort.Slice(typeList, func(i, j int) bool {
+		return typeList[i].String() < typeList[j].String()
+	})
+
+	values := make([]interface{}, 0, len(typeList))
+	for _, t := range typeList {
+		values = append(values, f.Types[t])
+	}
+	return values
+}
+
+// 按函数名排序的合约函数列表
+func (info *ContractInfo) SortedFuncNames() []string {
+	names := make([]string, 0, len(info.ContractFuncMap))
+	for name := range info.ContractFuncMap {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 type FuncAndParamsNameInfo struct {
 	// 存储FuncName和InvokeName之间对应关系，每个FuncName对应一个InvokeName
 	// 本工具全程使用FuncName,仅在调用过程中使用InvokeName
@@ -55,6 +113,10 @@ type ContractInfo struct {
 	ContractFuncMap map[string]*FuncAndParamsNameInfo
 	// 5. 保存合约中param与candidateTypes相关信息
 	ParamAndCandidateTypes map[string]*CandidateTypes
+	// 6. 合约运行时，EVM 合约同时保存 ABI 与存储布局，WASM 合约同时保存模块信息
+	Runtime string
+	EVM     *evm.Contract
+	WASM    *wasm.Contract
 }
 
 func (info *ContractInfo) PrintContractInfo() {
@@ -63,3 +125,86 @@ func (info *ContractInfo) PrintContractInfo() {
 	fmt.Println("Contract Name:", info.ContractName)
 	fmt.Println("Contract ByteCodePath:", info.ContractByteCodePath)
 }
+
+// 合约信息的可序列化摘要，用于保存至结果目录并供报告生成使用
+type ContractInfoSummary struct {
+	ContractName         string                            `json:"contract_name"`
+	ContractPath         string                            `json:"contract_path"`
+	ContractByteCodePath string                            `json:"contract_byte_code_path"`
+	ContractFuncMap      map[string]*FuncAndParamsNameInfo `json:"contract_func_map"`
+	ParamTypes           map[string]*ParamTypesSummary     `json:"param_types"`
+	Runtime              string                            `json:"runtime,omitempty"`
+	StorageLayoutPath    string                            `json:"storage_layout_path,omitempty"`
+	InterfacePath        string                            `json:"interface_path,omitempty"`
+}
+
+type ParamTypesSummary struct {
+	CandidateTypes []string      `json:"candidate_types"`
+	Confirm        bool          `json:"confirm"`
+	ConfirmValue   []interface{} `json:"confirm_value"`
+}
+
+func (info *ContractInfo) Summary() *ContractInfoSummary {
+	summary := &ContractInfoSummary{
+		ContractName:         info.ContractName,
+		ContractPath:         info.ContractPath,
+		ContractByteCodePath: info.ContractByteCodePath,
+		ContractFuncMap:      info.ContractFuncMap,
+		ParamTypes:           make(map[string]*ParamTypesSummary),
+		Runtime:              info.Runtime,
+	}
+	if info.EVM != nil {
+		summary.StorageLayoutPath = info.EVM.LayoutPath
+	}
+	if info.WASM != nil {
+		summary.InterfacePath = info.WASM.InterfacePath
+	}
+
+	for paramName, candidateTypes := range info.ParamAndCandidateTypes {
+		paramTypes := &ParamTypesSummary{
+			CandidateTypes: make([]string, 0, len(candidateTypes.Types)),
+			Confirm:        candidateTypes.Confirm,
+			ConfirmValue:   candidateTypes.ConfirmValue,
+		}
+		for t := range candidateTypes.Types {
+			paramTypes.CandidateTypes = append(paramTypes.CandidateTypes, t.String())
+		}
+		sort.Strings(paramTypes.CandidateTypes)
+
+		summary.ParamTypes[paramName] = paramTypes
+	}
+
+	return summary
+}
+
+// 将合约信息摘要保存为 JSON 文件
+func (info *ContractInfo) SaveToFile(filePath string) error {
+	data, err := json.MarshalIndent(info.Summary(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize ContractInfo: %v", err)
+	}
+
+	err = os.WriteFile(filePath, data, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write to file: %v", err)
+	}
+
+	return nil
+}
+
+// 从文件读取合约信息摘要
+func LoadContractInfoSummary(filePath string) (*ContractInfoSummary, error) {
+	var summary *ContractInfoSummary
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %v", err)
+	}
+
+	err = json.Unmarshal(data, &summary)
+	if err != nil {
+		return nil, fmt.Errorf("failed to deserialize ContractInfo: %v", err)
+	}
+
+	return summary, nil
+}
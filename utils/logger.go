@@ -1,12 +1,18 @@
 package utils
 
 import (
+	"bufio"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 )
 
+// 日志阶段，渲染为文本日志时，每个阶段对应一个文件
 type LogType string
 
 const (
@@ -16,11 +22,136 @@ const (
 	FuzzLog      LogType = "fuzz_test_logs"
 )
 
+// 结构化事件流文件名
+const EventLogFileName = "events.jsonl"
+
+type LogLevel int
+
+const (
+	LevelDebug LogLevel = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+var logLevelNames = map[LogLevel]string{
+	LevelDebug: "debug",
+	LevelInfo:  "info",
+	LevelWarn:  "warn",
+	LevelError: "error",
+}
+
+func (l LogLevel) String() string {
+	if name, ok := logLevelNames[l]; ok {
+		return name
+	}
+	return fmt.Sprintf("level(%d)", int(l))
+}
+
+func (l LogLevel) MarshalJSON() ([]byte, error) {
+	return json.Marshal(l.String())
+}
+
+func (l *LogLevel) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return err
+	}
+
+	level, err := ParseLogLevel(name)
+	if err != nil {
+		return err
+	}
+	*l = level
+	return nil
+}
+
+// 将字符串解析为日志等级
+func ParseLogLevel(name string) (LogLevel, error) {
+	for level, levelName := range logLevelNames {
+		if strings.EqualFold(levelName, name) {
+			return level, nil
+		}
+	}
+	return LevelInfo, fmt.Errorf("unknown log level: %s", name)
+}
+
+// 事件类型
+const (
+	EventMessage        = "message"
+	EventSection        = "section"
+	EventContractInfo   = "contract_info"
+	EventParamConfirm   = "param_confirm"
+	EventFuncSeed       = "func_seed"
+	EventPairSeed       = "pair_seed"
+	EventPoolSummary    = "pool_summary"
+	EventMutateStart    = "mutate_start"
+	EventMutateFound    = "mutate_conflict_found"
+	EventMutateMiss     = "mutate_no_conflict"
+	EventExperiment     = "experiment"
+	EventTxBatch        = "tx_batch"
+	EventTxPoolWaiting  = "tx_pool_waiting"
+	EventRunSeed        = "run_seed"
+	EventMutatorStats   = "mutator_stats"
+	EventSeedRetired    = "seed_retired"
+	EventCorpusStats    = "corpus_stats"
+	EventSeedMinimized  = "seed_minimized"
+	EventSeedReplay     = "seed_replay"
+	EventNodeStarted    = "node_started"
+	EventNodeCrashed    = "node_crashed"
+	EventClusterReady   = "cluster_ready"
+	EventLedgerSnapshot = "ledger_snapshot"
+	EventLedgerRestore  = "ledger_restore"
+	EventMatrixCell     = "matrix_cell"
+	EventReplayDiverged = "replay_diverged"
+	EventGasSearch      = "gas_search"
+	EventGasAnomaly     = "gas_anomaly"
+	EventExecTimeout    = "exec_timeout"
+	EventEventMismatch  = "event_mismatch"
+	EventEnvelope       = "envelope"
+	EventSeedSync       = "seed_sync"
+	EventNodeCompare    = "node_compare"
+	EventVersionDiff    = "version_diff"
+)
+
+// 结构化日志事件，序列化为 JSONL 中的一行
+type Event struct {
+	Time       time.Time              `json:"time"`
+	Level      LogLevel               `json:"level"`
+	Phase      LogType                `json:"phase"`
+	Type       string                 `json:"type"`
+	Message    string                 `json:"message,omitempty"`
+	SeedIDs    []string               `json:"seed_ids,omitempty"`
+	Functions  []string               `json:"functions,omitempty"`
+	ReadSet    []string               `json:"read_set,omitempty"`
+	WriteSet   []string               `json:"write_set,omitempty"`
+	Similarity *float64               `json:"similarity,omitempty"`
+	DurationMs int64                  `json:"duration_ms,omitempty"`
+	Fields     map[string]interface{} `json:"fields,omitempty"`
+}
+
+// 便于构造 Event.Similarity
+func Similarity(v float64) *float64 {
+	return &v
+}
+
 // Logger 结构体
 type Logger struct {
-	BaseDir string
+	BaseDir  string
+	MinLevel LogLevel
+
+	mu        sync.Mutex
+	file      *os.File
+	writer    *bufio.Writer
+	lastFlush time.Time
 }
 
+// 新建 Logger 时使用的最低日志等级
+var DefaultLogLevel = LevelInfo
+
+// 缓冲区内容最长保留时间，超过后在下一次写入时刷新到文件
+const logFlushInterval = time.Second
+
 // NewLogger 创建一个新的 Logger 实例
 func NewLogger(contractName string) (*Logger, error) {
 	timestamp := time.Now().Format("20060102_150405")
@@ -32,25 +163,224 @@ func NewLogger(contractName string) (*Logger, error) {
 		return nil, fmt.Errorf("failed to create directory: %v", err)
 	}
 
-	return &Logger{BaseDir: baseDir}, nil
+	file, err := os.OpenFile(filepath.Join(baseDir, EventLogFileName), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %v", err)
+	}
+
+	return &Logger{
+		BaseDir:   baseDir,
+		MinLevel:  DefaultLogLevel,
+		file:      file,
+		writer:    bufio.NewWriterSize(file, 64*1024),
+		lastFlush: time.Now(),
+	}, nil
 }
 
-// Log 将内容写入对应的日志文件
+// Emit 将事件写入事件流
+func (l *Logger) Emit(event *Event) error {
+	if l == nil || event.Level < l.MinLevel {
+		return nil
+	}
+
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %v", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.writer == nil {
+		return fmt.Errorf("logger is closed")
+	}
+
+	if _, err := l.writer.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write log: %v", err)
+	}
+
+	// 警告与错误立即落盘，其余事件按时间间隔刷新
+	if event.Level >= LevelWarn || time.Since(l.lastFlush) >= logFlushInterval {
+		l.lastFlush = time.Now()
+		return l.writer.Flush()
+	}
+
+	return nil
+}
+
+// Log 写入一条普通文本消息
 func (l *Logger) Log(logType LogType, message string) error {
-	filePath := filepath.Join(l.BaseDir, string(logType)+".txt")
+	return l.Emit(&Event{Level: LevelInfo, Phase: logType, Type: EventMessage, Message: message})
+}
 
-	// 打开文件（追加模式）
-	file, err := os.OpenFile(filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+// Debug 写入一条调试消息，默认等级下不输出
+func (l *Logger) Debug(logType LogType, message string) error {
+	return l.Emit(&Event{Level: LevelDebug, Phase: logType, Type: EventMessage, Message: message})
+}
+
+// Warn 写入一条警告消息
+func (l *Logger) Warn(logType LogType, message string) error {
+	return l.Emit(&Event{Level: LevelWarn, Phase: logType, Type: EventMessage, Message: message})
+}
+
+// Section 标记某一阶段的开始
+func (l *Logger) Section(logType LogType, title string) error {
+	return l.Emit(&Event{Level: LevelInfo, Phase: logType, Type: EventSection, Message: title})
+}
+
+// Flush 将缓冲区内容写入文件
+func (l *Logger) Flush() error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.writer == nil {
+		return nil
+	}
+	l.lastFlush = time.Now()
+	return l.writer.Flush()
+}
+
+// Close 刷新并关闭事件流，随后渲染出各阶段的文本日志
+func (l *Logger) Close() error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	if l.writer == nil {
+		l.mu.Unlock()
+		return nil
+	}
+	err := l.writer.Flush()
+	l.file.Close()
+	l.writer = nil
+	l.mu.Unlock()
+
+	if err != nil {
+		return fmt.Errorf("failed to flush log: %v", err)
+	}
+
+	return RenderEventLog(filepath.Join(l.BaseDir, EventLogFileName), l.BaseDir)
+}
+
+// 读取事件流文件；进程崩溃或被结束时最后一行可能只写了一半，无法解析的行跳过并计数
+func ReadEventLog(filePath string) ([]*Event, error) {
+	file, err := os.Open(filePath)
 	if err != nil {
-		return fmt.Errorf("failed to open file: %v", err)
+		return nil, fmt.Errorf("failed to open file: %v", err)
 	}
 	defer file.Close()
 
-	// 写入带时间戳的日志
-	timestamp := time.Now().Format("2006-01-02 15:04:05")
-	_, err = file.WriteString(fmt.Sprintf("[%s] %s\n", timestamp, message))
+	events := make([]*Event, 0)
+	skipped := 0
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 1024*1024), 64*1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var event Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			skipped++
+			continue
+		}
+		events = append(events, &event)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read file: %v", err)
+	}
+	if skipped > 0 {
+		fmt.Printf("%s: skipped %d unparseable event lines\n", filePath, skipped)
+	}
+
+	return events, nil
+}
+
+// 将事件渲染为一行可读文本
+func (e *Event) String() string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("[%s] ", e.Time.Format("2006-01-02 15:04:05")))
+	if e.Level != LevelInfo {
+		sb.WriteString(strings.ToUpper(e.Level.String()) + " ")
+	}
+
+	if e.Type == EventSection {
+		sb.WriteString(fmt.Sprintf("==================  %s  ==================", e.Message))
+		return sb.String()
+	}
+
+	if e.Type != EventMessage {
+		sb.WriteString("<" + e.Type + ">")
+		if e.Message != "" {
+			sb.WriteString(" ")
+		}
+	}
+	sb.WriteString(e.Message)
+
+	if len(e.Functions) > 0 {
+		sb.WriteString(fmt.Sprintf(" functions=%v", e.Functions))
+	}
+	if len(e.SeedIDs) > 0 {
+		sb.WriteString(fmt.Sprintf(" seeds=%v", e.SeedIDs))
+	}
+	if e.Similarity != nil {
+		sb.WriteString(fmt.Sprintf(" similarity=%.4f", *e.Similarity))
+	}
+	if e.DurationMs > 0 {
+		sb.WriteString(fmt.Sprintf(" duration=%dms", e.DurationMs))
+	}
+	if len(e.ReadSet) > 0 {
+		sb.WriteString(fmt.Sprintf(" read_set=%v", e.ReadSet))
+	}
+	if len(e.WriteSet) > 0 {
+		sb.WriteString(fmt.Sprintf(" write_set=%v", e.WriteSet))
+	}
+
+	keys := make([]string, 0, len(e.Fields))
+	for key := range e.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value, err := json.Marshal(e.Fields[key])
+		if err != nil {
+			value = []byte(fmt.Sprint(e.Fields[key]))
+		}
+		sb.WriteString(fmt.Sprintf(" %s=%s", key, value))
+	}
+
+	return sb.String()
+}
+
+// 根据事件流渲染各阶段的文本日志，每个阶段输出为 <phase>.txt
+func RenderEventLog(eventsPath, outDir string) error {
+	events, err := ReadEventLog(eventsPath)
 	if err != nil {
-		return fmt.Errorf("failed to write log: %v", err)
+		return err
+	}
+
+	lines := make(map[LogType][]string)
+	for _, event := range events {
+		lines[event.Phase] = append(lines[event.Phase], event.String())
+	}
+
+	for phase, phaseLines := range lines {
+		filePath := filepath.Join(outDir, string(phase)+".txt")
+		err := os.WriteFile(filePath, []byte(strings.Join(phaseLines, "\n")+"\n"), 0644)
+		if err != nil {
+			return fmt.Errorf("failed to write log: %v", err)
+		}
 	}
 
 	return nil
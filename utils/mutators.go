@@ -0,0 +1,713 @@
+/*
+	本文件主要用于：
+
+	1. 按类型提供变异算子：
+			a. 整数：边界值/特殊值字典、位翻转、加减运算
+			b. 浮点数：边界值、运算
+			c. 字符串：字符替换、插入、删除、复制、截断、大小写、数字串与十六进制串感知的变异、特殊字符串字典
+			d. 字节切片：多种操作叠加的 havoc 变异
+			e. 布尔值：取反
+			f. 拼接：使用同一参数在其他种子中的取值
+
+	2. 每个算子有独立的权重，按权重随机选取可用于当前值的算子
+
+	3. 统计每个算子被使用、使相似度提升以及直接产生冲突的次数
+*/
+
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// 变异算子
+type Mutator struct {
+	Name   string
+	Weight int
+	// 该算子能否作用于当前值，donors 为同一参数在其他种子中的取值
+	applies func(value interface{}, donors []interface{}) bool
+	mutate  func(rng *rand.Rand, value interface{}, donors []interface{}) interface{}
+}
+
+// 变异算子统计
+type MutatorStat struct {
+	Name      string `json:"name"`
+	Weight    int    `json:"weight"`
+	Applied   int    `json:"applied"`   // 被使用的次数
+	Improved  int    `json:"improved"`  // 使读写集相似度提升的次数
+	Conflicts int    `json:"conflicts"` // 直接产生冲突交易对的次数
+}
+
+// 结果目录下保存变异算子统计的文件名
+const MutatorStatsFileName = "mutation_stats.json"
+
+// 没有可用算子时（如未知类型），使用随机字符串替代原值
+const fallbackMutator = "fallback_string"
+
+var Mutators = []*Mutator{
+	{Name: "int_interesting", Weight: 3, applies: isInteger, mutate: mutateIntInteresting},
+	{Name: "int_arith", Weight: 4, applies: isInteger, mutate: mutateIntArith},
+	{Name: "int_bitflip", Weight: 2, applies: isInteger, mutate: mutateIntBitflip},
+	{Name: "float_interesting", Weight: 2, applies: isFloat, mutate: mutateFloatInteresting},
+	{Name: "float_arith", Weight: 3, applies: isFloat, mutate: mutateFloatArith},
+	{Name: "bool_flip", Weight: 1, applies: isBool, mutate: mutateBoolFlip},
+	{Name: "str_replace", Weight: 4, applies: isString, mutate: mutateStringReplace},
+	{Name: "str_insert", Weight: 2, applies: isString, mutate: mutateStringInsert},
+	{Name: "str_delete", Weight: 2, applies: isNonEmptyString, mutate: mutateStringDelete},
+	{Name: "str_duplicate", Weight: 1, applies: isNonEmptyString, mutate: mutateStringDuplicate},
+	{Name: "str_truncate", Weight: 1, applies: isNonEmptyString, mutate: mutateStringTruncate},
+	{Name: "str_case", Weight: 1, applies: hasLetter, mutate: mutateStringCase},
+	{Name: "str_numeric", Weight: 2, applies: isString, mutate: mutateNumericString},
+	{Name: "str_hex", Weight: 3, applies: isHexString, mutate: mutateHexString},
+	{Name: "str_dictionary", Weight: 1, applies: isString, mutate: mutateStringDictionary},
+	{Name: "bytes_havoc", Weight: 4, applies: isBytes, mutate: mutateBytesHavoc},
+	{Name: "splice", Weight: 3, applies: hasDonor, mutate: mutateSplice},
+}
+
+var mutatorStats = make(map[string]*MutatorStat)
+
+// 解析形如 "str_hex=5,bytes_havoc=0" 的权重设置，权重为 0 表示禁用该算子
+func SetMutatorWeights(spec string) error {
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		parts := strings.SplitN(item, "=", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("invalid mutator weight: %s", item)
+		}
+
+		weight, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+		if err != nil || weight < 0 {
+			return fmt.Errorf("invalid mutator weight: %s", item)
+		}
+
+		mutator := findMutator(strings.TrimSpace(parts[0]))
+		if mutator == nil {
+			return fmt.Errorf("unknown mutator: %s", parts[0])
+		}
+		mutator.Weight = weight
+	}
+	return nil
+}
+
+func findMutator(name string) *Mutator {
+	for _, mutator := range Mutators {
+		if mutator.Name == name {
+			return mutator
+		}
+	}
+	return nil
+}
+
+func mutatorStat(name string) *MutatorStat {
+	stat, ok := mutatorStats[name]
+	if !ok {
+		stat = &MutatorStat{Name: name}
+		if mutator := findMutator(name); mutator != nil {
+			stat.Weight = mutator.Weight
+		}
+		mutatorStats[name] = stat
+	}
+	return stat
+}
+
+// CreditMutator 记录一次变异的效果
+func CreditMutator(name string, improved, conflict bool) {
+	if name == "" {
+		return
+	}
+
+	stat := mutatorStat(name)
+	if improved {
+		stat.Improved++
+	}
+	if conflict {
+		stat.Conflicts++
+	}
+}
+
+// 按算子定义顺序返回统计结果
+func MutatorStats() []*MutatorStat {
+	stats := make([]*MutatorStat, 0, len(Mutators)+1)
+	for _, mutator := range Mutators {
+		stat := mutatorStat(mutator.Name)
+		stat.Weight = mutator.Weight
+		stats = append(stats, stat)
+	}
+	if stat, ok := mutatorStats[fallbackMutator]; ok {
+		stats = append(stats, stat)
+	}
+	return stats
+}
+
+func SaveMutatorStats(filePath string) error {
+	data, err := json.MarshalIndent(MutatorStats(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize MutatorStats: %v", err)
+	}
+
+	err = os.WriteFile(filePath, data, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write to file: %v", err)
+	}
+
+	return nil
+}
+
+func LoadMutatorStats(filePath string) ([]*MutatorStat, error) {
+	var stats []*MutatorStat
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %v", err)
+	}
+
+	err = json.Unmarshal(data, &stats)
+	if err != nil {
+		return nil, fmt.Errorf("failed to deserialize MutatorStats: %v", err)
+	}
+
+	return stats, nil
+}
+
+// 按权重选取一个可作用于当前值的算子
+func pickMutator(rng *rand.Rand, value interface{}, donors []interface{}) *Mutator {
+	total := 0
+	candidates := make([]*Mutator, 0)
+	for _, mutator := range Mutators {
+		if mutator.Weight > 0 && mutator.applies(value, donors) {
+			candidates = append(candidates, mutator)
+			total += mutator.Weight
+		}
+	}
+
+	if total == 0 {
+		return nil
+	}
+
+	r := rng.Intn(total)
+	for _, mutator := range candidates {
+		if r < mutator.Weight {
+			return mutator
+		}
+		r -= mutator.Weight
+	}
+	return nil
+}
+
+// 生成与原始value不同的新值
+func GenerateDiffValue(value interface{}) interface{} {
+	result, _ := MutateValue(value, nil)
+	return result
+}
+
+// MutateValue 生成与原始value不同的新值，并返回所使用的算子名
+// donors 为同一参数在其他种子中的取值，用于拼接
+func MutateValue(value interface{}, donors []interface{}) (interface{}, string) {
+	if value == nil {
+		fmt.Println("nil value!")
+		return nil, ""
+	}
+
+	rng := seededRand
+	for i := 0; i < 64; i++ {
+		mutator := pickMutator(rng, value, donors)
+		if mutator == nil {
+			mutatorStat(fallbackMutator).Applied++
+			return mutateStringReplace(rng, "string", nil), fallbackMutator
+		}
+
+		result := mutator.mutate(rng, value, donors)
+		if !reflect.DeepEqual(result, value) {
+			mutatorStat(mutator.Name).Applied++
+			return result, mutator.Name
+		}
+	}
+
+	// 多次变异均未产生新值（如仅存在相同取值的拼接对象），保持原值
+	return value, ""
+}
+
+/* 类型判断 */
+
+func isInteger(value interface{}, _ []interface{}) bool {
+	switch reflect.ValueOf(value).Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+func isFloat(value interface{}, _ []interface{}) bool {
+	kind := reflect.ValueOf(value).Kind()
+	return kind == reflect.Float32 || kind == reflect.Float64
+}
+
+func isBool(value interface{}, _ []interface{}) bool {
+	_, ok := value.(bool)
+	return ok
+}
+
+func isString(value interface{}, _ []interface{}) bool {
+	_, ok := value.(string)
+	return ok
+}
+
+func isNonEmptyString(value interface{}, _ []interface{}) bool {
+	str, ok := value.(string)
+	return ok && len(str) > 0
+}
+
+func hasLetter(value interface{}, _ []interface{}) bool {
+	str, ok := value.(string)
+	return ok && strings.ToUpper(str) != strings.ToLower(str)
+}
+
+func isBytes(value interface{}, _ []interface{}) bool {
+	_, ok := value.([]byte)
+	return ok
+}
+
+func hasDonor(value interface{}, donors []interface{}) bool {
+	return len(compatibleDonors(value, donors)) > 0
+}
+
+// 与原值类型相同且取值不同的拼接对象
+func compatibleDonors(value interface{}, donors []interface{}) []interface{} {
+	result := make([]interface{}, 0)
+	valueType := reflect.TypeOf(value)
+	for _, donor := range donors {
+		if reflect.TypeOf(donor) == valueType && !reflect.DeepEqual(donor, value) {
+			result = append(result, donor)
+		}
+	}
+	return result
+}
+
+/* 整数 */
+
+// 整数的位宽；Docker-Go 合约编译为 64 位 Linux 二进制（amd64/arm64），int/uint 与 int64/uint64 同为 64 位
+func intBits(kind reflect.Kind) uint {
+	switch kind {
+	case reflect.Int8, reflect.Uint8:
+		return 8
+	case reflect.Int16, reflect.Uint16:
+		return 16
+	case reflect.Int32, reflect.Uint32:
+		return 32
+	default:
+		return 64
+	}
+}
+
+func isSigned(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return true
+	}
+	return false
+}
+
+func signedRange(bits uint) (int64, int64) {
+	if bits >= 64 {
+		return math.MinInt64, math.MaxInt64
+	}
+	return -(1 << (bits - 1)), 1<<(bits-1) - 1
+}
+
+func unsignedMax(bits uint) uint64 {
+	if bits >= 64 {
+		return math.MaxUint64
+	}
+	return 1<<bits - 1
+}
+
+// 常用于触发边界条件的整数取值
+var interestingInts = []int64{
+	-128, -1, 0, 1, 16, 32, 64, 100, 127,
+	-32768, -129, 128, 255, 256, 512, 1000, 1024, 4096, 32767,
+	-2147483648, -100663046, -32769, 32768, 65535, 65536, 100663045, 2147483647,
+}
+
+// 将 int64/uint64 结果转换回原始类型
+func convertInt(value interface{}, signed int64, unsigned uint64) interface{} {
+	t := reflect.TypeOf(value)
+	if isSigned(t.Kind()) {
+		return reflect.ValueOf(signed).Convert(t).Interface()
+	}
+	return reflect.ValueOf(unsigned).Convert(t).Interface()
+}
+
+func mutateIntInteresting(rng *rand.Rand, value interface{}, _ []interface{}) interface{} {
+	kind := reflect.ValueOf(value).Kind()
+	bits := intBits(kind)
+
+	if isSigned(kind) {
+		min, max := signedRange(bits)
+		candidates := []int64{min, min + 1, max - 1, max}
+		for _, v := range interestingInts {
+			if v >= min && v <= max {
+				candidates = append(candidates, v)
+			}
+		}
+		return convertInt(value, candidates[rng.Intn(len(candidates))], 0)
+	}
+
+	max := unsignedMax(bits)
+	candidates := []uint64{max - 1, max}
+	for _, v := range interestingInts {
+		if v >= 0 && uint64(v) <= max {
+			candidates = append(candidates, uint64(v))
+		}
+	}
+	return convertInt(value, 0, candidates[rng.Intn(len(candidates))])
+}
+
+// 加减 1~35，超出范围时取边界值
+func mutateIntArith(rng *rand.Rand, value interface{}, _ []interface{}) interface{} {
+	v := reflect.ValueOf(value)
+	bits := intBits(v.Kind())
+	delta := rng.Intn(35) + 1
+	add := rng.Intn(2) == 0
+
+	if isSigned(v.Kind()) {
+		min, max := signedRange(bits)
+		x := v.Int()
+		switch {
+		case add && x > max-int64(delta):
+			x = max
+		case add:
+			x += int64(delta)
+		case x < min+int64(delta):
+			x = min
+		default:
+			x -= int64(delta)
+		}
+		return convertInt(value, x, 0)
+	}
+
+	max := unsignedMax(bits)
+	x := v.Uint()
+	switch {
+	case add && x > max-uint64(delta):
+		x = max
+	case add:
+		x += uint64(delta)
+	case x < uint64(delta):
+		x = 0
+	default:
+		x -= uint64(delta)
+	}
+	return convertInt(value, 0, x)
+}
+
+func mutateIntBitflip(rng *rand.Rand, value interface{}, _ []interface{}) interface{} {
+	v := reflect.ValueOf(value)
+	bits := intBits(v.Kind())
+	bit := uint64(1) << uint(rng.Intn(int(bits)))
+
+	if isSigned(v.Kind()) {
+		// 按补码翻转后做符号扩展
+		shift := 64 - bits
+		x := uint64(v.Int()) ^ bit
+		return convertInt(value, int64(x<<shift)>>shift, 0)
+	}
+	return convertInt(value, 0, (v.Uint()^bit)&unsignedMax(bits))
+}
+
+/* 浮点数 */
+
+func mutateFloatInteresting(rng *rand.Rand, value interface{}, _ []interface{}) interface{} {
+	max := math.MaxFloat64
+	smallest := math.SmallestNonzeroFloat64
+	if reflect.ValueOf(value).Kind() == reflect.Float32 {
+		max = math.MaxFloat32
+		smallest = math.SmallestNonzeroFloat32
+	}
+
+	candidates := []float64{0, 1, -1, 0.5, -0.5, 1e-9, smallest, max, -max}
+	x := candidates[rng.Intn(len(candidates))]
+	return reflect.ValueOf(x).Convert(reflect.TypeOf(value)).Interface()
+}
+
+func mutateFloatArith(rng *rand.Rand, value interface{}, _ []interface{}) interface{} {
+	x := reflect.ValueOf(value).Float()
+	switch rng.Intn(5) {
+	case 0:
+		x += float64(rng.Intn(35) + 1)
+	case 1:
+		x -= float64(rng.Intn(35) + 1)
+	case 2:
+		x *= 2
+	case 3:
+		x /= 2
+	default:
+		x = -x
+	}
+
+	// JSON 无法表示 Inf，溢出时保持原值
+	if math.IsInf(x, 0) || math.IsNaN(x) {
+		return value
+	}
+	return reflect.ValueOf(x).Convert(reflect.TypeOf(value)).Interface()
+}
+
+/* 布尔值 */
+
+func mutateBoolFlip(_ *rand.Rand, value interface{}, _ []interface{}) interface{} {
+	return !value.(bool)
+}
+
+/* 字符串 */
+
+func randomChars(rng *rand.Rand, n int) string {
+	var sb strings.Builder
+	for i := 0; i < n; i++ {
+		sb.WriteByte(charset[rng.Intn(len(charset))])
+	}
+	return sb.String()
+}
+
+// 保持长度不变，随机替换若干字符
+func mutateStringReplace(rng *rand.Rand, value interface{}, _ []interface{}) interface{} {
+	runes := []rune(value.(string))
+	if len(runes) == 0 {
+		return randomChars(rng, 1)
+	}
+
+	times := rng.Intn(MAX_MUTATE_ITER) + 1
+	for i := 0; i < times; i++ {
+		runes[rng.Intn(len(runes))] = rune(charset[rng.Intn(len(charset))])
+	}
+	return string(runes)
+}
+
+func mutateStringInsert(rng *rand.Rand, value interface{}, _ []interface{}) interface{} {
+	runes := []rune(value.(string))
+	pos := rng.Intn(len(runes) + 1)
+	insert := []rune(randomChars(rng, rng.Intn(8)+1))
+	return string(runes[:pos]) + string(insert) + string(runes[pos:])
+}
+
+func mutateStringDelete(rng *rand.Rand, value interface{}, _ []interface{}) interface{} {
+	runes := []rune(value.(string))
+	pos := rng.Intn(len(runes))
+	length := rng.Intn(len(runes)-pos) + 1
+	return string(runes[:pos]) + string(runes[pos+length:])
+}
+
+// 复制一段子串插入原位置，或将整个字符串重复
+func mutateStringDuplicate(rng *rand.Rand, value interface{}, _ []interface{}) interface{} {
+	str := value.(string)
+	if rng.Intn(2) == 0 {
+		return strings.Repeat(str, rng.Intn(3)+2)
+	}
+
+	runes := []rune(str)
+	pos := rng.Intn(len(runes))
+	length := rng.Intn(len(runes)-pos) + 1
+	chunk := string(runes[pos : pos+length])
+	return string(runes[:pos+length]) + chunk + string(runes[pos+length:])
+}
+
+func mutateStringTruncate(rng *rand.Rand, value interface{}, _ []interface{}) interface{} {
+	runes := []rune(value.(string))
+	return string(runes[:rng.Intn(len(runes))])
+}
+
+func mutateStringCase(rng *rand.Rand, value interface{}, _ []interface{}) interface{} {
+	str := value.(string)
+	switch rng.Intn(3) {
+	case 0:
+		return strings.ToUpper(str)
+	case 1:
+		return strings.ToLower(str)
+	default:
+		runes := []rune(str)
+		for i, r := range runes {
+			if unicode.IsUpper(r) {
+				runes[i] = unicode.ToLower(r)
+			} else {
+				runes[i] = unicode.ToUpper(r)
+			}
+		}
+		return string(runes)
+	}
+}
+
+// 常用于触发解析边界的数字串
+var interestingNumericStrings = []string{
+	"0", "-0", "1", "-1", "00", "+1", "01",
+	"127", "128", "255", "256", "65535", "65536",
+	"2147483647", "2147483648", "-2147483648", "4294967295", "4294967296",
+	"9223372036854775807", "9223372036854775808", "-9223372036854775808", "18446744073709551615",
+	"1e308", "1e-308", "0.1", "NaN",
+}
+
+// 数字串按数值进行变异，其他字符串替换为特殊数字串
+func mutateNumericString(rng *rand.Rand, value interface{}, _ []interface{}) interface{} {
+	str := value.(string)
+
+	if x, err := strconv.ParseInt(str, 10, 64); err == nil {
+		switch rng.Intn(3) {
+		case 0:
+			return strconv.FormatInt(mutateIntArith(rng, x, nil).(int64), 10)
+		case 1:
+			return strconv.FormatInt(mutateIntBitflip(rng, x, nil).(int64), 10)
+		default:
+			return strconv.FormatInt(mutateIntInteresting(rng, x, nil).(int64), 10)
+		}
+	}
+
+	if x, err := strconv.ParseFloat(str, 64); err == nil && !math.IsInf(x, 0) && !math.IsNaN(x) {
+		return strconv.FormatFloat(mutateFloatArith(rng, x, nil).(float64), 'g', -1, 64)
+	}
+
+	return interestingNumericStrings[rng.Intn(len(interestingNumericStrings))]
+}
+
+const hexDigits = "0123456789abcdef"
+
+// 形如 0x1a2b 或 1a2b 的十六进制串（如地址、哈希）
+func isHexString(value interface{}, _ []interface{}) bool {
+	str, ok := value.(string)
+	if !ok {
+		return false
+	}
+
+	digits := strings.TrimPrefix(strings.TrimPrefix(str, "0x"), "0X")
+	if len(digits) < 2 {
+		return false
+	}
+	for _, c := range strings.ToLower(digits) {
+		if !strings.ContainsRune(hexDigits, c) {
+			return false
+		}
+	}
+	return true
+}
+
+// 在保持十六进制格式的前提下变异：修改某一位、增删一个字节、全 0 或全 f
+func mutateHexString(rng *rand.Rand, value interface{}, _ []interface{}) interface{} {
+	str := value.(string)
+	prefix := ""
+	if strings.HasPrefix(str, "0x") || strings.HasPrefix(str, "0X") {
+		prefix, str = str[:2], str[2:]
+	}
+
+	upper := str != strings.ToLower(str)
+	digits := []byte(strings.ToLower(str))
+
+	switch rng.Intn(5) {
+	case 0, 1:
+		digits[rng.Intn(len(digits))] = hexDigits[rng.Intn(len(hexDigits))]
+	case 2:
+		if len(digits) >= 4 {
+			pos := rng.Intn(len(digits)/2) * 2
+			digits = append(digits[:pos], digits[pos+2:]...)
+		} else {
+			digits = append(digits, hexDigits[rng.Intn(16)], hexDigits[rng.Intn(16)])
+		}
+	case 3:
+		digits = append(digits, hexDigits[rng.Intn(16)], hexDigits[rng.Intn(16)])
+	default:
+		fill := byte('0')
+		if rng.Intn(2) == 0 {
+			fill = 'f'
+		}
+		for i := range digits {
+			digits[i] = fill
+		}
+	}
+
+	result := string(digits)
+	if upper {
+		result = strings.ToUpper(result)
+	}
+	return prefix + result
+}
+
+// 常用于触发特殊处理分支的字符串
+var interestingStrings = []string{
+	"", " ", "null", "nil", "true", "false", "undefined",
+	"%s", "\\", "\"", "'", "\n", "\x00", "中文", "😀",
+	strings.Repeat("A", 64), strings.Repeat("A", 1024),
+}
+
+func mutateStringDictionary(rng *rand.Rand, _ interface{}, _ []interface{}) interface{} {
+	return interestingStrings[rng.Intn(len(interestingStrings))]
+}
+
+/* 字节切片 */
+
+// 叠加 2~16 次随机字节操作
+func mutateBytesHavoc(rng *rand.Rand, value interface{}, _ []interface{}) interface{} {
+	res := append([]byte{}, value.([]byte)...)
+
+	stack := 1 << uint(rng.Intn(4)+1)
+	for i := 0; i < stack; i++ {
+		if len(res) == 0 {
+			res = append(res, byte(rng.Intn(256)))
+			continue
+		}
+
+		pos := rng.Intn(len(res))
+		switch rng.Intn(7) {
+		case 0:
+			res[pos] ^= 1 << uint(rng.Intn(8))
+		case 1:
+			res[pos] = byte(interestingInts[rng.Intn(9)])
+		case 2:
+			res[pos] += byte(rng.Intn(35) + 1)
+		case 3:
+			res[pos] -= byte(rng.Intn(35) + 1)
+		case 4:
+			res[pos] = byte(rng.Intn(256))
+		case 5:
+			length := rng.Intn(len(res)-pos) + 1
+			res = append(res[:pos], res[pos+length:]...)
+		default:
+			length := rng.Intn(len(res)-pos) + 1
+			chunk := append([]byte{}, res[pos:pos+length]...)
+			insertAt := rng.Intn(len(res) + 1)
+			res = append(res[:insertAt], append(chunk, res[insertAt:]...)...)
+		}
+	}
+	return res
+}
+
+/* 拼接 */
+
+// 使用同一参数在其他种子中的取值：字符串、字节切片取两者前后两段拼接，其他类型直接替换
+func mutateSplice(rng *rand.Rand, value interface{}, donors []interface{}) interface{} {
+	candidates := compatibleDonors(value, donors)
+	donor := candidates[rng.Intn(len(candidates))]
+
+	if rng.Intn(2) == 0 {
+		return donor
+	}
+
+	switch v := value.(type) {
+	case string:
+		head, tail := []rune(v), []rune(donor.(string))
+		return string(head[:rng.Intn(len(head)+1)]) + string(tail[rng.Intn(len(tail)+1):])
+	case []byte:
+		tail := donor.([]byte)
+		res := append([]byte{}, v[:rng.Intn(len(v)+1)]...)
+		return append(res, tail[rng.Intn(len(tail)+1):]...)
+	default:
+		return donor
+	}
+}
@@ -0,0 +1,272 @@
+package utils
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// 结果目录下记录随机种子与随机决策的文件名
+const (
+	RunSeedFileName   = "run_seed.json"
+	DecisionsFileName = "decisions.jsonl"
+)
+
+// 本次运行使用的主随机种子
+var MasterSeed int64
+
+// 回放模式下，上一次运行记录的随机决策
+var ReplayDecisions []*Decision
+
+// 回放模式下，被回放的结果目录
+var ReplayOf string
+
+// 全局随机决策记录
+var Decisions *DecisionRecorder
+
+// 根据当前时间生成主随机种子
+func NewMasterSeed() int64 {
+	return time.Now().UnixNano()
+}
+
+// 记录随机数读取次数的随机源，用于定位回放时随机序列从何处开始偏离
+type countingSource struct {
+	src   rand.Source64
+	draws uint64
+}
+
+func (s *countingSource) Int63() int64 {
+	s.draws++
+	return s.src.Int63()
+}
+
+func (s *countingSource) Uint64() uint64 {
+	s.draws++
+	return s.src.Uint64()
+}
+
+func (s *countingSource) Seed(seed int64) {
+	s.draws = 0
+	s.src.Seed(seed)
+}
+
+var masterSource *countingSource
+
+func newRecordingRand(seed int64) *rand.Rand {
+	MasterSeed = seed
+	masterSource = &countingSource{src: rand.NewSource(seed).(rand.Source64)}
+	return rand.New(masterSource)
+}
+
+// SetSeed 重置全局随机数生成器，相同种子在相同节点响应下产生相同的变异序列
+func SetSeed(seed int64) {
+	seededRand = newRecordingRand(seed)
+}
+
+// Rand 返回全局随机数生成器
+func Rand() *rand.Rand {
+	return seededRand
+}
+
+// 当前已从全局随机数生成器中读取随机数的次数
+func RandDraws() uint64 {
+	return masterSource.draws
+}
+
+type RunSeed struct {
+	Seed     int64  `json:"seed"`
+	ReplayOf string `json:"replay_of,omitempty"`
+}
+
+// 将本次运行的随机种子保存至结果目录
+func SaveRunSeed(baseDir string) error {
+	data, err := json.MarshalIndent(&RunSeed{Seed: MasterSeed, ReplayOf: ReplayOf}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize RunSeed: %v", err)
+	}
+
+	err = os.WriteFile(filepath.Join(baseDir, RunSeedFileName), data, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write to file: %v", err)
+	}
+
+	return nil
+}
+
+func LoadRunSeed(baseDir string) (*RunSeed, error) {
+	var runSeed *RunSeed
+
+	data, err := os.ReadFile(filepath.Join(baseDir, RunSeedFileName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %v", err)
+	}
+
+	err = json.Unmarshal(data, &runSeed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to deserialize RunSeed: %v", err)
+	}
+
+	return runSeed, nil
+}
+
+// 一次随机决策
+type Decision struct {
+	Seq     int    `json:"seq"`
+	Site    string `json:"site"`              // 做出决策的位置，如 mutate
+	Subject string `json:"subject,omitempty"` // 决策对象，如交易对种子标识
+	Choice  string `json:"choice"`            // 决策结果
+	Draws   uint64 `json:"draws"`             // 决策时已读取的随机数个数
+}
+
+func (d *Decision) equal(other *Decision) bool {
+	return d.Site == other.Site && d.Subject == other.Subject && d.Choice == other.Choice && d.Draws == other.Draws
+}
+
+func (d *Decision) String() string {
+	return fmt.Sprintf("#%d %s(%s) -> %s @draw %d", d.Seq, d.Site, d.Subject, d.Choice, d.Draws)
+}
+
+// 随机决策记录器，回放模式下同时与上一次运行的决策进行比对
+type DecisionRecorder struct {
+	file     *os.File
+	writer   *bufio.Writer
+	seq      int
+	expected []*Decision
+	matched  int
+	diverged *Decision
+}
+
+func NewDecisionRecorder(baseDir string, expected []*Decision) (*DecisionRecorder, error) {
+	file, err := os.Create(filepath.Join(baseDir, DecisionsFileName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file: %v", err)
+	}
+
+	return &DecisionRecorder{
+		file:     file,
+		writer:   bufio.NewWriter(file),
+		expected: expected,
+	}, nil
+}
+
+// Record 记录一次随机决策
+func (r *DecisionRecorder) Record(site, subject, choice string) {
+	if r == nil || r.writer == nil {
+		return
+	}
+
+	decision := &Decision{
+		Seq:     r.seq,
+		Site:    site,
+		Subject: subject,
+		Choice:  choice,
+		Draws:   RandDraws(),
+	}
+	r.seq++
+
+	data, err := json.Marshal(decision)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	r.writer.Write(append(data, '\n'))
+
+	if r.expected == nil || r.diverged != nil {
+		return
+	}
+
+	if decision.Seq < len(r.expected) && r.expected[decision.Seq].equal(decision) {
+		r.matched++
+		return
+	}
+
+	// 只报告第一次偏离，之后的决策均不再可比
+	r.diverged = decision
+	expected := "<none>"
+	if decision.Seq < len(r.expected) {
+		expected = r.expected[decision.Seq].String()
+	}
+	Log.Emit(&Event{
+		Level:   LevelWarn,
+		Phase:   ExecutionLog,
+		Type:    EventReplayDiverged,
+		Message: fmt.Sprintf("replay diverged: expected %s, got %s", expected, decision),
+	})
+}
+
+// 回放结果说明，非回放模式下返回空字符串
+func (r *DecisionRecorder) Summary() string {
+	if r == nil || r.expected == nil {
+		return ""
+	}
+
+	if r.diverged != nil {
+		return fmt.Sprintf("replay diverged at decision #%d after %d matched decisions", r.diverged.Seq, r.matched)
+	}
+	if r.matched < len(r.expected) {
+		return fmt.Sprintf("replay matched %d decisions but stopped before the recorded %d", r.matched, len(r.expected))
+	}
+	return fmt.Sprintf("replay matched all %d recorded decisions", r.matched)
+}
+
+func (r *DecisionRecorder) Close() error {
+	if r == nil || r.writer == nil {
+		return nil
+	}
+
+	err := r.writer.Flush()
+	r.file.Close()
+	r.writer = nil
+	return err
+}
+
+// 读取记录的随机决策
+func LoadDecisions(filePath string) ([]*Decision, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	decisions := make([]*Decision, 0)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var decision Decision
+		if err := json.Unmarshal(scanner.Bytes(), &decision); err != nil {
+			return nil, fmt.Errorf("failed to parse decision: %v", err)
+		}
+		decisions = append(decisions, &decision)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read file: %v", err)
+	}
+
+	return decisions, nil
+}
+
+// 进入回放模式：使用结果目录中记录的种子，并在运行过程中比对随机决策
+func PrepareReplay(resultDir string) error {
+	runSeed, err := LoadRunSeed(resultDir)
+	if err != nil {
+		return err
+	}
+
+	decisions, err := LoadDecisions(filepath.Join(resultDir, DecisionsFileName))
+	if err != nil {
+		return err
+	}
+
+	SetSeed(runSeed.Seed)
+	ReplayDecisions = decisions
+	ReplayOf = resultDir
+	return nil
+}
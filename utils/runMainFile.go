@@ -110,6 +110,7 @@ func RunMainFile() (*common.TxRWSet, *common.TxRWSet, float64) {
 
 	// 在一个goroutine中读取子程序的自定义日志输出
 	go func() {
+		defer RecoverPanic()
 		scanner := bufio.NewScanner(reader)
 		for scanner.Scan() {
 			line := scanner.Text()
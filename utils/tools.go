@@ -2,7 +2,12 @@ package utils
 
 import (
 	"encoding/json"
+	"fmt"
+	"os"
+	"runtime/debug"
 	"sort"
+	"strings"
+	"sync"
 
 	"github.com/agnivade/levenshtein"
 )
@@ -25,6 +30,17 @@ func StringArraysEqual(a, b []string) bool {
 	return true
 }
 
+// 判断节点或 SDK 返回的信息中是否包含任一片段（不区分大小写）
+func MessageContains(message string, subs ...string) bool {
+	message = strings.ToLower(message)
+	for _, sub := range subs {
+		if strings.Contains(message, sub) {
+			return true
+		}
+	}
+	return false
+}
+
 // 主要作用：string或byte类型转为[]byte时不添加额外字符
 func MarshalInterfaceToBytes(data interface{}) ([]byte, error) {
 	switch v := data.(type) {
@@ -63,3 +79,24 @@ func CalculateSimilarity(str1, str2 string) float64 {
 	}
 	return 1 - float64(distance)/float64(maxLen)
 }
+
+// 程序异常退出前的清理函数，由 main 设置为停止集群
+var OnPanic func()
+
+var panicOnce sync.Once
+
+// RecoverPanic 在后台 goroutine 开始处 defer 调用：goroutine 中的 panic 不经过 main 中的 recover，
+// 在此执行一次清理（停止节点与虚拟机容器）后打印堆栈并退出
+func RecoverPanic() {
+	r := recover()
+	if r == nil {
+		return
+	}
+	fmt.Printf("panic: %v\n%s", r, debug.Stack())
+	panicOnce.Do(func() {
+		if OnPanic != nil {
+			OnPanic()
+		}
+	})
+	os.Exit(2)
+}
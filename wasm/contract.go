@@ -0,0 +1,285 @@
+/*
+	本文件主要用于：
+
+	1. 读取 Rust（WASMER）与 TinyGo（GASM）编译得到的 WASM 合约 <Name>.wasm，
+	   以及可选的接口描述 <Name>_interface.json
+
+	2. 有接口描述时，方法、参数、参数类型、初始化参数与运行时均以接口描述为准；
+	   否则由模块推断：导出函数（去除运行时与生命周期函数）为合约方法，
+	   各方法函数体中引用的标识符字符串为参数键，producers 段判断运行时
+
+	3. WASM 合约的参数均以字节传入，数值参数由合约自行解析，
+	   推断得到的参数以可同时作为字符串与数字解析的 "1" 为初始值
+*/
+
+package wasm
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/types"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+
+	"chainmaker.org/chainmaker/pb-go/v2/common"
+)
+
+const (
+	WASMExt      = ".wasm"
+	InterfaceExt = "_interface.json"
+
+	// 部署时调用的初始化函数
+	InitMethod = "init_contract"
+
+	// 推断得到的参数与初始化参数的初始值
+	defaultParamValue = "1"
+	defaultInitValue  = "100000000"
+
+	// 每个方法推断得到的参数键数量上限
+	maxInferredParams = 8
+)
+
+// 运行时与生命周期相关的导出函数，不作为合约方法
+var reservedExports = map[string]bool{
+	"runtime_type": true,
+	"allocate":     true,
+	"deallocate":   true,
+	"malloc":       true,
+	"free":         true,
+	"memcpy":       true,
+	"memset":       true,
+	"memmove":      true,
+	"go_scheduler": true,
+	"resume":       true,
+	InitMethod:     true,
+	"upgrade":      true,
+	"stackAlloc":   true,
+	"stackSave":    true,
+	"stackRestore": true,
+}
+
+type Contract struct {
+	Path          string
+	InterfacePath string // 为空表示方法与参数由模块推断
+	Runtime       common.RuntimeType
+
+	Methods    map[string]*Method
+	InitParams map[string]string // 部署时传给 init_contract 的参数
+}
+
+type Method struct {
+	Name   string
+	Params []*Param
+}
+
+type Param struct {
+	Name string
+	Type string // 接口描述中的类型，推断得到的参数为 string
+}
+
+// 用户提供的接口描述
+type Interface struct {
+	Runtime    string            `json:"runtime"` // WASMER 或 GASM
+	InitParams map[string]string `json:"init_params"`
+	Methods    []struct {
+		Name   string   `json:"name"`
+		Params []*Param `json:"params"`
+	} `json:"methods"`
+}
+
+// Load 读取 WASM 模块及同目录下同名的接口描述
+func Load(path string) (*Contract, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read WASM module: %v", err)
+	}
+	m, err := parseModule(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse WASM module %s: %v", path, err)
+	}
+
+	c := &Contract{
+		Path:       path,
+		Runtime:    detectRuntime(m),
+		Methods:    make(map[string]*Method),
+		InitParams: make(map[string]string),
+	}
+
+	interfacePath := strings.TrimSuffix(path, WASMExt) + InterfaceExt
+	if _, err := os.Stat(interfacePath); err == nil {
+		c.InterfacePath = interfacePath
+		if err := c.loadInterface(interfacePath); err != nil {
+			return nil, err
+		}
+	} else {
+		c.inferMethods(m)
+	}
+
+	if len(c.Methods) == 0 {
+		return nil, fmt.Errorf("no callable method in %s", path)
+	}
+	return c, nil
+}
+
+// TinyGo 编译的模块运行于 GASM，其余（Rust 等）运行于 WASMER
+func detectRuntime(m *module) common.RuntimeType {
+	if strings.Contains(strings.ToLower(string(m.producers)), "tinygo") {
+		return common.RuntimeType_GASM
+	}
+	for _, name := range m.imports {
+		if strings.HasPrefix(name, "wasi_unstable.") {
+			return common.RuntimeType_GASM
+		}
+	}
+	return common.RuntimeType_WASMER
+}
+
+func (c *Contract) loadInterface(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read WASM interface: %v", err)
+	}
+	var desc Interface
+	if err := json.Unmarshal(data, &desc); err != nil {
+		return fmt.Errorf("failed to parse WASM interface %s: %v", path, err)
+	}
+
+	if desc.Runtime != "" {
+		runtime, ok := common.RuntimeType_value[strings.ToUpper(desc.Runtime)]
+		if !ok || (common.RuntimeType(runtime) != common.RuntimeType_WASMER && common.RuntimeType(runtime) != common.RuntimeType_GASM) {
+			return fmt.Errorf("unsupported WASM runtime %q in %s (expected WASMER or GASM)", desc.Runtime, path)
+		}
+		c.Runtime = common.RuntimeType(runtime)
+	}
+	for key, value := range desc.InitParams {
+		c.InitParams[key] = value
+	}
+	for _, method := range desc.Methods {
+		if method.Name == "" {
+			continue
+		}
+		for _, param := range method.Params {
+			if param.Type == "" {
+				param.Type = "string"
+			}
+		}
+		c.Methods[method.Name] = &Method{Name: method.Name, Params: method.Params}
+	}
+	return nil
+}
+
+// 由导出函数与函数体中引用的字符串推断方法与参数键
+func (c *Contract) inferMethods(m *module) {
+	names := make([]string, 0)
+	for _, name := range m.exportOrder {
+		if reservedExports[name] || strings.HasPrefix(name, "_") || strings.HasPrefix(name, "dynCall_") ||
+			!identifierRegexp.MatchString(name) || m.exportBody(name) == nil {
+			continue
+		}
+		names = append(names, name)
+	}
+
+	// 过半方法都引用的字符串多为 SDK 内部使用的键（如 key、field），不作为参数
+	strs := make(map[string][]string)
+	refs := make(map[string]int)
+	for _, name := range append(names, InitMethod) {
+		strs[name] = uniqueStrings(m.bodyStrings(m.exportBody(name)))
+		for _, s := range strs[name] {
+			refs[s]++
+		}
+	}
+	isKey := func(s string) bool {
+		if strings.HasPrefix(s, "__") || unicode.IsUpper(rune(s[0])) || refs[s]*2 > len(names)+1 {
+			return false
+		}
+		_, isMethod := m.exports[s]
+		return !isMethod
+	}
+
+	for _, name := range names {
+		method := &Method{Name: name}
+		for _, s := range strs[name] {
+			if isKey(s) && len(method.Params) < maxInferredParams {
+				method.Params = append(method.Params, &Param{Name: s, Type: "string"})
+			}
+		}
+		c.Methods[name] = method
+	}
+
+	// 初始化参数同样由 init_contract 中引用的字符串推断，初始化函数中的方法名也可能是参数键
+	for _, s := range strs[InitMethod] {
+		if !strings.HasPrefix(s, "__") && !unicode.IsUpper(rune(s[0])) && len(c.InitParams) < maxInferredParams {
+			c.InitParams[s] = defaultInitValue
+		}
+	}
+}
+
+func uniqueStrings(strs []string) []string {
+	seen := make(map[string]bool)
+	result := make([]string, 0, len(strs))
+	for _, s := range strs {
+		if !seen[s] {
+			seen[s] = true
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// 按方法名排序的方法列表
+func (c *Contract) SortedMethods() []*Method {
+	methods := make([]*Method, 0, len(c.Methods))
+	for _, method := range c.Methods {
+		methods = append(methods, method)
+	}
+	sort.Slice(methods, func(i, j int) bool { return methods[i].Name < methods[j].Name })
+	return methods
+}
+
+// 参数名列表
+func (m *Method) ParamNames() []string {
+	names := make([]string, 0, len(m.Params))
+	for _, param := range m.Params {
+		names = append(names, param.Name)
+	}
+	return names
+}
+
+// 参数类型及其初始值，未知类型按字符串处理
+func (p *Param) GoType() (types.Type, interface{}) {
+	switch p.Type {
+	case "int":
+		return types.Typ[types.Int], int(0)
+	case "int32":
+		return types.Typ[types.Int32], int32(0)
+	case "int64":
+		return types.Typ[types.Int64], int64(0)
+	case "uint":
+		return types.Typ[types.Uint], uint(0)
+	case "uint32":
+		return types.Typ[types.Uint32], uint32(0)
+	case "uint64":
+		return types.Typ[types.Uint64], uint64(0)
+	case "bool":
+		return types.Typ[types.Bool], false
+	default:
+		return types.Typ[types.String], defaultParamValue
+	}
+}
+
+// DeployPayload 部署所需的运行时与初始化参数，字节码直接使用模块文件
+func (c *Contract) DeployPayload() (common.RuntimeType, []*common.KeyValuePair) {
+	keys := make([]string, 0, len(c.InitParams))
+	for key := range c.InitParams {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	kvs := make([]*common.KeyValuePair, 0, len(keys))
+	for _, key := range keys {
+		kvs = append(kvs, &common.KeyValuePair{Key: key, Value: []byte(c.InitParams[key])})
+	}
+	return c.Runtime, kvs
+}
@@ -0,0 +1,407 @@
+/*
+	本文件主要用于：
+
+	1. 解析 WASM 模块的二进制格式，读取导入、导出、函数体、数据段与 producers 自定义段
+
+	2. 在函数体中查找连续的两条 i32.const 指令，若二者恰好指向数据段中的一段标识符字符串，
+	   则视为该函数引用的字符串（Rust 与 TinyGo 均以指针与长度传递字符串常量），用于推断参数键
+*/
+
+package wasm
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+var (
+	wasmMagic   = []byte{0x00, 0x61, 0x73, 0x6d}
+	wasmVersion = []byte{0x01, 0x00, 0x00, 0x00}
+)
+
+const (
+	sectionCustom = 0
+	sectionImport = 2
+	sectionExport = 7
+	sectionCode   = 10
+	sectionData   = 11
+
+	externalFunction = 0
+	externalTable    = 1
+	externalMemory   = 2
+	externalGlobal   = 3
+
+	opI32Const = 0x41
+	opEnd      = 0x0b
+)
+
+// 参数键最长长度
+const maxKeyLength = 32
+
+var identifierRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+type module struct {
+	imports       []string          // 导入项，形如 env.sys_call
+	importedFuncs int               // 导入函数数量，函数下标从导入函数开始计数
+	exports       map[string]uint32 // 导出函数名与函数下标
+	exportOrder   []string          // 导出函数在模块中的顺序
+	bodies        [][]byte          // 模块内定义的函数体
+	data          []dataSegment
+	producers     []byte // producers 自定义段原始内容
+}
+
+type dataSegment struct {
+	offset uint32
+	data   []byte
+}
+
+func parseModule(data []byte) (*module, error) {
+	if len(data) < 8 || !bytes.Equal(data[:4], wasmMagic) {
+		return nil, errors.New("not a WASM module")
+	}
+	if !bytes.Equal(data[4:8], wasmVersion) {
+		return nil, fmt.Errorf("unsupported WASM version % x", data[4:8])
+	}
+
+	m := &module{exports: make(map[string]uint32)}
+	r := &reader{data: data, pos: 8}
+	for !r.done() {
+		id, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		size, err := r.u32()
+		if err != nil {
+			return nil, err
+		}
+		content, err := r.bytes(int(size))
+		if err != nil {
+			return nil, err
+		}
+
+		section := &reader{data: content}
+		switch id {
+		case sectionCustom:
+			err = m.parseCustom(section)
+		case sectionImport:
+			err = m.parseImports(section)
+		case sectionExport:
+			err = m.parseExports(section)
+		case sectionCode:
+			err = m.parseCode(section)
+		case sectionData:
+			err = m.parseData(section)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("section %d: %v", id, err)
+		}
+	}
+	return m, nil
+}
+
+func (m *module) parseCustom(r *reader) error {
+	name, err := r.name()
+	if err != nil {
+		return err
+	}
+	if name == "producers" {
+		m.producers = r.data[r.pos:]
+	}
+	return nil
+}
+
+func (m *module) parseImports(r *reader) error {
+	count, err := r.u32()
+	if err != nil {
+		return err
+	}
+	for i := uint32(0); i < count; i++ {
+		moduleName, err := r.name()
+		if err != nil {
+			return err
+		}
+		name, err := r.name()
+		if err != nil {
+			return err
+		}
+		m.imports = append(m.imports, moduleName+"."+name)
+
+		kind, err := r.byte()
+		if err != nil {
+			return err
+		}
+		switch kind {
+		case externalFunction:
+			m.importedFuncs++
+			_, err = r.u32()
+		case externalTable:
+			if _, err = r.byte(); err == nil {
+				err = r.limits()
+			}
+		case externalMemory:
+			err = r.limits()
+		case externalGlobal:
+			_, err = r.bytes(2)
+		default:
+			err = fmt.Errorf("unknown import kind %d", kind)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *module) parseExports(r *reader) error {
+	count, err := r.u32()
+	if err != nil {
+		return err
+	}
+	for i := uint32(0); i < count; i++ {
+		name, err := r.name()
+		if err != nil {
+			return err
+		}
+		kind, err := r.byte()
+		if err != nil {
+			return err
+		}
+		index, err := r.u32()
+		if err != nil {
+			return err
+		}
+		if kind == externalFunction {
+			m.exports[name] = index
+			m.exportOrder = append(m.exportOrder, name)
+		}
+	}
+	return nil
+}
+
+func (m *module) parseCode(r *reader) error {
+	count, err := r.u32()
+	if err != nil {
+		return err
+	}
+	for i := uint32(0); i < count; i++ {
+		size, err := r.u32()
+		if err != nil {
+			return err
+		}
+		body, err := r.bytes(int(size))
+		if err != nil {
+			return err
+		}
+		m.bodies = append(m.bodies, body)
+	}
+	return nil
+}
+
+func (m *module) parseData(r *reader) error {
+	count, err := r.u32()
+	if err != nil {
+		return err
+	}
+	for i := uint32(0); i < count; i++ {
+		flag, err := r.u32()
+		if err != nil {
+			return err
+		}
+		if flag > 2 {
+			return fmt.Errorf("unknown data segment flag %d", flag)
+		}
+		if flag == 2 {
+			if _, err := r.u32(); err != nil {
+				return err
+			}
+		}
+
+		// 仅处理偏移为 i32.const 常量的主动数据段
+		offset, static := int64(0), false
+		if flag == 0 || flag == 2 {
+			if offset, static, err = r.constExpr(); err != nil {
+				return err
+			}
+		}
+
+		size, err := r.u32()
+		if err != nil {
+			return err
+		}
+		data, err := r.bytes(int(size))
+		if err != nil {
+			return err
+		}
+		if static {
+			m.data = append(m.data, dataSegment{offset: uint32(offset), data: data})
+		}
+	}
+	return nil
+}
+
+// 导出函数的函数体，导入函数没有函数体
+func (m *module) exportBody(name string) []byte {
+	index, ok := m.exports[name]
+	if !ok || int(index) < m.importedFuncs || int(index)-m.importedFuncs >= len(m.bodies) {
+		return nil
+	}
+	return m.bodies[int(index)-m.importedFuncs]
+}
+
+// 读取数据段中 [addr, addr+length) 的内容
+func (m *module) readData(addr, length uint32) []byte {
+	for _, segment := range m.data {
+		start := uint64(segment.offset)
+		if uint64(addr) >= start && uint64(addr)+uint64(length) <= start+uint64(len(segment.data)) {
+			return segment.data[addr-segment.offset : addr-segment.offset+length]
+		}
+	}
+	return nil
+}
+
+// 函数体中以指针与长度引用的标识符字符串，按出现顺序返回
+func (m *module) bodyStrings(body []byte) []string {
+	strs := make([]string, 0)
+	for i := 0; i < len(body); i++ {
+		if body[i] != opI32Const {
+			continue
+		}
+		r := &reader{data: body, pos: i + 1}
+		addr, err := r.s32()
+		if err != nil || r.done() || body[r.pos] != opI32Const {
+			continue
+		}
+		r.pos++
+		length, err := r.s32()
+		if err != nil || length <= 0 || length > maxKeyLength {
+			continue
+		}
+		if s := m.readData(uint32(addr), uint32(length)); s != nil && identifierRegexp.Match(s) {
+			strs = append(strs, string(s))
+		}
+	}
+	return strs
+}
+
+type reader struct {
+	data []byte
+	pos  int
+}
+
+func (r *reader) done() bool {
+	return r.pos >= len(r.data)
+}
+
+func (r *reader) byte() (byte, error) {
+	if r.done() {
+		return 0, errors.New("unexpected end of data")
+	}
+	b := r.data[r.pos]
+	r.pos++
+	return b, nil
+}
+
+func (r *reader) bytes(n int) ([]byte, error) {
+	if n < 0 || r.pos+n > len(r.data) {
+		return nil, errors.New("unexpected end of data")
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b, nil
+}
+
+// 无符号 LEB128，最多 5 字节，第 5 字节中超出 32 位的部分必须为 0
+func (r *reader) u32() (uint32, error) {
+	var result uint64
+	for shift := uint(0); shift < 35; shift += 7 {
+		b, err := r.byte()
+		if err != nil {
+			return 0, err
+		}
+		if shift == 28 && b&0x70 != 0 {
+			return 0, errors.New("LEB128 value overflows u32")
+		}
+		result |= uint64(b&0x7f) << shift
+		if b < 0x80 {
+			return uint32(result), nil
+		}
+	}
+	return 0, errors.New("invalid LEB128")
+}
+
+// 有符号 LEB128，最多 5 字节，第 5 字节中超出 32 位的部分必须与符号位一致
+func (r *reader) s32() (int32, error) {
+	var result int64
+	for shift := uint(0); shift < 35; shift += 7 {
+		b, err := r.byte()
+		if err != nil {
+			return 0, err
+		}
+		if shift == 28 && b&0x78 != 0 && b&0x78 != 0x78 {
+			return 0, errors.New("LEB128 value overflows s32")
+		}
+		result |= int64(b&0x7f) << shift
+		if b < 0x80 {
+			if b&0x40 != 0 {
+				result -= int64(1) << (shift + 7)
+			}
+			return int32(result), nil
+		}
+	}
+	return 0, errors.New("invalid LEB128")
+}
+
+func (r *reader) name() (string, error) {
+	n, err := r.u32()
+	if err != nil {
+		return "", err
+	}
+	b, err := r.bytes(int(n))
+	return string(b), err
+}
+
+func (r *reader) limits() error {
+	flag, err := r.byte()
+	if err != nil {
+		return err
+	}
+	if _, err := r.u32(); err != nil {
+		return err
+	}
+	if flag&1 != 0 {
+		_, err = r.u32()
+	}
+	return err
+}
+
+// 常量表达式，仅 i32.const 时返回其值
+func (r *reader) constExpr() (int64, bool, error) {
+	op, err := r.byte()
+	if err != nil {
+		return 0, false, err
+	}
+	if op == opI32Const {
+		value, err := r.s32()
+		if err != nil {
+			return 0, false, err
+		}
+		end, err := r.byte()
+		if err != nil || end != opEnd {
+			return 0, false, errors.New("invalid constant expression")
+		}
+		return int64(uint32(value)), true, nil
+	}
+
+	// 其余表达式（如 global.get）跳过至 end
+	for {
+		b, err := r.byte()
+		if err != nil {
+			return 0, false, err
+		}
+		if b == opEnd {
+			return 0, false, nil
+		}
+	}
+}
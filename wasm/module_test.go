@@ -0,0 +1,210 @@
+package wasm
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestReaderU32(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		want    uint32
+		wantErr bool
+	}{
+		{"zero", []byte{0x00}, 0, false},
+		{"one byte max", []byte{0x7f}, 127, false},
+		{"two bytes", []byte{0x80, 0x01}, 128, false},
+		{"624485", []byte{0xe5, 0x8e, 0x26}, 624485, false},
+		{"padded zero", []byte{0x80, 0x80, 0x00}, 0, false},
+		{"max u32", []byte{0xff, 0xff, 0xff, 0xff, 0x0f}, 0xffffffff, false},
+		{"empty", []byte{}, 0, true},
+		{"truncated", []byte{0x80}, 0, true},
+		{"truncated after four bytes", []byte{0xff, 0xff, 0xff, 0xff}, 0, true},
+		{"overflows u32", []byte{0xff, 0xff, 0xff, 0xff, 0x1f}, 0, true},
+		{"unused bits set", []byte{0x80, 0x80, 0x80, 0x80, 0x70}, 0, true},
+		{"longer than five bytes", []byte{0x80, 0x80, 0x80, 0x80, 0x80, 0x00}, 0, true},
+	}
+
+	for _, tt := range tests {
+		r := &reader{data: tt.data}
+		got, err := r.u32()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: u32(% x) error = %v, wantErr %v", tt.name, tt.data, err, tt.wantErr)
+			continue
+		}
+		if err == nil && (got != tt.want || !r.done()) {
+			t.Errorf("%s: u32(% x) = %d (read %d bytes), want %d (read %d bytes)", tt.name, tt.data, got, r.pos, tt.want, len(tt.data))
+		}
+	}
+}
+
+func TestReaderS32(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		want    int32
+		wantErr bool
+	}{
+		{"zero", []byte{0x00}, 0, false},
+		{"one", []byte{0x01}, 1, false},
+		{"minus one", []byte{0x7f}, -1, false},
+		{"63", []byte{0x3f}, 63, false},
+		{"64", []byte{0xc0, 0x00}, 64, false},
+		{"minus 64", []byte{0x40}, -64, false},
+		{"minus 65", []byte{0xbf, 0x7f}, -65, false},
+		{"-123456", []byte{0xc0, 0xbb, 0x78}, -123456, false},
+		{"padded minus one", []byte{0xff, 0x7f}, -1, false},
+		{"max s32", []byte{0xff, 0xff, 0xff, 0xff, 0x07}, 2147483647, false},
+		{"min s32", []byte{0x80, 0x80, 0x80, 0x80, 0x78}, -2147483648, false},
+		{"empty", []byte{}, 0, true},
+		{"truncated", []byte{0xff}, 0, true},
+		{"overflows s32", []byte{0xff, 0xff, 0xff, 0xff, 0x0f}, 0, true},
+		{"unused bits differ from sign", []byte{0x80, 0x80, 0x80, 0x80, 0x70}, 0, true},
+		{"longer than five bytes", []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0x7f}, 0, true},
+	}
+
+	for _, tt := range tests {
+		r := &reader{data: tt.data}
+		got, err := r.s32()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: s32(% x) error = %v, wantErr %v", tt.name, tt.data, err, tt.wantErr)
+			continue
+		}
+		if err == nil && (got != tt.want || !r.done()) {
+			t.Errorf("%s: s32(% x) = %d (read %d bytes), want %d (read %d bytes)", tt.name, tt.data, got, r.pos, tt.want, len(tt.data))
+		}
+	}
+}
+
+// 按 WASM 二进制格式拼接测试用模块
+func testSection(id byte, content ...[]byte) []byte {
+	body := bytes.Join(content, nil)
+	return append([]byte{id, byte(len(body))}, body...)
+}
+
+func testName(s string) []byte {
+	return append([]byte{byte(len(s))}, s...)
+}
+
+func testModule(sections ...[]byte) []byte {
+	return append(append(append([]byte{}, wasmMagic...), wasmVersion...), bytes.Join(sections, nil)...)
+}
+
+// 导入 env.sys_call 与内存，导出 save(函数 1)、memory 与 get(函数 2)，
+// save 的函数体以指针与长度引用数据段中的 "key"
+func validModule() []byte {
+	saveBody := []byte{0x00, opI32Const, 0x84, 0x08, opI32Const, 0x03, 0x1a, 0x1a, opEnd}
+	getBody := []byte{0x00, opEnd}
+	return testModule(
+		testSection(sectionCustom, testName("producers"), []byte{0x01}),
+		testSection(sectionImport, []byte{0x02},
+			testName("env"), testName("sys_call"), []byte{externalFunction, 0x00},
+			testName("env"), testName("memory"), []byte{externalMemory, 0x00, 0x01}),
+		testSection(sectionExport, []byte{0x03},
+			testName("save"), []byte{externalFunction, 0x01},
+			testName("memory"), []byte{externalMemory, 0x00},
+			testName("get"), []byte{externalFunction, 0x02}),
+		testSection(sectionCode, []byte{0x02},
+			[]byte{byte(len(saveBody))}, saveBody,
+			[]byte{byte(len(getBody))}, getBody),
+		testSection(sectionData, []byte{0x01},
+			[]byte{0x00, opI32Const, 0x80, 0x08, opEnd},
+			testName("xxxxkeyxx")),
+	)
+}
+
+func TestParseModule(t *testing.T) {
+	m, err := parseModule(validModule())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"env.sys_call", "env.memory"}; !reflect.DeepEqual(m.imports, want) {
+		t.Errorf("imports = %v, want %v", m.imports, want)
+	}
+	if m.importedFuncs != 1 {
+		t.Errorf("importedFuncs = %d, want 1", m.importedFuncs)
+	}
+	if want := map[string]uint32{"save": 1, "get": 2}; !reflect.DeepEqual(m.exports, want) {
+		t.Errorf("exports = %v, want %v", m.exports, want)
+	}
+	if want := []string{"save", "get"}; !reflect.DeepEqual(m.exportOrder, want) {
+		t.Errorf("exportOrder = %v, want %v", m.exportOrder, want)
+	}
+	if len(m.bodies) != 2 {
+		t.Fatalf("bodies = %d, want 2", len(m.bodies))
+	}
+	if want := []dataSegment{{offset: 1024, data: []byte("xxxxkeyxx")}}; !reflect.DeepEqual(m.data, want) {
+		t.Errorf("data = %v, want %v", m.data, want)
+	}
+	if !bytes.Equal(m.producers, []byte{0x01}) {
+		t.Errorf("producers = % x, want 01", m.producers)
+	}
+
+	if got := m.bodyStrings(m.exportBody("save")); !reflect.DeepEqual(got, []string{"key"}) {
+		t.Errorf("strings of save = %v, want [key]", got)
+	}
+	if got := m.bodyStrings(m.exportBody("get")); len(got) != 0 {
+		t.Errorf("strings of get = %v, want none", got)
+	}
+	if body := m.exportBody("sys_call"); body != nil {
+		t.Errorf("body of an import = % x, want nil", body)
+	}
+}
+
+func TestParseModuleMalformed(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", []byte{}},
+		{"bad magic", []byte{0x00, 0x61, 0x73, 0x6e, 0x01, 0x00, 0x00, 0x00}},
+		{"bad version", []byte{0x00, 0x61, 0x73, 0x6d, 0x02, 0x00, 0x00, 0x00}},
+		{"section size past end", testModule([]byte{sectionExport, 0x10, 0x00})},
+		{"overlong section size", testModule([]byte{sectionExport, 0xff, 0xff, 0xff, 0xff, 0x7f})},
+		{"export name past section", testModule(testSection(sectionExport, []byte{0x01, 0x09}, []byte("save")))},
+		{"export count past section", testModule(testSection(sectionExport, []byte{0x02}, testName("save"), []byte{externalFunction, 0x00}))},
+		{"truncated export index", testModule(testSection(sectionExport, []byte{0x01}, testName("save"), []byte{externalFunction, 0x80}))},
+		{"unknown import kind", testModule(testSection(sectionImport, []byte{0x01}, testName("env"), testName("f"), []byte{0x04}))},
+		{"function body past section", testModule(testSection(sectionCode, []byte{0x01, 0x05, 0x00, opEnd}))},
+		{"unknown data segment flag", testModule(testSection(sectionData, []byte{0x01, 0x03, 0x00}))},
+		{"bad data offset expression", testModule(testSection(sectionData, []byte{0x01, 0x00, opI32Const, 0x00, 0x00, 0x00}))},
+		{"unterminated data offset expression", testModule(testSection(sectionData, []byte{0x01, 0x00, 0x23, 0x00}))},
+	}
+
+	for _, tt := range tests {
+		if _, err := parseModule(tt.data); err == nil {
+			t.Errorf("%s: parseModule(% x) returned no error", tt.name, tt.data)
+		}
+	}
+}
+
+// 截断或改写合法模块中的任意字节，解析只能返回错误而不能 panic
+func TestParseModuleCorrupted(t *testing.T) {
+	valid := validModule()
+	parse := func(data []byte) {
+		defer func() {
+			if r := recover(); r != nil {
+				t.Fatalf("parseModule(% x) panicked: %v", data, r)
+			}
+		}()
+		if m, err := parseModule(data); err == nil {
+			for _, name := range m.exportOrder {
+				m.bodyStrings(m.exportBody(name))
+			}
+		}
+	}
+
+	for i := 0; i < len(valid); i++ {
+		parse(valid[:i])
+	}
+	for i := 8; i < len(valid); i++ {
+		for _, b := range []byte{0x00, 0x01, 0x7f, 0x80, 0xff} {
+			data := append([]byte{}, valid...)
+			data[i] = b
+			parse(data)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"TransactionRwset/engine"
+	"TransactionRwset/fuzz"
+	"TransactionRwset/report"
+	"TransactionRwset/utils"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// worker 子命令，返回进程退出码
+// 用法: worker -sync-dir <dir> -index I -workers N [-id name] [-shared-chain | -release-dir <dir> -sdk-config <yml>]
+func workerCommand(args []string) int {
+	flags := flag.NewFlagSet("worker", flag.ExitOnError)
+	opts := &engine.WorkerOptions{}
+	flags.StringVar(&opts.Sync.Dir, "sync-dir", "", "Shared directory the workers publish and import seeds through (local or a network mount)")
+	flags.StringVar(&opts.Sync.WorkerID, "id", "", "Worker name, unique among the workers (default worker<index>)")
+	flags.IntVar(&opts.Sync.Index, "index", 0, "Index of this worker, 0 is the main worker that generates the seed pool")
+	flags.IntVar(&opts.Sync.Workers, "workers", 1, "Total number of workers, used to split the mutable pairs")
+	flags.DurationVar(&opts.Sync.Interval, "sync-interval", 30*time.Second, "Interval of importing seeds published by the other workers")
+	flags.DurationVar(&opts.Sync.IdleTimeout, "idle-timeout", 10*time.Minute, "Maximum time to wait for new seeds once the local queues are empty")
+	flags.BoolVar(&opts.SharedChain, "shared-chain", false, "Use a running chain shared with the other workers and deploy a separate contract instance on it")
+	flags.StringVar(&opts.SDKConfig, "sdk-config", "", "SDK config of the chain this worker uses (empty uses the one of the auth mode)")
+	flags.StringVar(&opts.PoolFile, "load", "", "Path to JSON file to load the pair seeds pool on the main worker (empty generates it)")
+	flags.DurationVar(&opts.PoolWait, "pool-wait", 30*time.Minute, "Maximum time the other workers wait for the main worker to publish the seed pool")
+	contractPath := flags.String("contract", defaultContractPath, "Path to the contract source (.go), EVM ABI (.abi) or WASM module (.wasm)")
+	seed := flags.Int64("seed", 0, "Master random seed of this worker (0 picks one from the current time)")
+	flags.DurationVar(&fuzz.SampleInterval, "sample-interval", fuzz.SampleInterval, "Interval of tx pool and node state sampling during experiments (0 disables sampling)")
+	flags.DurationVar(&fuzz.Schedule.TimeBudget, "time-budget", fuzz.Schedule.TimeBudget, "Global time budget of the mutation phase (0 means unlimited)")
+	flags.BoolVar(&engine.SnapshotLedger, "snapshot-ledger", engine.SnapshotLedger, "Snapshot every node's ledger after deployment and restore it before each conflict experiment (own chain only)")
+	flags.IntVar(&fuzz.MaxMinimizeExecs, "minimize-execs", fuzz.MaxMinimizeExecs, "Maximum transactions executed when minimizing a conflict seed (0 disables minimization)")
+	registerClusterFlags(flags)
+	registerBuildFlags(flags)
+	logLevel := flags.String("log-level", utils.DefaultLogLevel.String(), "Minimum level of events written to the event log (debug, info, warn, error)")
+	flags.Parse(args)
+
+	if opts.Sync.Dir == "" {
+		fmt.Println("worker: -sync-dir is required")
+		flags.Usage()
+		return 2
+	}
+	if opts.Sync.WorkerID == "" {
+		opts.Sync.WorkerID = fmt.Sprintf("worker%d", opts.Sync.Index)
+	}
+	if opts.SharedChain {
+		engine.SnapshotLedger = false
+	}
+
+	level, err := utils.ParseLogLevel(*logLevel)
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	utils.DefaultLogLevel = level
+	if *seed != 0 {
+		utils.SetSeed(*seed)
+	}
+
+	err = engine.RunWorker(*contractPath, opts)
+	if err != nil {
+		fmt.Printf("Error running worker %s: %v\n", opts.Sync.WorkerID, err)
+	}
+	engine.Stop()
+
+	if utils.Log != nil {
+		reportPath, err := report.Generate(utils.Log.BaseDir)
+		if err != nil {
+			fmt.Printf("Error generating report: %v\n", err)
+		} else {
+			fmt.Printf("Report generated: %s\n", reportPath)
+		}
+	}
+
+	if err != nil {
+		return 1
+	}
+	return 0
+}
+
+// distribute 子命令：在本机启动多个 worker 进程，返回进程退出码
+// 用法: distribute -workers N -sync-dir <dir> [-release-dirs a,b -sdk-configs x,y] [-- worker 参数]
+func distributeCommand(args []string) int {
+	flags := flag.NewFlagSet("distribute", flag.ExitOnError)
+	opts := &engine.DistributeOptions{}
+	flags.IntVar(&opts.Workers, "workers", 2, "Number of worker processes to start")
+	flags.StringVar(&opts.SyncDir, "sync-dir", "./result/sync", "Parent of the shared directories, every run uses a new subdirectory named by its start time")
+	releaseDirs := flags.String("release-dirs", "", "Comma separated release dirs, one cluster per worker (empty shares one running chain)")
+	sdkConfigs := flags.String("sdk-configs", "", "Comma separated SDK configs matching -release-dirs")
+	flags.Int64Var(&opts.Seed, "seed", 0, "Master random seed, worker i uses seed+i (0 lets every worker pick one)")
+	flags.Parse(args)
+
+	if *releaseDirs != "" {
+		opts.ReleaseDirs = strings.Split(*releaseDirs, ",")
+	}
+	if *sdkConfigs != "" {
+		opts.SDKConfigs = strings.Split(*sdkConfigs, ",")
+	}
+	// "--" 之后的参数原样传给每个 worker
+	opts.WorkerArgs = flags.Args()
+
+	runs, err := engine.Distribute(opts)
+	if err != nil {
+		fmt.Printf("Error starting workers: %v\n", err)
+		return 1
+	}
+
+	failed := 0
+	for _, run := range runs {
+		status := "ok"
+		if run.Err != nil {
+			status = run.Err.Error()
+			failed++
+		}
+		fmt.Printf("  %-10s %-12s %s\n", run.ID, run.Duration.Round(time.Second), status)
+	}
+	for _, stats := range fuzz.LoadAllSyncStats(opts.RunDir) {
+		fmt.Printf("  %-10s assigned %d, published %d, imported %d (%d duplicates), experiments %d, claimed elsewhere %d\n",
+			stats.Worker, stats.Assigned, stats.Published, stats.Imported, stats.Duplicates, stats.ClaimsWon, stats.ClaimsLost)
+	}
+	fmt.Printf("Worker logs saved to: %s\n", opts.RunDir)
+
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}